@@ -0,0 +1,380 @@
+package spacetimedb
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// AlgebraicTypeKind is the kind of an AlgebraicType.
+type AlgebraicTypeKind uint8
+
+const (
+	AlgebraicTypeInvalid AlgebraicTypeKind = iota
+	AlgebraicTypeRef
+	AlgebraicTypeSum
+	AlgebraicTypeProduct
+	AlgebraicTypeArray
+	AlgebraicTypeMap
+	AlgebraicTypeString
+	AlgebraicTypeBool
+	AlgebraicTypeI8
+	AlgebraicTypeU8
+	AlgebraicTypeI16
+	AlgebraicTypeU16
+	AlgebraicTypeI32
+	AlgebraicTypeU32
+	AlgebraicTypeI64
+	AlgebraicTypeU64
+	AlgebraicTypeI128
+	AlgebraicTypeU128
+	AlgebraicTypeI256
+	AlgebraicTypeU256
+	AlgebraicTypeF32
+	AlgebraicTypeF64
+)
+
+var algebraicTypeKindNames = [...]string{
+	AlgebraicTypeInvalid: "Invalid",
+	AlgebraicTypeRef:     "Ref",
+	AlgebraicTypeSum:     "Sum",
+	AlgebraicTypeProduct: "Product",
+	AlgebraicTypeArray:   "Array",
+	AlgebraicTypeMap:     "Map",
+	AlgebraicTypeString:  "String",
+	AlgebraicTypeBool:    "Bool",
+	AlgebraicTypeI8:      "I8",
+	AlgebraicTypeU8:      "U8",
+	AlgebraicTypeI16:     "I16",
+	AlgebraicTypeU16:     "U16",
+	AlgebraicTypeI32:     "I32",
+	AlgebraicTypeU32:     "U32",
+	AlgebraicTypeI64:     "I64",
+	AlgebraicTypeU64:     "U64",
+	AlgebraicTypeI128:    "I128",
+	AlgebraicTypeU128:    "U128",
+	AlgebraicTypeI256:    "I256",
+	AlgebraicTypeU256:    "U256",
+	AlgebraicTypeF32:     "F32",
+	AlgebraicTypeF64:     "F64",
+}
+
+func (k AlgebraicTypeKind) String() string {
+	if int(k) < len(algebraicTypeKindNames) {
+		return algebraicTypeKindNames[k]
+	}
+	return fmt.Sprintf("AlgebraicTypeKind(%d)", k)
+}
+
+// IsPrimitive reports whether k is one of the builtin types without a structure, such as U32 or String.
+func (k AlgebraicTypeKind) IsPrimitive() bool {
+	return k >= AlgebraicTypeString && int(k) < len(algebraicTypeKindNames)
+}
+
+// AlgebraicType is a type in SpacetimeDB's type system (SATS). The field that is set depends on
+// Kind, primitive types only set Kind.
+type AlgebraicType struct {
+	Kind AlgebraicTypeKind
+	// Ref is the index of the referred type in the Typespace of a Ref type.
+	Ref     uint32
+	Sum     *SumType
+	Product *ProductType
+	// Elem is the element type of an Array type.
+	Elem *AlgebraicType
+	Map  *MapType
+}
+
+// ProductType is a struct-like type with a list of elements, which are usually named.
+type ProductType struct {
+	Elements []ProductTypeElement `json:"elements"`
+}
+
+type ProductTypeElement struct {
+	// Name is the name of the element, or an empty string if it has none.
+	Name string
+	Type AlgebraicType
+}
+
+// SumType is a tagged union. The tag of a value is the index of its variant.
+type SumType struct {
+	Variants []SumTypeVariant `json:"variants"`
+}
+
+type SumTypeVariant struct {
+	// Name is the name of the variant, or an empty string if it has none.
+	Name string
+	Type AlgebraicType
+}
+
+type MapType struct {
+	Key   AlgebraicType `json:"key_ty"`
+	Value AlgebraicType `json:"ty"`
+}
+
+// Typespace holds the types of a module, which Ref types refer to by index.
+type Typespace struct {
+	Types []AlgebraicType `json:"types"`
+}
+
+// Names of the only element of the product types that have a Go type in the SDK.
+const (
+	identityElementName     = "__identity__"
+	connectionIdElementName = "__connection_id__"
+	timestampElementName    = "__timestamp_micros_since_unix_epoch__"
+	timeDurationElementName = "__time_duration_micros__"
+)
+
+func NewRefType(ref uint32) AlgebraicType {
+	return AlgebraicType{Kind: AlgebraicTypeRef, Ref: ref}
+}
+
+func NewArrayType(elem AlgebraicType) AlgebraicType {
+	return AlgebraicType{Kind: AlgebraicTypeArray, Elem: &elem}
+}
+
+func NewMapType(key, value AlgebraicType) AlgebraicType {
+	return AlgebraicType{Kind: AlgebraicTypeMap, Map: &MapType{Key: key, Value: value}}
+}
+
+func NewProductType(elements ...ProductTypeElement) AlgebraicType {
+	return AlgebraicType{Kind: AlgebraicTypeProduct, Product: &ProductType{Elements: elements}}
+}
+
+func NewSumType(variants ...SumTypeVariant) AlgebraicType {
+	return AlgebraicType{Kind: AlgebraicTypeSum, Sum: &SumType{Variants: variants}}
+}
+
+// NewUnitType returns the empty product type, which is the type of sum variants without a value.
+func NewUnitType() AlgebraicType {
+	return NewProductType()
+}
+
+// NewOptionType returns the type of an Option, which is a sum of some with the value and none.
+func NewOptionType(value AlgebraicType) AlgebraicType {
+	return NewSumType(
+		SumTypeVariant{Name: "some", Type: value},
+		SumTypeVariant{Name: "none", Type: NewUnitType()},
+	)
+}
+
+func IdentityType() AlgebraicType {
+	return NewProductType(ProductTypeElement{Name: identityElementName, Type: AlgebraicType{Kind: AlgebraicTypeU256}})
+}
+
+func ConnectionIdType() AlgebraicType {
+	return NewProductType(ProductTypeElement{Name: connectionIdElementName, Type: AlgebraicType{Kind: AlgebraicTypeU128}})
+}
+
+func TimestampType() AlgebraicType {
+	return NewProductType(ProductTypeElement{Name: timestampElementName, Type: AlgebraicType{Kind: AlgebraicTypeI64}})
+}
+
+func TimeDurationType() AlgebraicType {
+	return NewProductType(ProductTypeElement{Name: timeDurationElementName, Type: AlgebraicType{Kind: AlgebraicTypeI64}})
+}
+
+// specialElement returns the name of the only element of a product type with a Go type in the SDK,
+// or an empty string.
+func (t AlgebraicType) specialElement() string {
+	if t.Kind != AlgebraicTypeProduct || t.Product == nil || len(t.Product.Elements) != 1 {
+		return ""
+	}
+	element := t.Product.Elements[0]
+	switch {
+	case element.Name == identityElementName && element.Type.Kind == AlgebraicTypeU256,
+		element.Name == connectionIdElementName && element.Type.Kind == AlgebraicTypeU128,
+		element.Name == timestampElementName && element.Type.Kind == AlgebraicTypeI64,
+		element.Name == timeDurationElementName && element.Type.Kind == AlgebraicTypeI64:
+		return element.Name
+	default:
+		return ""
+	}
+}
+
+func (t AlgebraicType) IsIdentity() bool     { return t.specialElement() == identityElementName }
+func (t AlgebraicType) IsConnectionId() bool { return t.specialElement() == connectionIdElementName }
+func (t AlgebraicType) IsTimestamp() bool    { return t.specialElement() == timestampElementName }
+func (t AlgebraicType) IsTimeDuration() bool { return t.specialElement() == timeDurationElementName }
+
+// IsSpecial reports whether t is Identity, ConnectionId, Timestamp or TimeDuration.
+func (t AlgebraicType) IsSpecial() bool {
+	return t.specialElement() != ""
+}
+
+// IsUnit reports whether t is the empty product type.
+func (t AlgebraicType) IsUnit() bool {
+	return t.Kind == AlgebraicTypeProduct && (t.Product == nil || len(t.Product.Elements) == 0)
+}
+
+// Option returns the value type of an Option type. It reports false if t is not an Option.
+func (t AlgebraicType) Option() (AlgebraicType, bool) {
+	if t.Kind != AlgebraicTypeSum || t.Sum == nil || len(t.Sum.Variants) != 2 {
+		return AlgebraicType{}, false
+	}
+	some, none := t.Sum.Variants[0], t.Sum.Variants[1]
+	if some.Name != "some" || none.Name != "none" || !none.Type.IsUnit() {
+		return AlgebraicType{}, false
+	}
+	return some.Type, true
+}
+
+// Resolve follows Ref types until it reaches a type that is not a Ref.
+func (ts *Typespace) Resolve(t AlgebraicType) (AlgebraicType, error) {
+	for seen := 0; t.Kind == AlgebraicTypeRef; seen++ {
+		if ts == nil || int(t.Ref) >= len(ts.Types) {
+			return AlgebraicType{}, fmt.Errorf("Typespace.Resolve: reference to missing type %d", t.Ref)
+		}
+		if seen > len(ts.Types) {
+			return AlgebraicType{}, fmt.Errorf("Typespace.Resolve: reference cycle at type %d", t.Ref)
+		}
+		t = ts.Types[t.Ref]
+	}
+	return t, nil
+}
+
+// AlgebraicType is encoded in JSON the way SpacetimeDB encodes sums, as an object with a single key
+// naming the kind: {"Ref": 0}, {"Product": {"elements": [...]}}, {"Array": {"U8": []}} or {"U32": []}.
+
+func (t AlgebraicType) MarshalJSON() ([]byte, error) {
+	var value any = []any{}
+	switch t.Kind {
+	case AlgebraicTypeRef:
+		value = t.Ref
+	case AlgebraicTypeSum:
+		value = t.Sum
+	case AlgebraicTypeProduct:
+		value = t.Product
+	case AlgebraicTypeArray:
+		value = t.Elem
+	case AlgebraicTypeMap:
+		value = t.Map
+	default:
+		if !t.Kind.IsPrimitive() {
+			return nil, fmt.Errorf("AlgebraicType.MarshalJSON: invalid kind %s", t.Kind)
+		}
+	}
+	return json.Marshal(map[string]any{t.Kind.String(): value})
+}
+
+func (t *AlgebraicType) UnmarshalJSON(data []byte) error {
+	name, value, err := unmarshalJSONSum(data)
+	if err != nil {
+		return fmt.Errorf("AlgebraicType.UnmarshalJSON: %w", err)
+	}
+	*t = AlgebraicType{}
+	for kind, kindName := range algebraicTypeKindNames {
+		if kindName == name && AlgebraicTypeKind(kind) != AlgebraicTypeInvalid {
+			t.Kind = AlgebraicTypeKind(kind)
+		}
+	}
+	switch t.Kind {
+	case AlgebraicTypeRef:
+		err = json.Unmarshal(value, &t.Ref)
+	case AlgebraicTypeSum:
+		t.Sum = &SumType{}
+		err = json.Unmarshal(value, t.Sum)
+	case AlgebraicTypeProduct:
+		t.Product = &ProductType{}
+		err = json.Unmarshal(value, t.Product)
+	case AlgebraicTypeArray:
+		t.Elem = &AlgebraicType{}
+		err = json.Unmarshal(value, t.Elem)
+	case AlgebraicTypeMap:
+		t.Map = &MapType{}
+		err = json.Unmarshal(value, t.Map)
+	case AlgebraicTypeInvalid:
+		return fmt.Errorf("AlgebraicType.UnmarshalJSON: unknown type %q", name)
+	}
+	if err != nil {
+		return fmt.Errorf("AlgebraicType.UnmarshalJSON: failed to decode %s: %w", name, err)
+	}
+	return nil
+}
+
+// namedTypeJSON is the JSON encoding of product elements and sum variants, whose name is an Option.
+type namedTypeJSON struct {
+	Name          json.RawMessage `json:"name"`
+	AlgebraicType AlgebraicType   `json:"algebraic_type"`
+}
+
+func marshalNamedType(name string, t AlgebraicType) ([]byte, error) {
+	jsonName, err := marshalJSONOption(name)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(namedTypeJSON{Name: jsonName, AlgebraicType: t})
+}
+
+func unmarshalNamedType(data []byte) (string, AlgebraicType, error) {
+	var named namedTypeJSON
+	if err := json.Unmarshal(data, &named); err != nil {
+		return "", AlgebraicType{}, err
+	}
+	var name string
+	if len(named.Name) > 0 {
+		if err := unmarshalJSONOption(named.Name, &name); err != nil {
+			return "", AlgebraicType{}, err
+		}
+	}
+	return name, named.AlgebraicType, nil
+}
+
+func (e ProductTypeElement) MarshalJSON() ([]byte, error) {
+	return marshalNamedType(e.Name, e.Type)
+}
+
+func (e *ProductTypeElement) UnmarshalJSON(data []byte) error {
+	var err error
+	e.Name, e.Type, err = unmarshalNamedType(data)
+	return err
+}
+
+func (v SumTypeVariant) MarshalJSON() ([]byte, error) {
+	return marshalNamedType(v.Name, v.Type)
+}
+
+func (v *SumTypeVariant) UnmarshalJSON(data []byte) error {
+	var err error
+	v.Name, v.Type, err = unmarshalNamedType(data)
+	return err
+}
+
+// unmarshalJSONSum decodes a sum value, which SpacetimeDB encodes in JSON as an object with a
+// single key naming the variant.
+func unmarshalJSONSum(data []byte) (string, json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", nil, err
+	}
+	if len(fields) != 1 {
+		return "", nil, fmt.Errorf("expected a sum value with one variant, got %d fields", len(fields))
+	}
+	for name, value := range fields {
+		return name, value, nil
+	}
+	return "", nil, nil
+}
+
+// marshalJSONOption encodes an Option as {"some": value}, or {"none": []} for the zero value.
+func marshalJSONOption[T comparable](value T) ([]byte, error) {
+	var zero T
+	if value == zero {
+		return []byte(`{"none":[]}`), nil
+	}
+	return json.Marshal(map[string]T{"some": value})
+}
+
+// unmarshalJSONOption decodes an Option, leaving value unchanged for None.
+func unmarshalJSONOption[T any](data []byte, value *T) error {
+	name, content, err := unmarshalJSONSum(data)
+	if err != nil {
+		return fmt.Errorf("failed to decode option: %w", err)
+	}
+	switch name {
+	case "some":
+		return json.Unmarshal(content, value)
+	case "none":
+		return nil
+	default:
+		return fmt.Errorf("failed to decode option: unknown variant %q", name)
+	}
+}
@@ -0,0 +1,282 @@
+package spacetimedb
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Value is a value decoded by DecodeValue. Its Go type depends on the AlgebraicType:
+//
+//   - Bool, integers up to 64 bits, F32, F64 and String decode to bool, int8 to uint64, float32,
+//     float64 and string
+//   - 128 and 256 bit integers decode to *big.Int
+//   - Identity and ConnectionId decode to Identity and ConnectionId, Timestamp and TimeDuration
+//     to *Timestamp and *TimeDuration
+//   - arrays of U8 decode to []byte, other arrays to ArrayValue
+//   - Map, Product and Sum decode to MapValue, ProductValue and SumValue
+type Value any
+
+// ProductValue is a decoded product, with an element for every element of its type.
+type ProductValue struct {
+	Elements []ProductValueElement
+}
+
+type ProductValueElement struct {
+	// Name is the name of the element in the type, or an empty string if it has none.
+	Name  string
+	Value Value
+}
+
+// Get returns the value of the element with the given name.
+func (p ProductValue) Get(name string) (Value, bool) {
+	for _, element := range p.Elements {
+		if element.Name == name {
+			return element.Value, true
+		}
+	}
+	return nil, false
+}
+
+// SumValue is a decoded sum. Options are sums with the variants some and none.
+type SumValue struct {
+	Tag uint8
+	// Name is the name of the variant in the type, or an empty string if it has none.
+	Name  string
+	Value Value
+}
+
+type ArrayValue []Value
+
+type MapValue []MapEntry
+
+type MapEntry struct {
+	Key   Value
+	Value Value
+}
+
+// DecodeValue reads a value of type ty. ty must not contain Ref types; use Typespace.DecodeValue
+// for the types of a module.
+func DecodeValue(reader *BinaryReader, ty AlgebraicType) (Value, error) {
+	return (*Typespace)(nil).DecodeValue(reader, ty)
+}
+
+// DecodeValue reads a value of type ty, resolving Ref types in the typespace.
+func (ts *Typespace) DecodeValue(reader *BinaryReader, ty AlgebraicType) (Value, error) {
+	value, err := ts.decodeValue(reader, ty)
+	if err != nil {
+		return nil, err
+	}
+	if err := reader.Err(); err != nil {
+		return nil, fmt.Errorf("DecodeValue: %w", err)
+	}
+	return value, nil
+}
+
+func (ts *Typespace) decodeValue(reader *BinaryReader, ty AlgebraicType) (Value, error) {
+	switch ty.Kind {
+	case AlgebraicTypeRef:
+		resolved, err := ts.Resolve(ty)
+		if err != nil {
+			return nil, fmt.Errorf("DecodeValue: %w", err)
+		}
+		return ts.decodeValue(reader, resolved)
+	case AlgebraicTypeBool:
+		return reader.ReadBool(), nil
+	case AlgebraicTypeI8:
+		return reader.ReadI8(), nil
+	case AlgebraicTypeU8:
+		return reader.ReadU8(), nil
+	case AlgebraicTypeI16:
+		return reader.ReadI16(), nil
+	case AlgebraicTypeU16:
+		return reader.ReadU16(), nil
+	case AlgebraicTypeI32:
+		return reader.ReadI32(), nil
+	case AlgebraicTypeU32:
+		return reader.ReadU32(), nil
+	case AlgebraicTypeI64:
+		return reader.ReadI64(), nil
+	case AlgebraicTypeU64:
+		return reader.ReadU64(), nil
+	case AlgebraicTypeI128:
+		return reader.ReadI128(), nil
+	case AlgebraicTypeU128:
+		return reader.ReadU128(), nil
+	case AlgebraicTypeI256:
+		return reader.ReadI256(), nil
+	case AlgebraicTypeU256:
+		return reader.ReadU256(), nil
+	case AlgebraicTypeF32:
+		return reader.ReadF32(), nil
+	case AlgebraicTypeF64:
+		return reader.ReadF64(), nil
+	case AlgebraicTypeString:
+		return reader.ReadString(), nil
+	case AlgebraicTypeArray:
+		if ty.Elem == nil {
+			return nil, fmt.Errorf("DecodeValue: array type without element type")
+		}
+		if ty.Elem.Kind == AlgebraicTypeU8 {
+			return reader.ReadUInt8Array(), nil
+		}
+		length := reader.ReadU32()
+		// The length comes from the data, so it is not trusted for preallocating.
+		array := make(ArrayValue, 0, min(int(length), reader.Remaining()))
+		for i := 0; i < int(length) && reader.Err() == nil; i++ {
+			elem, err := ts.decodeValue(reader, *ty.Elem)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, elem)
+		}
+		return array, nil
+	case AlgebraicTypeMap:
+		if ty.Map == nil {
+			return nil, fmt.Errorf("DecodeValue: map type without key and value types")
+		}
+		length := reader.ReadU32()
+		entries := make(MapValue, 0, min(int(length), reader.Remaining()))
+		for i := 0; i < int(length) && reader.Err() == nil; i++ {
+			key, err := ts.decodeValue(reader, ty.Map.Key)
+			if err != nil {
+				return nil, err
+			}
+			value, err := ts.decodeValue(reader, ty.Map.Value)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, MapEntry{Key: key, Value: value})
+		}
+		return entries, nil
+	case AlgebraicTypeProduct:
+		return ts.decodeProduct(reader, ty)
+	case AlgebraicTypeSum:
+		if ty.Sum == nil {
+			return nil, fmt.Errorf("DecodeValue: sum type without variants")
+		}
+		tag := reader.ReadU8()
+		if reader.Err() != nil {
+			return nil, nil
+		}
+		if int(tag) >= len(ty.Sum.Variants) {
+			return nil, fmt.Errorf("DecodeValue: unknown tag %d of sum type with %d variants", tag, len(ty.Sum.Variants))
+		}
+		variant := ty.Sum.Variants[tag]
+		value, err := ts.decodeValue(reader, variant.Type)
+		if err != nil {
+			return nil, err
+		}
+		return SumValue{Tag: tag, Name: variant.Name, Value: value}, nil
+	default:
+		return nil, fmt.Errorf("DecodeValue: invalid type kind %s", ty.Kind)
+	}
+}
+
+func (ts *Typespace) decodeProduct(reader *BinaryReader, ty AlgebraicType) (Value, error) {
+	switch ty.specialElement() {
+	case identityElementName:
+		var identity Identity
+		if err := identity.Deserialize(reader); err != nil {
+			return nil, err
+		}
+		return identity, nil
+	case connectionIdElementName:
+		var connectionId ConnectionId
+		if err := connectionId.Deserialize(reader); err != nil {
+			return nil, err
+		}
+		return connectionId, nil
+	case timestampElementName:
+		timestamp := &Timestamp{}
+		return timestamp, timestamp.Deserialize(reader)
+	case timeDurationElementName:
+		duration := &TimeDuration{}
+		return duration, duration.Deserialize(reader)
+	}
+
+	var product ProductValue
+	if ty.Product == nil {
+		return product, nil
+	}
+	product.Elements = make([]ProductValueElement, len(ty.Product.Elements))
+	for i, element := range ty.Product.Elements {
+		value, err := ts.decodeValue(reader, element.Type)
+		if err != nil {
+			return nil, err
+		}
+		product.Elements[i] = ProductValueElement{Name: element.Name, Value: value}
+	}
+	return product, nil
+}
+
+// FormatValue formats a Value for display, such as (identity = c200…, name = some("alice")).
+func FormatValue(value Value) string {
+	var b strings.Builder
+	formatValue(&b, value)
+	return b.String()
+}
+
+func formatValue(b *strings.Builder, value Value) {
+	switch v := value.(type) {
+	case string:
+		b.WriteString(strconv.Quote(v))
+	case []byte:
+		b.WriteString("0x")
+		b.WriteString(hex.EncodeToString(v))
+	case Identity:
+		b.WriteString(v.ToHexString())
+	case ConnectionId:
+		b.WriteString(v.ToHexString())
+	case *Timestamp:
+		b.WriteString(v.String())
+	case ProductValue:
+		b.WriteString("(")
+		for i, element := range v.Elements {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			if element.Name != "" {
+				b.WriteString(element.Name)
+				b.WriteString(" = ")
+			}
+			formatValue(b, element.Value)
+		}
+		b.WriteString(")")
+	case SumValue:
+		if v.Name != "" {
+			b.WriteString(v.Name)
+		} else {
+			fmt.Fprintf(b, "variant_%d", v.Tag)
+		}
+		if product, ok := v.Value.(ProductValue); ok && len(product.Elements) == 0 {
+			return
+		}
+		b.WriteString("(")
+		formatValue(b, v.Value)
+		b.WriteString(")")
+	case ArrayValue:
+		b.WriteString("[")
+		for i, elem := range v {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			formatValue(b, elem)
+		}
+		b.WriteString("]")
+	case MapValue:
+		b.WriteString("{")
+		for i, entry := range v {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			formatValue(b, entry.Key)
+			b.WriteString(": ")
+			formatValue(b, entry.Value)
+		}
+		b.WriteString("}")
+	default:
+		fmt.Fprint(b, v)
+	}
+}
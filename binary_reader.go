@@ -2,14 +2,20 @@ package spacetimedb
 
 import (
 	"encoding/binary"
+	"fmt"
 	"math"
 	"math/big"
 )
 
 // BinaryReader helps to read binary data from a byte slice.
+//
+// Reading past the end of the data does not panic. Instead the reader records the error,
+// which is returned by Err, and every read after that returns a zero value. This means a
+// Deserialize method can read all its fields and check Err once at the end.
 type BinaryReader struct {
 	buffer []byte
 	offset int
+	err    error
 }
 
 // NewBinaryReader creates a new BinaryReader with the given input byte slice.
@@ -26,16 +32,42 @@ func (br *BinaryReader) Offset() int {
 	return br.offset
 }
 
-func (br *BinaryReader) checkBounds(needed int) {
-	if br.offset+needed > len(br.buffer) {
-		panic("BinaryReader: read out of bounds")
+// Err returns the first error encountered while reading, or nil.
+func (br *BinaryReader) Err() error {
+	return br.err
+}
+
+// SetErr records err as the error of the reader, unless it already has one.
+// Deserialize methods use it to report invalid data, which stops all further reads.
+func (br *BinaryReader) SetErr(err error) {
+	if br.err == nil && err != nil {
+		br.err = err
+	}
+}
+
+// Remaining returns the number of unread bytes.
+func (br *BinaryReader) Remaining() int {
+	return len(br.buffer) - br.offset
+}
+
+// checkBounds reports whether needed more bytes can be read, and records an error if not.
+func (br *BinaryReader) checkBounds(needed int) bool {
+	if br.err != nil {
+		return false
+	}
+	if needed < 0 || needed > len(br.buffer)-br.offset {
+		br.err = fmt.Errorf("BinaryReader: read of %d bytes at offset %d out of bounds of %d bytes", needed, br.offset, len(br.buffer))
+		return false
 	}
+	return true
 }
 
 // ReadUInt8Array reads a U32 for length, then that many bytes.
 func (br *BinaryReader) ReadUInt8Array() []byte {
 	length := br.ReadU32()
-	br.checkBounds(int(length))
+	if !br.checkBounds(int(length)) {
+		return nil
+	}
 	value := make([]byte, length)
 	copy(value, br.buffer[br.offset:br.offset+int(length)])
 	br.offset += int(length)
@@ -44,23 +76,29 @@ func (br *BinaryReader) ReadUInt8Array() []byte {
 
 // ReadBool reads a single byte as a boolean (non-zero is true).
 func (br *BinaryReader) ReadBool() bool {
-	br.checkBounds(1)
+	if !br.checkBounds(1) {
+		return false
+	}
 	value := br.buffer[br.offset]
 	br.offset += 1
 	return value != 0
 }
 
-// ReadByte reads a single byte.
-func (br *BinaryReader) ReadByte() byte {
-	br.checkBounds(1)
+// ReadByte reads a single byte. It implements io.ByteReader.
+func (br *BinaryReader) ReadByte() (byte, error) {
+	if !br.checkBounds(1) {
+		return 0, br.err
+	}
 	value := br.buffer[br.offset]
 	br.offset += 1
-	return value
+	return value, nil
 }
 
 // ReadBytes reads a specified number of bytes.
 func (br *BinaryReader) ReadBytes(length int) []byte {
-	br.checkBounds(length)
+	if !br.checkBounds(length) {
+		return nil
+	}
 	value := make([]byte, length)
 	copy(value, br.buffer[br.offset:br.offset+length])
 	br.offset += length
@@ -69,7 +107,9 @@ func (br *BinaryReader) ReadBytes(length int) []byte {
 
 // ReadI8 reads a signed 8-bit integer.
 func (br *BinaryReader) ReadI8() int8 {
-	br.checkBounds(1)
+	if !br.checkBounds(1) {
+		return 0
+	}
 	value := int8(br.buffer[br.offset])
 	br.offset += 1
 	return value
@@ -77,7 +117,9 @@ func (br *BinaryReader) ReadI8() int8 {
 
 // ReadU8 reads an unsigned 8-bit integer.
 func (br *BinaryReader) ReadU8() uint8 {
-	br.checkBounds(1)
+	if !br.checkBounds(1) {
+		return 0
+	}
 	value := br.buffer[br.offset]
 	br.offset += 1
 	return value
@@ -85,7 +127,9 @@ func (br *BinaryReader) ReadU8() uint8 {
 
 // ReadI16 reads a signed 16-bit integer (little-endian).
 func (br *BinaryReader) ReadI16() int16 {
-	br.checkBounds(2)
+	if !br.checkBounds(2) {
+		return 0
+	}
 	value := binary.LittleEndian.Uint16(br.buffer[br.offset : br.offset+2])
 	br.offset += 2
 	return int16(value)
@@ -93,7 +137,9 @@ func (br *BinaryReader) ReadI16() int16 {
 
 // ReadU16 reads an unsigned 16-bit integer (little-endian).
 func (br *BinaryReader) ReadU16() uint16 {
-	br.checkBounds(2)
+	if !br.checkBounds(2) {
+		return 0
+	}
 	value := binary.LittleEndian.Uint16(br.buffer[br.offset : br.offset+2])
 	br.offset += 2
 	return value
@@ -101,7 +147,9 @@ func (br *BinaryReader) ReadU16() uint16 {
 
 // ReadI32 reads a signed 32-bit integer (little-endian).
 func (br *BinaryReader) ReadI32() int32 {
-	br.checkBounds(4)
+	if !br.checkBounds(4) {
+		return 0
+	}
 	value := binary.LittleEndian.Uint32(br.buffer[br.offset : br.offset+4])
 	br.offset += 4
 	return int32(value)
@@ -109,7 +157,9 @@ func (br *BinaryReader) ReadI32() int32 {
 
 // ReadU32 reads an unsigned 32-bit integer (little-endian).
 func (br *BinaryReader) ReadU32() uint32 {
-	br.checkBounds(4)
+	if !br.checkBounds(4) {
+		return 0
+	}
 	value := binary.LittleEndian.Uint32(br.buffer[br.offset : br.offset+4])
 	br.offset += 4
 	return value
@@ -117,7 +167,9 @@ func (br *BinaryReader) ReadU32() uint32 {
 
 // ReadI64 reads a signed 64-bit integer (little-endian).
 func (br *BinaryReader) ReadI64() int64 {
-	br.checkBounds(8)
+	if !br.checkBounds(8) {
+		return 0
+	}
 	value := binary.LittleEndian.Uint64(br.buffer[br.offset : br.offset+8])
 	br.offset += 8
 	return int64(value)
@@ -125,7 +177,9 @@ func (br *BinaryReader) ReadI64() int64 {
 
 // ReadU64 reads an unsigned 64-bit integer (little-endian).
 func (br *BinaryReader) ReadU64() uint64 {
-	br.checkBounds(8)
+	if !br.checkBounds(8) {
+		return 0
+	}
 	value := binary.LittleEndian.Uint64(br.buffer[br.offset : br.offset+8])
 	br.offset += 8
 	return value
@@ -133,7 +187,9 @@ func (br *BinaryReader) ReadU64() uint64 {
 
 // ReadU128 reads an unsigned 128-bit integer (little-endian).
 func (br *BinaryReader) ReadU128() *big.Int {
-	br.checkBounds(16)
+	if !br.checkBounds(16) {
+		return new(big.Int)
+	}
 	lowerPart := binary.LittleEndian.Uint64(br.buffer[br.offset : br.offset+8])
 	upperPart := binary.LittleEndian.Uint64(br.buffer[br.offset+8 : br.offset+16])
 	br.offset += 16
@@ -147,7 +203,9 @@ func (br *BinaryReader) ReadU128() *big.Int {
 // ReadI128 reads a signed 128-bit integer (little-endian).
 // Matches TypeScript logic: lower 64 bits as unsigned, upper 64 bits as signed.
 func (br *BinaryReader) ReadI128() *big.Int {
-	br.checkBounds(16)
+	if !br.checkBounds(16) {
+		return new(big.Int)
+	}
 	lowerPart := binary.LittleEndian.Uint64(br.buffer[br.offset : br.offset+8])
 	upperPartSigned := int64(binary.LittleEndian.Uint64(br.buffer[br.offset+8 : br.offset+16]))
 	br.offset += 16
@@ -163,7 +221,9 @@ func (br *BinaryReader) ReadI128() *big.Int {
 
 // ReadU256 reads an unsigned 256-bit integer (little-endian).
 func (br *BinaryReader) ReadU256() *big.Int {
-	br.checkBounds(32)
+	if !br.checkBounds(32) {
+		return new(big.Int)
+	}
 	p0 := binary.LittleEndian.Uint64(br.buffer[br.offset : br.offset+8])
 	p1 := binary.LittleEndian.Uint64(br.buffer[br.offset+8 : br.offset+16])
 	p2 := binary.LittleEndian.Uint64(br.buffer[br.offset+16 : br.offset+24])
@@ -190,7 +250,9 @@ func (br *BinaryReader) ReadU256() *big.Int {
 // ReadI256 reads a signed 256-bit integer (little-endian).
 // Matches TypeScript logic: p0, p1, p2 as unsigned 64-bit, p3 as signed 64-bit.
 func (br *BinaryReader) ReadI256() *big.Int {
-	br.checkBounds(32)
+	if !br.checkBounds(32) {
+		return new(big.Int)
+	}
 	p0 := binary.LittleEndian.Uint64(br.buffer[br.offset : br.offset+8])
 	p1 := binary.LittleEndian.Uint64(br.buffer[br.offset+8 : br.offset+16])
 	p2 := binary.LittleEndian.Uint64(br.buffer[br.offset+16 : br.offset+24])
@@ -216,7 +278,9 @@ func (br *BinaryReader) ReadI256() *big.Int {
 
 // ReadF32 reads a 32-bit float (little-endian).
 func (br *BinaryReader) ReadF32() float32 {
-	br.checkBounds(4)
+	if !br.checkBounds(4) {
+		return 0
+	}
 	bits := binary.LittleEndian.Uint32(br.buffer[br.offset : br.offset+4])
 	br.offset += 4
 	return math.Float32frombits(bits)
@@ -224,7 +288,9 @@ func (br *BinaryReader) ReadF32() float32 {
 
 // ReadF64 reads a 64-bit float (little-endian).
 func (br *BinaryReader) ReadF64() float64 {
-	br.checkBounds(8)
+	if !br.checkBounds(8) {
+		return 0
+	}
 	bits := binary.LittleEndian.Uint64(br.buffer[br.offset : br.offset+8])
 	br.offset += 8
 	return math.Float64frombits(bits)
@@ -233,7 +299,9 @@ func (br *BinaryReader) ReadF64() float64 {
 // ReadString reads a U32 for length, then that many bytes as a UTF-8 string.
 func (br *BinaryReader) ReadString() string {
 	length := br.ReadU32()
-	br.checkBounds(int(length))
+	if !br.checkBounds(int(length)) {
+		return ""
+	}
 	value := string(br.buffer[br.offset : br.offset+int(length)])
 	br.offset += int(length)
 	return value
@@ -241,23 +309,66 @@ func (br *BinaryReader) ReadString() string {
 
 func (br *BinaryReader) ReadArray(elementReader func(*BinaryReader) any) []any {
 	length := br.ReadU32()
-	result := make([]any, length)
-	for i := 0; i < int(length); i++ {
+	// The length comes from the data, so it is not trusted for preallocating.
+	result := make([]any, 0, min(int(length), br.Remaining()))
+	for i := 0; i < int(length) && br.err == nil; i++ {
 		element := elementReader(br)
 		if element == nil {
 			return nil // If any element is nil, return nil
 		}
-		result[i] = element
+		result = append(result, element)
+	}
+	if br.err != nil {
+		return nil
 	}
 	return result
 }
 
 func ReadArray[T any](br *BinaryReader, elementReader func() T) []T {
 	length := br.ReadU32()
-	result := make([]T, length)
-	for i := 0; i < int(length); i++ {
+	// The length comes from the data, so it is not trusted for preallocating.
+	result := make([]T, 0, min(int(length), br.Remaining()))
+	for i := 0; i < int(length) && br.err == nil; i++ {
 		element := elementReader()
-		result[i] = element
+		result = append(result, element)
+	}
+	if br.err != nil {
+		return nil
 	}
 	return result
 }
+
+// ReadOption reads an Option, which is a sum type where tag 0 is Some followed by the value and tag 1 is None.
+// It returns nil for None.
+func ReadOption[T any](br *BinaryReader, elementReader func() T) *T {
+	switch tag := br.ReadU8(); tag {
+	case 0:
+		value := elementReader()
+		return &value
+	case 1:
+		return nil
+	default:
+		br.SetErr(fmt.Errorf("BinaryReader: invalid option tag %d", tag))
+		return nil
+	}
+}
+
+// ReadNullable reads an Option of a pointer type, returning nil for None.
+func ReadNullable[T any](br *BinaryReader, elementReader func() *T) *T {
+	value := ReadOption(br, elementReader)
+	if value == nil {
+		return nil
+	}
+	return *value
+}
+
+// ReadValue reads a value of a type with a Deserialize method, such as Identity or a generated
+// row type. An error returned by Deserialize is recorded on the reader.
+func ReadValue[T any, P interface {
+	*T
+	Deserialize(reader *BinaryReader) error
+}](br *BinaryReader) P {
+	value := P(new(T))
+	br.SetErr(value.Deserialize(br))
+	return value
+}
@@ -54,6 +54,13 @@ func (bw *BinaryWriter) WriteUInt8Array(value []byte) {
 	bw.offset += len(value)
 }
 
+// WriteBytes writes the bytes as they are, without a length.
+func (bw *BinaryWriter) WriteBytes(value []byte) {
+	bw.expandBuffer(len(value))
+	copy(bw.buffer[bw.offset:], value)
+	bw.offset += len(value)
+}
+
 // WriteBool writes a boolean as a single byte (1 for true, 0 for false).
 func (bw *BinaryWriter) WriteBool(value bool) {
 	bw.expandBuffer(1)
@@ -65,11 +72,12 @@ func (bw *BinaryWriter) WriteBool(value bool) {
 	bw.offset += 1
 }
 
-// WriteByte writes a single byte.
-func (bw *BinaryWriter) WriteByte(value byte) {
+// WriteByte writes a single byte. It implements io.ByteWriter and never returns an error.
+func (bw *BinaryWriter) WriteByte(value byte) error {
 	bw.expandBuffer(1)
 	bw.buffer[bw.offset] = value
 	bw.offset += 1
+	return nil
 }
 
 // WriteI8 writes a signed 8-bit integer.
@@ -257,3 +265,14 @@ func WriteArray[T any](bw *BinaryWriter, values []T, writeFunc func(*BinaryWrite
 		writeFunc(bw, value)
 	}
 }
+
+// WriteOption writes an Option, which is a sum type where tag 0 is Some followed by the value and tag 1 is None.
+// A nil value is written as None.
+func WriteOption[T any](bw *BinaryWriter, value *T, writeFunc func(T)) {
+	if value == nil {
+		bw.WriteU8(1)
+		return
+	}
+	bw.WriteU8(0)
+	writeFunc(*value)
+}
@@ -0,0 +1,58 @@
+package spacetimedb
+
+import "sync"
+
+// CallbackHandle is returned when registering a callback and can be used to remove it again.
+type CallbackHandle struct {
+	remove func()
+	once   sync.Once
+}
+
+// Remove unregisters the callback. Removing a callback more than once has no effect.
+func (h *CallbackHandle) Remove() {
+	if h != nil && h.remove != nil {
+		h.once.Do(h.remove)
+	}
+}
+
+type callbackEntry[F any] struct {
+	id       uint64
+	callback F
+}
+
+// callbacks is a list of callbacks that are run in the order they were registered. Callbacks can
+// be added and removed from any goroutine.
+type callbacks[F any] struct {
+	mu      sync.Mutex
+	nextId  uint64
+	entries []callbackEntry[F]
+}
+
+func (c *callbacks[F]) add(callback F) *CallbackHandle {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nextId++
+	id := c.nextId
+	c.entries = append(c.entries, callbackEntry[F]{id: id, callback: callback})
+	return &CallbackHandle{remove: func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for i, entry := range c.entries {
+			if entry.id == id {
+				c.entries = append(c.entries[:i:i], c.entries[i+1:]...)
+				return
+			}
+		}
+	}}
+}
+
+// each runs fn for every registered callback. Callbacks added or removed while
+// running do not affect the current iteration.
+func (c *callbacks[F]) each(fn func(callback F)) {
+	c.mu.Lock()
+	entries := c.entries
+	c.mu.Unlock()
+	for _, entry := range entries {
+		fn(entry.callback)
+	}
+}
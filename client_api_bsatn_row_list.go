@@ -10,11 +10,50 @@ type BsatnRowList struct {
 func (it *BsatnRowList) Deserialize(reader *BinaryReader) error {
 
 	it.SizeHint = &RowSizeHint{}
-	it.SizeHint.Deserialize(reader)
+	if err := it.SizeHint.Deserialize(reader); err != nil {
+		return fmt.Errorf("BsatnRowList.Deserialize: failed to deserialize SizeHint: %w", err)
+	}
 
 	it.RowsData = reader.ReadUInt8Array()
 
-	return nil
+	return reader.Err()
+}
+
+// Rows splits RowsData into the BSATN encoded rows it contains, using the size hint to find the row boundaries.
+func (it *BsatnRowList) Rows() ([][]byte, error) {
+	if len(it.RowsData) == 0 {
+		return nil, nil
+	}
+	if it.SizeHint == nil {
+		return nil, fmt.Errorf("BsatnRowList.Rows: missing size hint")
+	}
+	switch hint := it.SizeHint.RowSizeHint.(type) {
+	case *RowSizeHintFixedSize:
+		size := int(hint.FixedSize)
+		if size == 0 || len(it.RowsData)%size != 0 {
+			return nil, fmt.Errorf("BsatnRowList.Rows: %d bytes of rows do not divide into rows of size %d", len(it.RowsData), size)
+		}
+		rows := make([][]byte, 0, len(it.RowsData)/size)
+		for offset := 0; offset < len(it.RowsData); offset += size {
+			rows = append(rows, it.RowsData[offset:offset+size])
+		}
+		return rows, nil
+	case *RowSizeHintRowOffsets:
+		rows := make([][]byte, 0, len(hint.RowOffsets))
+		for i, start := range hint.RowOffsets {
+			end := uint64(len(it.RowsData))
+			if i+1 < len(hint.RowOffsets) {
+				end = hint.RowOffsets[i+1]
+			}
+			if start > end || end > uint64(len(it.RowsData)) {
+				return nil, fmt.Errorf("BsatnRowList.Rows: invalid row offsets %d..%d for %d bytes of rows", start, end, len(it.RowsData))
+			}
+			rows = append(rows, it.RowsData[start:end])
+		}
+		return rows, nil
+	default:
+		return nil, fmt.Errorf("BsatnRowList.Rows: unknown size hint type %T", it.SizeHint.RowSizeHint)
+	}
 }
 
 func (it *BsatnRowList) String() string {
@@ -27,3 +66,28 @@ func (it *BsatnRowList) String() string {
 	}
 	return result
 }
+
+// NewBsatnRowList creates a row list holding the given BSATN encoded rows.
+func NewBsatnRowList(rows [][]byte) *BsatnRowList {
+	offsets := make([]uint64, len(rows))
+	var data []byte
+	for i, row := range rows {
+		offsets[i] = uint64(len(data))
+		data = append(data, row...)
+	}
+	return &BsatnRowList{
+		SizeHint: &RowSizeHint{RowSizeHint: NewRowSizeHintRowOffsets(offsets)},
+		RowsData: data,
+	}
+}
+
+func (it *BsatnRowList) Serialize(writer *BinaryWriter) error {
+	if it.SizeHint == nil {
+		return fmt.Errorf("BsatnRowList.Serialize: missing size hint")
+	}
+	if err := it.SizeHint.Serialize(writer); err != nil {
+		return fmt.Errorf("BsatnRowList.Serialize: failed to serialize SizeHint: %w", err)
+	}
+	writer.WriteUInt8Array(it.RowsData)
+	return nil
+}
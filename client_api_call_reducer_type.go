@@ -1,36 +1,44 @@
 package spacetimedb
 
+// CallReducerFlags controls how the server answers a reducer call.
+type CallReducerFlags uint8
+
+const (
+	// FullUpdate asks for a TransactionUpdate whether the reducer commits or fails.
+	FullUpdate CallReducerFlags = 0
+	// NoSuccessNotify asks for a TransactionUpdate only if the reducer fails. Rows the reducer
+	// changes still arrive through subscriptions.
+	NoSuccessNotify CallReducerFlags = 1
+)
+
 type CallReducer struct {
 	Reducer   string
 	Args      []byte
 	RequestId uint32
-	Flags     uint8
+	Flags     CallReducerFlags
 }
 
 func (cr *CallReducer) Serialize(writer *BinaryWriter) error {
 	writer.WriteString(cr.Reducer)
 	writer.WriteUInt8Array(cr.Args)
 	writer.WriteU32(cr.RequestId)
-	writer.WriteU8(cr.Flags)
+	writer.WriteU8(uint8(cr.Flags))
 	return nil
 }
 
-func (conn *DBConnection) CallReducer(reducer string, args []byte, requestId uint32, flags uint8) error {
-
-	clientMsg := &ClientMessage{
-		Message: &CallReducer{
-			Reducer:   reducer,
-			Args:      args,
-			RequestId: requestId,
-			Flags:     flags,
-		},
-	}
-
-	writer := NewBinaryWriter()
-
-	clientMsg.Serialize(writer)
+func (cr *CallReducer) Deserialize(reader *BinaryReader) error {
+	cr.Reducer = reader.ReadString()
+	cr.Args = reader.ReadUInt8Array()
+	cr.RequestId = reader.ReadU32()
+	cr.Flags = CallReducerFlags(reader.ReadU8())
+	return reader.Err()
+}
 
-	msg := writer.GetBuffer()
-	conn.Logger("Sending ClientMessage: %s", clientMsg)
-	return conn.SendMessage(msg)
+func (conn *DBConnection) CallReducer(reducer string, args []byte, requestId uint32, flags CallReducerFlags) error {
+	return conn.sendClientMessage(&CallReducer{
+		Reducer:   reducer,
+		Args:      args,
+		RequestId: requestId,
+		Flags:     flags,
+	})
 }
@@ -14,6 +14,68 @@ func (sm *ClientMessage) Serialize(writer *BinaryWriter) error {
 	case *Subscribe:
 		writer.WriteU8(0x01) // Type identifier for Subscribe
 		return v.Serialize(writer)
+	case *OneOffQuery:
+		writer.WriteU8(0x02) // Type identifier for OneOffQuery
+		return v.Serialize(writer)
+	case *SubscribeSingle:
+		writer.WriteU8(0x03) // Type identifier for SubscribeSingle
+		return v.Serialize(writer)
+	case *SubscribeMulti:
+		writer.WriteU8(0x04) // Type identifier for SubscribeMulti
+		return v.Serialize(writer)
+	case *Unsubscribe:
+		writer.WriteU8(0x05) // Type identifier for Unsubscribe
+		return v.Serialize(writer)
+	case *UnsubscribeMulti:
+		writer.WriteU8(0x06) // Type identifier for UnsubscribeMulti
+		return v.Serialize(writer)
 	}
 	return fmt.Errorf("unsupported message type when serializing ClientMessage: %T", sm.Message)
 }
+
+func (sm *ClientMessage) Deserialize(reader *BinaryReader) error {
+	var message interface{ Deserialize(*BinaryReader) error }
+	switch unionType := reader.ReadU8(); unionType {
+	case 0x00:
+		message = &CallReducer{}
+	case 0x01:
+		message = &Subscribe{}
+	case 0x02:
+		message = &OneOffQuery{}
+	case 0x03:
+		message = &SubscribeSingle{}
+	case 0x04:
+		message = &SubscribeMulti{}
+	case 0x05:
+		message = &Unsubscribe{}
+	case 0x06:
+		message = &UnsubscribeMulti{}
+	default:
+		if err := reader.Err(); err != nil {
+			return err
+		}
+		return fmt.Errorf("unknown ClientMessage type: 0x%02x", unionType)
+	}
+	if err := message.Deserialize(reader); err != nil {
+		return fmt.Errorf("failed to deserialize %T: %w", message, err)
+	}
+	sm.Message = message
+	return nil
+}
+
+// sendClientMessage serializes message as a ClientMessage and sends it to the server.
+func (db *DBConnection) sendClientMessage(message any) error {
+	clientMsg := &ClientMessage{
+		Message: message,
+	}
+
+	writer := NewBinaryWriter()
+
+	if err := clientMsg.Serialize(writer); err != nil {
+		return err
+	}
+
+	msg := writer.GetBuffer()
+	db.Logger("Sending ClientMessage: %s", clientMsg)
+	return db.SendMessage(msg)
+}
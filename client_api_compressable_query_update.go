@@ -2,7 +2,6 @@ package spacetimedb
 
 import (
 	"fmt"
-	"log"
 )
 
 type CompressableQueryUpdate struct {
@@ -19,10 +18,45 @@ func (it *CompressableQueryUpdate) Deserialize(reader *BinaryReader) error {
 		}
 		it.Update = uncompressed
 	case 0x01:
-		log.Println("CompressableQueryUpdate.Deserialize: type 0x01 is not implemented yet. Brotli compression.")
+		update, err := deserializeCompressedQueryUpdate(CompressionTypeBrotly, reader.ReadUInt8Array())
+		if err != nil {
+			return fmt.Errorf("failed to deserialize brotli query update: %w", err)
+		}
+		it.Update = update
 	case 0x02:
-		log.Println("CompressableQueryUpdate.Deserialize: type 0x02 is not implemented yet. Gzip compression.")
+		update, err := deserializeCompressedQueryUpdate(CompressionTypeGzip, reader.ReadUInt8Array())
+		if err != nil {
+			return fmt.Errorf("failed to deserialize gzip query update: %w", err)
+		}
+		it.Update = update
+	default:
+		return fmt.Errorf("CompressableQueryUpdate.Deserialize: unknown union type 0x%02x", unionType)
+	}
+
+	return reader.Err()
+}
+
+func deserializeCompressedQueryUpdate(compression uint8, data []byte) (*QueryUpdate, error) {
+	decompressed, err := decompress(compression, data)
+	if err != nil {
+		return nil, err
+	}
+	reader := NewBinaryReader(decompressed)
+	update := &QueryUpdate{}
+	if err := update.Deserialize(reader); err != nil {
+		return nil, err
+	}
+	if reader.Offset() != len(decompressed) {
+		return nil, fmt.Errorf("%d trailing bytes after query update", len(decompressed)-reader.Offset())
 	}
+	return update, nil
+}
 
-	return nil
+// Serialize writes the query update uncompressed.
+func (it *CompressableQueryUpdate) Serialize(writer *BinaryWriter) error {
+	if it.Update == nil {
+		return fmt.Errorf("CompressableQueryUpdate.Serialize: missing Update")
+	}
+	writer.WriteU8(0x00)
+	return it.Update.Serialize(writer)
 }
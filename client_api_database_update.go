@@ -1,5 +1,7 @@
 package spacetimedb
 
+import "fmt"
+
 type DatabaseUpdate struct {
 	Tables []*TableUpdate
 }
@@ -8,12 +10,14 @@ func (it *DatabaseUpdate) Deserialize(reader *BinaryReader) error {
 
 	tables := ReadArray(reader, func() *TableUpdate {
 		table := &TableUpdate{}
-		table.Deserialize(reader)
+		if err := table.Deserialize(reader); err != nil {
+			reader.SetErr(fmt.Errorf("DatabaseUpdate.Deserialize: failed to deserialize TableUpdate: %w", err))
+		}
 		return table
 	})
 	it.Tables = tables
 
-	return nil
+	return reader.Err()
 }
 
 func (it *DatabaseUpdate) String() string {
@@ -23,3 +27,13 @@ func (it *DatabaseUpdate) String() string {
 	}
 	return result
 }
+
+func (it *DatabaseUpdate) Serialize(writer *BinaryWriter) error {
+	writer.WriteU32(uint32(len(it.Tables)))
+	for _, table := range it.Tables {
+		if err := table.Serialize(writer); err != nil {
+			return fmt.Errorf("DatabaseUpdate.Serialize: failed to serialize TableUpdate: %w", err)
+		}
+	}
+	return nil
+}
@@ -10,5 +10,10 @@ func (it *EnergyQuanta) Deserialize(reader *BinaryReader) error {
 
 	it.Quanta = *reader.ReadU128()
 
+	return reader.Err()
+}
+
+func (it *EnergyQuanta) Serialize(writer *BinaryWriter) error {
+	writer.WriteU128(&it.Quanta)
 	return nil
 }
@@ -1,20 +1,35 @@
 package spacetimedb
 
+import "fmt"
+
 type IdentityToken struct {
-	Identity     *Identity     `json:"identity"`
-	Token        string        `json:"token"`
-	ConnectionId *ConnectionId `json:"connectionId"`
+	Identity     Identity     `json:"identity"`
+	Token        string       `json:"token"`
+	ConnectionId ConnectionId `json:"connectionId"`
 }
 
 func (it *IdentityToken) Deserialize(reader *BinaryReader) error {
 
-	it.Identity = &Identity{}
-	it.Identity.Deserialize(reader)
+	if err := it.Identity.Deserialize(reader); err != nil {
+		return fmt.Errorf("IdentityToken.Deserialize: failed to deserialize Identity: %w", err)
+	}
 
 	it.Token = reader.ReadString()
 
-	it.ConnectionId = &ConnectionId{}
-	it.ConnectionId.Deserialize(reader)
+	if err := it.ConnectionId.Deserialize(reader); err != nil {
+		return fmt.Errorf("IdentityToken.Deserialize: failed to deserialize ConnectionId: %w", err)
+	}
+
+	return reader.Err()
+}
 
+func (it *IdentityToken) Serialize(writer *BinaryWriter) error {
+	if err := it.Identity.Serialize(writer); err != nil {
+		return fmt.Errorf("IdentityToken.Serialize: failed to serialize Identity: %w", err)
+	}
+	writer.WriteString(it.Token)
+	if err := it.ConnectionId.Serialize(writer); err != nil {
+		return fmt.Errorf("IdentityToken.Serialize: failed to serialize ConnectionId: %w", err)
+	}
 	return nil
 }
@@ -17,5 +17,17 @@ func (it *InitialSubscription) Deserialize(reader *BinaryReader) error {
 	it.RequestId = reader.ReadU32()
 	it.TotalHostExecutionDuration = NewTimeDuration(reader.ReadI64())
 
+	return reader.Err()
+}
+
+func (it *InitialSubscription) Serialize(writer *BinaryWriter) error {
+	if it.DatabaseUpdate == nil || it.TotalHostExecutionDuration == nil {
+		return fmt.Errorf("InitialSubscription.Serialize: missing field")
+	}
+	if err := it.DatabaseUpdate.Serialize(writer); err != nil {
+		return fmt.Errorf("InitialSubscription.Serialize: failed to serialize DatabaseUpdate: %w", err)
+	}
+	writer.WriteU32(it.RequestId)
+	writer.WriteI64(it.TotalHostExecutionDuration.Micros)
 	return nil
 }
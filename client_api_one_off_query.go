@@ -0,0 +1,18 @@
+package spacetimedb
+
+type OneOffQuery struct {
+	MessageId   []byte
+	QueryString string
+}
+
+func (cr *OneOffQuery) Serialize(writer *BinaryWriter) error {
+	writer.WriteUInt8Array(cr.MessageId)
+	writer.WriteString(cr.QueryString)
+	return nil
+}
+
+func (cr *OneOffQuery) Deserialize(reader *BinaryReader) error {
+	cr.MessageId = reader.ReadUInt8Array()
+	cr.QueryString = reader.ReadString()
+	return reader.Err()
+}
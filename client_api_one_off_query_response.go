@@ -0,0 +1,46 @@
+package spacetimedb
+
+import "fmt"
+
+type OneOffQueryResponse struct {
+	MessageId []byte
+	// Error is nil if the query succeeded.
+	Error                      *string
+	Tables                     []*OneOffTable
+	TotalHostExecutionDuration *TimeDuration
+}
+
+func (it *OneOffQueryResponse) Deserialize(reader *BinaryReader) error {
+
+	it.MessageId = reader.ReadUInt8Array()
+	it.Error = ReadOption(reader, reader.ReadString)
+
+	tables := ReadArray(reader, func() *OneOffTable {
+		table := &OneOffTable{}
+		if err := table.Deserialize(reader); err != nil {
+			reader.SetErr(fmt.Errorf("OneOffQueryResponse.Deserialize: failed to deserialize OneOffTable: %w", err))
+		}
+		return table
+	})
+	it.Tables = tables
+
+	it.TotalHostExecutionDuration = NewTimeDuration(reader.ReadI64())
+
+	return reader.Err()
+}
+
+func (it *OneOffQueryResponse) Serialize(writer *BinaryWriter) error {
+	if it.TotalHostExecutionDuration == nil {
+		return fmt.Errorf("OneOffQueryResponse.Serialize: missing TotalHostExecutionDuration")
+	}
+	writer.WriteUInt8Array(it.MessageId)
+	WriteOption(writer, it.Error, writer.WriteString)
+	writer.WriteU32(uint32(len(it.Tables)))
+	for _, table := range it.Tables {
+		if err := table.Serialize(writer); err != nil {
+			return fmt.Errorf("OneOffQueryResponse.Serialize: failed to serialize OneOffTable: %w", err)
+		}
+	}
+	writer.WriteI64(it.TotalHostExecutionDuration.Micros)
+	return nil
+}
@@ -0,0 +1,28 @@
+package spacetimedb
+
+import "fmt"
+
+type OneOffTable struct {
+	TableName string
+	Rows      *BsatnRowList
+}
+
+func (it *OneOffTable) Deserialize(reader *BinaryReader) error {
+
+	it.TableName = reader.ReadString()
+
+	it.Rows = &BsatnRowList{}
+	if err := it.Rows.Deserialize(reader); err != nil {
+		return fmt.Errorf("OneOffTable.Deserialize: failed to deserialize Rows: %w", err)
+	}
+
+	return reader.Err()
+}
+
+func (it *OneOffTable) Serialize(writer *BinaryWriter) error {
+	if it.Rows == nil {
+		return fmt.Errorf("OneOffTable.Serialize: missing Rows")
+	}
+	writer.WriteString(it.TableName)
+	return it.Rows.Serialize(writer)
+}
@@ -1,5 +1,7 @@
 package spacetimedb
 
+import "fmt"
+
 type QueryUpdate struct {
 	Deletes *BsatnRowList
 	Inserts *BsatnRowList
@@ -8,12 +10,16 @@ type QueryUpdate struct {
 func (it *QueryUpdate) Deserialize(reader *BinaryReader) error {
 
 	it.Deletes = &BsatnRowList{}
-	it.Deletes.Deserialize(reader)
+	if err := it.Deletes.Deserialize(reader); err != nil {
+		return fmt.Errorf("QueryUpdate.Deserialize: failed to deserialize Deletes: %w", err)
+	}
 
 	it.Inserts = &BsatnRowList{}
-	it.Inserts.Deserialize(reader)
+	if err := it.Inserts.Deserialize(reader); err != nil {
+		return fmt.Errorf("QueryUpdate.Deserialize: failed to deserialize Inserts: %w", err)
+	}
 
-	return nil
+	return reader.Err()
 }
 
 func (it *QueryUpdate) String() string {
@@ -26,3 +32,16 @@ func (it *QueryUpdate) String() string {
 	}
 	return result
 }
+
+func (it *QueryUpdate) Serialize(writer *BinaryWriter) error {
+	if it.Deletes == nil || it.Inserts == nil {
+		return fmt.Errorf("QueryUpdate.Serialize: missing Deletes or Inserts")
+	}
+	if err := it.Deletes.Serialize(writer); err != nil {
+		return fmt.Errorf("QueryUpdate.Serialize: failed to serialize Deletes: %w", err)
+	}
+	if err := it.Inserts.Serialize(writer); err != nil {
+		return fmt.Errorf("QueryUpdate.Serialize: failed to serialize Inserts: %w", err)
+	}
+	return nil
+}
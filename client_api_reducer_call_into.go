@@ -14,9 +14,17 @@ func (it *ReducerCallInfo) Deserialize(reader *BinaryReader) error {
 	it.Args = reader.ReadUInt8Array()
 	it.RequestID = reader.ReadU32()
 
-	return nil
+	return reader.Err()
 }
 
 func (it *ReducerCallInfo) String() string {
 	return it.ReducerName
 }
+
+func (it *ReducerCallInfo) Serialize(writer *BinaryWriter) error {
+	writer.WriteString(it.ReducerName)
+	writer.WriteU32(it.ReducerID)
+	writer.WriteUInt8Array(it.Args)
+	writer.WriteU32(it.RequestID)
+	return nil
+}
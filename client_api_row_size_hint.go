@@ -32,7 +32,7 @@ func (it *RowSizeHint) Deserialize(reader *BinaryReader) error {
 	default:
 		return fmt.Errorf("RowSizeHint.Deserialize: unknown union type 0x%02x", unionType)
 	}
-	return nil
+	return reader.Err()
 }
 
 func (it *RowSizeHint) String() string {
@@ -45,3 +45,19 @@ func (it *RowSizeHint) String() string {
 		return fmt.Sprintf("Unknown RowSizeHint type: %T", it.RowSizeHint)
 	}
 }
+
+func (it *RowSizeHint) Serialize(writer *BinaryWriter) error {
+	switch hint := it.RowSizeHint.(type) {
+	case *RowSizeHintFixedSize:
+		writer.WriteU8(0x00)
+		writer.WriteU16(hint.FixedSize)
+	case *RowSizeHintRowOffsets:
+		writer.WriteU8(0x01)
+		WriteArray(writer, hint.RowOffsets, func(writer *BinaryWriter, offset uint64) {
+			writer.WriteU64(offset)
+		})
+	default:
+		return fmt.Errorf("RowSizeHint.Serialize: unknown size hint type %T", it.RowSizeHint)
+	}
+	return nil
+}
@@ -2,7 +2,6 @@ package spacetimedb
 
 import (
 	"fmt"
-	"log"
 )
 
 type ServerMessage struct {
@@ -25,14 +24,86 @@ func (sm *ServerMessage) Deserialize(reader *BinaryReader) error {
 		}
 		sm.Message = transactionUpdate
 	case 0x02:
-		log.Println("ServerMessage.Deserialize: type 0x02 is not implemented yet")
+		transactionUpdateLight := &TransactionUpdateLight{}
+		if err := transactionUpdateLight.Deserialize(reader); err != nil {
+			return fmt.Errorf("failed to deserialize TransactionUpdateLight: %w", err)
+		}
+		sm.Message = transactionUpdateLight
 	case 0x03:
 		identityToken := &IdentityToken{}
 		if err := identityToken.Deserialize(reader); err != nil {
 			return fmt.Errorf("failed to deserialize IdentityToken: %w", err)
 		}
 		sm.Message = identityToken
+	case 0x04:
+		oneOffQueryResponse := &OneOffQueryResponse{}
+		if err := oneOffQueryResponse.Deserialize(reader); err != nil {
+			return fmt.Errorf("failed to deserialize OneOffQueryResponse: %w", err)
+		}
+		sm.Message = oneOffQueryResponse
+	case 0x05:
+		subscribeApplied := &SubscribeApplied{}
+		if err := subscribeApplied.Deserialize(reader); err != nil {
+			return fmt.Errorf("failed to deserialize SubscribeApplied: %w", err)
+		}
+		sm.Message = subscribeApplied
+	case 0x06:
+		unsubscribeApplied := &UnsubscribeApplied{}
+		if err := unsubscribeApplied.Deserialize(reader); err != nil {
+			return fmt.Errorf("failed to deserialize UnsubscribeApplied: %w", err)
+		}
+		sm.Message = unsubscribeApplied
+	case 0x07:
+		subscriptionError := &SubscriptionError{}
+		if err := subscriptionError.Deserialize(reader); err != nil {
+			return fmt.Errorf("failed to deserialize SubscriptionError: %w", err)
+		}
+		sm.Message = subscriptionError
+	case 0x08:
+		subscribeMultiApplied := &SubscribeMultiApplied{}
+		if err := subscribeMultiApplied.Deserialize(reader); err != nil {
+			return fmt.Errorf("failed to deserialize SubscribeMultiApplied: %w", err)
+		}
+		sm.Message = subscribeMultiApplied
+	case 0x09:
+		unsubscribeMultiApplied := &UnsubscribeMultiApplied{}
+		if err := unsubscribeMultiApplied.Deserialize(reader); err != nil {
+			return fmt.Errorf("failed to deserialize UnsubscribeMultiApplied: %w", err)
+		}
+		sm.Message = unsubscribeMultiApplied
+	default:
+		return fmt.Errorf("ServerMessage.Deserialize: unknown union type 0x%02x", unionType)
 	}
 
-	return nil
+	return reader.Err()
+}
+
+func (sm *ServerMessage) Serialize(writer *BinaryWriter) error {
+	var unionType uint8
+	switch sm.Message.(type) {
+	case *InitialSubscription:
+		unionType = 0x00
+	case *TransactionUpdate:
+		unionType = 0x01
+	case *TransactionUpdateLight:
+		unionType = 0x02
+	case *IdentityToken:
+		unionType = 0x03
+	case *OneOffQueryResponse:
+		unionType = 0x04
+	case *SubscribeApplied:
+		unionType = 0x05
+	case *UnsubscribeApplied:
+		unionType = 0x06
+	case *SubscriptionError:
+		unionType = 0x07
+	case *SubscribeMultiApplied:
+		unionType = 0x08
+	case *UnsubscribeMultiApplied:
+		unionType = 0x09
+	default:
+		return fmt.Errorf("unsupported message type when serializing ServerMessage: %T", sm.Message)
+	}
+	writer.WriteU8(unionType)
+	return sm.Message.(interface{ Serialize(*BinaryWriter) error }).Serialize(writer)
 }
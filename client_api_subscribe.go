@@ -13,20 +13,25 @@ func (cr *Subscribe) Serialize(writer *BinaryWriter) error {
 	return nil
 }
 
-func (conn *DBConnection) Subscribe(queryStrings ...string) error {
+func (cr *Subscribe) Deserialize(reader *BinaryReader) error {
+	cr.QueryStrings = ReadArray(reader, reader.ReadString)
+	cr.RequestId = reader.ReadU32()
+	return reader.Err()
+}
 
-	clientMsg := &ClientMessage{
-		Message: &Subscribe{
-			QueryStrings: queryStrings,
-			RequestId:    0, // TODO: Support request IDs
-		},
+// Subscribe sends the legacy Subscribe message, which replaces all queries previously subscribed to
+// with Subscribe. Use NewSubscription to manage subscriptions that can be unsubscribed individually.
+func (conn *DBConnection) Subscribe(queryStrings ...string) error {
+	err := conn.sendClientMessage(&Subscribe{
+		QueryStrings: queryStrings,
+		RequestId:    conn.newRequestId(),
+	})
+	if err != nil {
+		return err
 	}
-
-	writer := NewBinaryWriter()
-
-	clientMsg.Serialize(writer)
-
-	msg := writer.GetBuffer()
-	conn.Logger("Sending ClientMessage: %s", clientMsg)
-	return conn.SendMessage(msg)
+	// A Subscribe replaces the previous set of subscribed queries, so only the latest is kept for resubscribing.
+	conn.mu.Lock()
+	conn.subscriptionQueries = queryStrings
+	conn.mu.Unlock()
+	return nil
 }
@@ -0,0 +1,23 @@
+package spacetimedb
+
+type SubscribeMulti struct {
+	QueryStrings []string
+	RequestId    uint32
+	QueryId      uint32
+}
+
+func (cr *SubscribeMulti) Serialize(writer *BinaryWriter) error {
+	WriteArray(writer, cr.QueryStrings, func(writer *BinaryWriter, item string) {
+		writer.WriteString(item)
+	})
+	writer.WriteU32(cr.RequestId)
+	writer.WriteU32(cr.QueryId)
+	return nil
+}
+
+func (cr *SubscribeMulti) Deserialize(reader *BinaryReader) error {
+	cr.QueryStrings = ReadArray(reader, reader.ReadString)
+	cr.RequestId = reader.ReadU32()
+	cr.QueryId = reader.ReadU32()
+	return reader.Err()
+}
@@ -0,0 +1,34 @@
+package spacetimedb
+
+import "fmt"
+
+type SubscribeRows struct {
+	TableID   uint32
+	TableName string
+	TableRows *TableUpdate
+}
+
+func (it *SubscribeRows) Deserialize(reader *BinaryReader) error {
+
+	it.TableID = reader.ReadU32()
+	it.TableName = reader.ReadString()
+
+	it.TableRows = &TableUpdate{}
+	if err := it.TableRows.Deserialize(reader); err != nil {
+		return fmt.Errorf("SubscribeRows.Deserialize: failed to deserialize TableRows: %w", err)
+	}
+
+	return reader.Err()
+}
+
+func (it *SubscribeRows) Serialize(writer *BinaryWriter) error {
+	if it.TableRows == nil {
+		return fmt.Errorf("SubscribeRows.Serialize: missing TableRows")
+	}
+	writer.WriteU32(it.TableID)
+	writer.WriteString(it.TableName)
+	if err := it.TableRows.Serialize(writer); err != nil {
+		return fmt.Errorf("SubscribeRows.Serialize: failed to serialize TableRows: %w", err)
+	}
+	return nil
+}
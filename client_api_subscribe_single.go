@@ -0,0 +1,21 @@
+package spacetimedb
+
+type SubscribeSingle struct {
+	Query     string
+	RequestId uint32
+	QueryId   uint32
+}
+
+func (cr *SubscribeSingle) Serialize(writer *BinaryWriter) error {
+	writer.WriteString(cr.Query)
+	writer.WriteU32(cr.RequestId)
+	writer.WriteU32(cr.QueryId)
+	return nil
+}
+
+func (cr *SubscribeSingle) Deserialize(reader *BinaryReader) error {
+	cr.Query = reader.ReadString()
+	cr.RequestId = reader.ReadU32()
+	cr.QueryId = reader.ReadU32()
+	return reader.Err()
+}
@@ -0,0 +1,40 @@
+package spacetimedb
+
+import "fmt"
+
+type SubscriptionError struct {
+	TotalHostExecutionDuration *TimeDuration
+	// RequestId, QueryId and TableId are nil when the error is not caused by a specific request,
+	// in which case all subscriptions of the connection have been dropped.
+	RequestId    *uint32
+	QueryId      *uint32
+	TableId      *uint32
+	ErrorMessage string
+}
+
+func (it *SubscriptionError) Deserialize(reader *BinaryReader) error {
+
+	it.TotalHostExecutionDuration = NewTimeDuration(int64(reader.ReadU64()))
+	it.RequestId = ReadOption(reader, reader.ReadU32)
+	it.QueryId = ReadOption(reader, reader.ReadU32)
+	it.TableId = ReadOption(reader, reader.ReadU32)
+	it.ErrorMessage = reader.ReadString()
+
+	return reader.Err()
+}
+
+func (it *SubscriptionError) Error() string {
+	return it.ErrorMessage
+}
+
+func (it *SubscriptionError) Serialize(writer *BinaryWriter) error {
+	if it.TotalHostExecutionDuration == nil {
+		return fmt.Errorf("SubscriptionError.Serialize: missing TotalHostExecutionDuration")
+	}
+	writer.WriteU64(uint64(it.TotalHostExecutionDuration.Micros))
+	WriteOption(writer, it.RequestId, writer.WriteU32)
+	WriteOption(writer, it.QueryId, writer.WriteU32)
+	WriteOption(writer, it.TableId, writer.WriteU32)
+	writer.WriteString(it.ErrorMessage)
+	return nil
+}
@@ -1,5 +1,7 @@
 package spacetimedb
 
+import "fmt"
+
 type TableUpdate struct {
 	TableID   uint32
 	TableName string
@@ -16,12 +18,14 @@ func (it *TableUpdate) Deserialize(reader *BinaryReader) error {
 
 	updates := ReadArray(reader, func() *QueryUpdate {
 		update := &CompressableQueryUpdate{}
-		update.Deserialize(reader)
+		if err := update.Deserialize(reader); err != nil {
+			reader.SetErr(fmt.Errorf("TableUpdate.Deserialize: failed to deserialize CompressableQueryUpdate: %w", err))
+		}
 		return update.Update
 	})
 	it.Updates = updates
 
-	return nil
+	return reader.Err()
 }
 
 func (it *TableUpdate) String() string {
@@ -34,3 +38,17 @@ func (it *TableUpdate) String() string {
 	}
 	return result
 }
+
+// Serialize writes the table update with uncompressed query updates.
+func (it *TableUpdate) Serialize(writer *BinaryWriter) error {
+	writer.WriteU32(it.TableID)
+	writer.WriteString(it.TableName)
+	writer.WriteU64(it.NumRows)
+	writer.WriteU32(uint32(len(it.Updates)))
+	for _, update := range it.Updates {
+		if err := (&CompressableQueryUpdate{Update: update}).Serialize(writer); err != nil {
+			return fmt.Errorf("TableUpdate.Serialize: failed to serialize CompressableQueryUpdate: %w", err)
+		}
+	}
+	return nil
+}
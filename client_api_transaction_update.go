@@ -5,8 +5,8 @@ import "fmt"
 type TransactionUpdate struct {
 	Status                     *UpdateStatus
 	Timestamp                  *Timestamp
-	CallerIdentity             *Identity
-	CallerConnectionId         *ConnectionId
+	CallerIdentity             Identity
+	CallerConnectionId         ConnectionId
 	ReducerCall                *ReducerCallInfo
 	EnergyQuantaUsed           *EnergyQuanta
 	TotalHostExecutionDuration *TimeDuration
@@ -21,11 +21,9 @@ func (it *TransactionUpdate) Deserialize(reader *BinaryReader) error {
 
 	it.Timestamp = NewTimestamp(reader.ReadI64())
 
-	it.CallerIdentity = &Identity{}
 	if err := it.CallerIdentity.Deserialize(reader); err != nil {
 		return fmt.Errorf("TransactionUpdate.Deserialize: failed to deserialize CallerIdentity: %w", err)
 	}
-	it.CallerConnectionId = &ConnectionId{}
 	if err := it.CallerConnectionId.Deserialize(reader); err != nil {
 		return fmt.Errorf("TransactionUpdate.Deserialize: failed to deserialize CallerConnectionId: %w", err)
 	}
@@ -39,5 +37,25 @@ func (it *TransactionUpdate) Deserialize(reader *BinaryReader) error {
 	}
 	it.TotalHostExecutionDuration = NewTimeDuration(reader.ReadI64())
 
+	return reader.Err()
+}
+
+func (it *TransactionUpdate) Serialize(writer *BinaryWriter) error {
+	if it.Status == nil || it.Timestamp == nil || it.ReducerCall == nil || it.EnergyQuantaUsed == nil || it.TotalHostExecutionDuration == nil {
+		return fmt.Errorf("TransactionUpdate.Serialize: missing field")
+	}
+	if err := it.Status.Serialize(writer); err != nil {
+		return fmt.Errorf("TransactionUpdate.Serialize: failed to serialize Status: %w", err)
+	}
+	writer.WriteI64(it.Timestamp.MicrosSinceUnixEpoch())
+	if err := it.CallerIdentity.Serialize(writer); err != nil {
+		return fmt.Errorf("TransactionUpdate.Serialize: failed to serialize CallerIdentity: %w", err)
+	}
+	if err := it.CallerConnectionId.Serialize(writer); err != nil {
+		return fmt.Errorf("TransactionUpdate.Serialize: failed to serialize CallerConnectionId: %w", err)
+	}
+	it.ReducerCall.Serialize(writer)
+	it.EnergyQuantaUsed.Serialize(writer)
+	writer.WriteI64(it.TotalHostExecutionDuration.Micros)
 	return nil
 }
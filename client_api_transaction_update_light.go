@@ -0,0 +1,31 @@
+package spacetimedb
+
+import "fmt"
+
+type TransactionUpdateLight struct {
+	RequestId uint32
+	Update    *DatabaseUpdate
+}
+
+func (it *TransactionUpdateLight) Deserialize(reader *BinaryReader) error {
+
+	it.RequestId = reader.ReadU32()
+
+	it.Update = &DatabaseUpdate{}
+	if err := it.Update.Deserialize(reader); err != nil {
+		return fmt.Errorf("TransactionUpdateLight.Deserialize: failed to deserialize Update: %w", err)
+	}
+
+	return reader.Err()
+}
+
+func (it *TransactionUpdateLight) Serialize(writer *BinaryWriter) error {
+	if it.Update == nil {
+		return fmt.Errorf("TransactionUpdateLight.Serialize: missing Update")
+	}
+	writer.WriteU32(it.RequestId)
+	if err := it.Update.Serialize(writer); err != nil {
+		return fmt.Errorf("TransactionUpdateLight.Serialize: failed to serialize Update: %w", err)
+	}
+	return nil
+}
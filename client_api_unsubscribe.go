@@ -0,0 +1,18 @@
+package spacetimedb
+
+type Unsubscribe struct {
+	RequestId uint32
+	QueryId   uint32
+}
+
+func (cr *Unsubscribe) Serialize(writer *BinaryWriter) error {
+	writer.WriteU32(cr.RequestId)
+	writer.WriteU32(cr.QueryId)
+	return nil
+}
+
+func (cr *Unsubscribe) Deserialize(reader *BinaryReader) error {
+	cr.RequestId = reader.ReadU32()
+	cr.QueryId = reader.ReadU32()
+	return reader.Err()
+}
@@ -0,0 +1,37 @@
+package spacetimedb
+
+import "fmt"
+
+type UnsubscribeApplied struct {
+	RequestId                  uint32
+	TotalHostExecutionDuration *TimeDuration
+	QueryId                    uint32
+	Rows                       *SubscribeRows
+}
+
+func (it *UnsubscribeApplied) Deserialize(reader *BinaryReader) error {
+
+	it.RequestId = reader.ReadU32()
+	it.TotalHostExecutionDuration = NewTimeDuration(int64(reader.ReadU64()))
+	it.QueryId = reader.ReadU32()
+
+	it.Rows = &SubscribeRows{}
+	if err := it.Rows.Deserialize(reader); err != nil {
+		return fmt.Errorf("UnsubscribeApplied.Deserialize: failed to deserialize Rows: %w", err)
+	}
+
+	return reader.Err()
+}
+
+func (it *UnsubscribeApplied) Serialize(writer *BinaryWriter) error {
+	if it.TotalHostExecutionDuration == nil || it.Rows == nil {
+		return fmt.Errorf("UnsubscribeApplied.Serialize: missing field")
+	}
+	writer.WriteU32(it.RequestId)
+	writer.WriteU64(uint64(it.TotalHostExecutionDuration.Micros))
+	writer.WriteU32(it.QueryId)
+	if err := it.Rows.Serialize(writer); err != nil {
+		return fmt.Errorf("UnsubscribeApplied.Serialize: failed to serialize Rows: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package spacetimedb
+
+type UnsubscribeMulti struct {
+	RequestId uint32
+	QueryId   uint32
+}
+
+func (cr *UnsubscribeMulti) Serialize(writer *BinaryWriter) error {
+	writer.WriteU32(cr.RequestId)
+	writer.WriteU32(cr.QueryId)
+	return nil
+}
+
+func (cr *UnsubscribeMulti) Deserialize(reader *BinaryReader) error {
+	cr.RequestId = reader.ReadU32()
+	cr.QueryId = reader.ReadU32()
+	return reader.Err()
+}
@@ -0,0 +1,37 @@
+package spacetimedb
+
+import "fmt"
+
+type UnsubscribeMultiApplied struct {
+	RequestId                  uint32
+	TotalHostExecutionDuration *TimeDuration
+	QueryId                    uint32
+	Update                     *DatabaseUpdate
+}
+
+func (it *UnsubscribeMultiApplied) Deserialize(reader *BinaryReader) error {
+
+	it.RequestId = reader.ReadU32()
+	it.TotalHostExecutionDuration = NewTimeDuration(int64(reader.ReadU64()))
+	it.QueryId = reader.ReadU32()
+
+	it.Update = &DatabaseUpdate{}
+	if err := it.Update.Deserialize(reader); err != nil {
+		return fmt.Errorf("UnsubscribeMultiApplied.Deserialize: failed to deserialize Update: %w", err)
+	}
+
+	return reader.Err()
+}
+
+func (it *UnsubscribeMultiApplied) Serialize(writer *BinaryWriter) error {
+	if it.TotalHostExecutionDuration == nil || it.Update == nil {
+		return fmt.Errorf("UnsubscribeMultiApplied.Serialize: missing field")
+	}
+	writer.WriteU32(it.RequestId)
+	writer.WriteU64(uint64(it.TotalHostExecutionDuration.Micros))
+	writer.WriteU32(it.QueryId)
+	if err := it.Update.Serialize(writer); err != nil {
+		return fmt.Errorf("UnsubscribeMultiApplied.Serialize: failed to serialize Update: %w", err)
+	}
+	return nil
+}
@@ -36,7 +36,7 @@ func (it *UpdateStatus) Deserialize(reader *BinaryReader) error {
 	default:
 		return fmt.Errorf("UpdateStatus.Deserialize: unknown union type 0x%02x", unionType)
 	}
-	return nil
+	return reader.Err()
 }
 
 func (it *UpdateStatus) String() string {
@@ -51,3 +51,22 @@ func (it *UpdateStatus) String() string {
 		return fmt.Sprintf("Unknown UpdateStatus type: %T", it.Status)
 	}
 }
+
+func (it *UpdateStatus) Serialize(writer *BinaryWriter) error {
+	switch status := it.Status.(type) {
+	case *UpdateStatusComitted:
+		writer.WriteU8(0x00)
+		if status.DatabaseUpdate == nil {
+			return fmt.Errorf("UpdateStatus.Serialize: missing DatabaseUpdate")
+		}
+		return status.DatabaseUpdate.Serialize(writer)
+	case *UpdateStatusFailed:
+		writer.WriteU8(0x01)
+		writer.WriteString(status.ErrorMessage)
+	case *UpdateStatusOutOfEnergy:
+		writer.WriteU8(0x02)
+	default:
+		return fmt.Errorf("UpdateStatus.Serialize: unknown status type %T", it.Status)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package spacetimedb
+
+import "fmt"
+
+// clientCache keeps track of how many times each BSATN encoded row has been applied to each table.
+// Overlapping subscriptions can each return the same row, so a row is only inserted into the table
+// when its count goes from 0 to 1, and only deleted when it goes from 1 to 0.
+type clientCache struct {
+	tables map[string]map[string]*cachedRow
+}
+
+type cachedRow struct {
+	bytes []byte
+	count int
+}
+
+func newClientCache() *clientCache {
+	return &clientCache{
+		tables: make(map[string]map[string]*cachedRow),
+	}
+}
+
+// insert increments the count of row and reports whether the row was not cached before.
+func (c *clientCache) insert(tableName string, row []byte) bool {
+	rows := c.tables[tableName]
+	if rows == nil {
+		rows = make(map[string]*cachedRow)
+		c.tables[tableName] = rows
+	}
+	cached := rows[string(row)]
+	if cached == nil {
+		cached = &cachedRow{bytes: row}
+		rows[string(row)] = cached
+	}
+	cached.count++
+	return cached.count == 1
+}
+
+// delete decrements the count of row and reports whether the row is no longer cached.
+func (c *clientCache) delete(tableName string, row []byte) bool {
+	cached := c.tables[tableName][string(row)]
+	if cached == nil {
+		return false
+	}
+	cached.count--
+	if cached.count > 0 {
+		return false
+	}
+	delete(c.tables[tableName], string(row))
+	return true
+}
+
+// count returns how many times row is currently applied to the given table.
+func (c *clientCache) count(tableName string, row []byte) int {
+	if cached := c.tables[tableName][string(row)]; cached != nil {
+		return cached.count
+	}
+	return 0
+}
+
+// rows returns the rows currently applied to the given table.
+func (c *clientCache) rows(tableName string) [][]byte {
+	rows := make([][]byte, 0, len(c.tables[tableName]))
+	for _, cached := range c.tables[tableName] {
+		rows = append(rows, cached.bytes)
+	}
+	return rows
+}
+
+// applyUpdates applies the rows deleted and inserted by table updates to the cache.
+func (c *clientCache) applyUpdates(updates []*TableUpdate) error {
+	return eachQueryUpdate(updates, func(tableName string, deletes, inserts [][]byte) {
+		for _, row := range deletes {
+			c.delete(tableName, row)
+		}
+		for _, row := range inserts {
+			c.insert(tableName, row)
+		}
+	})
+}
+
+// rowChanges holds the rows deleted from and inserted into a table.
+type rowChanges struct {
+	deletes [][]byte
+	inserts [][]byte
+}
+
+// replaceRows returns the rows of updates and, per table, the rows to delete and insert to turn the
+// rows of old into them. A row is repeated as many times as its count differs, and inserted rows
+// keep their order in updates.
+func replaceRows(old *clientCache, updates []*TableUpdate) (*clientCache, map[string]*rowChanges, error) {
+	rows := newClientCache()
+	changes := make(map[string]*rowChanges)
+	tableChanges := func(tableName string) *rowChanges {
+		if changes[tableName] == nil {
+			changes[tableName] = &rowChanges{}
+		}
+		return changes[tableName]
+	}
+	err := eachQueryUpdate(updates, func(tableName string, deletes, inserts [][]byte) {
+		for _, row := range inserts {
+			rows.insert(tableName, row)
+			if rows.count(tableName, row) > old.count(tableName, row) {
+				tableChanges(tableName).inserts = append(tableChanges(tableName).inserts, row)
+			}
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	for tableName, cachedRows := range old.tables {
+		for _, cached := range cachedRows {
+			for range cached.count - rows.count(tableName, cached.bytes) {
+				tableChanges(tableName).deletes = append(tableChanges(tableName).deletes, cached.bytes)
+			}
+		}
+	}
+	return rows, changes, nil
+}
+
+// eachQueryUpdate calls fn with the rows deleted and inserted by every query update.
+func eachQueryUpdate(updates []*TableUpdate, fn func(tableName string, deletes, inserts [][]byte)) error {
+	for _, tableUpdate := range updates {
+		if tableUpdate == nil {
+			continue
+		}
+		for _, update := range tableUpdate.Updates {
+			if update == nil {
+				continue
+			}
+			deletes, err := update.Deletes.Rows()
+			if err != nil {
+				return fmt.Errorf("error reading deleted rows: %w", err)
+			}
+			inserts, err := update.Inserts.Rows()
+			if err != nil {
+				return fmt.Errorf("error reading inserted rows: %w", err)
+			}
+			fn(tableUpdate.TableName, deletes, inserts)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+// Command spacetimedb-go-codegen generates Go bindings for a SpacetimeDB module.
+//
+// It reads the module schema as JSON, which can be obtained with `spacetime describe --json <database>`:
+//
+//	spacetimedb-go-codegen -schema schema.json -out module_bindings
+//
+// Generated files that are no longer part of the bindings are removed from the output directory.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	spacetimedb "github.com/alexanderbh/spacetimedb-go-sdk"
+	"github.com/alexanderbh/spacetimedb-go-sdk/codegen"
+)
+
+func main() {
+	schemaPath := flag.String("schema", "", "path of the module schema JSON, or - to read it from stdin")
+	outDir := flag.String("out", "module_bindings", "directory to write the bindings to")
+	packageName := flag.String("package", "", "package name of the bindings (default: name of the output directory)")
+	flag.Parse()
+
+	if *schemaPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(*schemaPath, *outDir, *packageName); err != nil {
+		fmt.Fprintf(os.Stderr, "spacetimedb-go-codegen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(schemaPath, outDir, packageName string) error {
+	var data []byte
+	var err error
+	if schemaPath == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(schemaPath)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read schema: %w", err)
+	}
+
+	def, err := spacetimedb.ParseModuleDef(data)
+	if err != nil {
+		return err
+	}
+	if packageName == "" {
+		abs, err := filepath.Abs(outDir)
+		if err != nil {
+			return err
+		}
+		packageName = strings.ReplaceAll(filepath.Base(abs), "-", "_")
+	}
+	files, err := codegen.Generate(def, packageName)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := removeStaleFiles(outDir, files); err != nil {
+		return err
+	}
+	for name, source := range files {
+		if err := os.WriteFile(filepath.Join(outDir, name), source, 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// removeStaleFiles removes the generated files in dir that are not in files, such as the
+// bindings of a table that was dropped from the module.
+func removeStaleFiles(dir string, files map[string][]byte) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read output directory: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".go" || files[entry.Name()] != nil {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", entry.Name(), err)
+		}
+		if bytes.HasPrefix(source, []byte(codegen.Header)) {
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", entry.Name(), err)
+			}
+		}
+	}
+	return nil
+}
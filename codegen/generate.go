@@ -0,0 +1,580 @@
+// Package codegen generates Go bindings for a SpacetimeDB module from its schema: row types with
+// BSATN Serialize and Deserialize methods, a TableCache wrapper per table and a function per reducer.
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+)
+
+const sdkImport = "github.com/alexanderbh/spacetimedb-go-sdk"
+
+// Header is the comment at the top of every generated file.
+const Header = "// Code generated by spacetimedb-go-codegen. DO NOT EDIT.\n\n"
+
+// Generate returns the Go source files of the bindings for def, keyed by file name.
+func Generate(def *spacetimedb.ModuleDef, packageName string) (map[string][]byte, error) {
+	g := &generator{
+		def:   def,
+		names: make(map[uint32]string),
+		files: make(map[string][]byte),
+	}
+	for _, typeDef := range def.Types {
+		if int(typeDef.Ty) >= len(def.Typespace.Types) {
+			return nil, fmt.Errorf("type %s refers to missing type %d", typeDef.Name.Name, typeDef.Ty)
+		}
+		g.names[typeDef.Ty] = pascalCase(strings.Join(append(append([]string{}, typeDef.Name.Scope...), typeDef.Name.Name), "_"))
+	}
+
+	for _, typeDef := range def.Types {
+		if err := g.generateType(packageName, typeDef.Ty); err != nil {
+			return nil, fmt.Errorf("failed to generate type %s: %w", typeDef.Name.Name, err)
+		}
+	}
+	for _, table := range def.Tables {
+		if err := g.generateTable(packageName, table); err != nil {
+			return nil, fmt.Errorf("failed to generate table %s: %w", table.Name, err)
+		}
+	}
+	if err := g.generateTables(packageName); err != nil {
+		return nil, err
+	}
+	for _, reducer := range def.Reducers {
+		if reducer.Lifecycle != "" || strings.HasPrefix(reducer.Name, "__") {
+			// Lifecycle reducers are called by the host, not by clients.
+			continue
+		}
+		if err := g.generateReducer(packageName, reducer); err != nil {
+			return nil, fmt.Errorf("failed to generate reducer %s: %w", reducer.Name, err)
+		}
+	}
+	return g.files, nil
+}
+
+type generator struct {
+	def *spacetimedb.ModuleDef
+	// names holds the Go names of the named types in the typespace.
+	names map[uint32]string
+	files map[string][]byte
+}
+
+// addFile formats body and adds it as a file, importing the packages it uses.
+func (g *generator) addFile(fileName, packageName, body string) error {
+	var imports []string
+	for _, imp := range []struct{ path, use string }{
+		{"context", "context."},
+		{"fmt", "fmt."},
+		{"math/big", "big."},
+		{sdkImport, "spacetimedb."},
+	} {
+		if !strings.Contains(body, imp.use) {
+			continue
+		}
+		if imp.path == sdkImport && len(imports) > 0 {
+			imports = append(imports, "")
+		}
+		imports = append(imports, fmt.Sprintf("%q", imp.path))
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(Header)
+	fmt.Fprintf(&buf, "package %s\n\n", packageName)
+	if len(imports) > 0 {
+		fmt.Fprintf(&buf, "import (\n%s\n)\n\n", strings.Join(imports, "\n"))
+	}
+	buf.WriteString(body)
+
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to format %s: %w\n%s", fileName, err, buf.String())
+	}
+	g.files[fileName] = source
+	return nil
+}
+
+func (g *generator) resolve(ref uint32) (*spacetimedb.AlgebraicType, error) {
+	if int(ref) >= len(g.def.Typespace.Types) {
+		return nil, fmt.Errorf("reference to missing type %d", ref)
+	}
+	return &g.def.Typespace.Types[ref], nil
+}
+
+func (g *generator) typeName(ref uint32) (string, error) {
+	name, ok := g.names[ref]
+	if !ok {
+		return "", fmt.Errorf("reference to unnamed type %d", ref)
+	}
+	return name, nil
+}
+
+func (g *generator) generateType(packageName string, ref uint32) error {
+	name, err := g.typeName(ref)
+	if err != nil {
+		return err
+	}
+	ty, err := g.resolve(ref)
+	if err != nil {
+		return err
+	}
+
+	var body string
+	switch {
+	case ty.Kind == spacetimedb.AlgebraicTypeProduct:
+		body, err = g.productType(name, ty.Product)
+	case ty.Kind == spacetimedb.AlgebraicTypeSum:
+		body, err = g.sumType(name, ty.Sum)
+	default:
+		return fmt.Errorf("only product and sum types can be named")
+	}
+	if err != nil {
+		return err
+	}
+	return g.addFile(snakeCase(name)+"_type.go", packageName, body)
+}
+
+func (g *generator) productType(name string, product *spacetimedb.ProductType) (string, error) {
+	recv := receiverName(name)
+	fields := make([]string, len(product.Elements))
+	for i, element := range product.Elements {
+		fields[i] = fieldName(element.Name, i)
+	}
+
+	var decl, serialize, deserialize strings.Builder
+	for i, element := range product.Elements {
+		goType, err := g.goType(&element.Type)
+		if err != nil {
+			return "", fmt.Errorf("field %s: %w", fields[i], err)
+		}
+		fmt.Fprintf(&decl, "%s %s\n", fields[i], goType)
+
+		write, err := g.writeValue(recv+"."+fields[i], &element.Type, "return err", 0)
+		if err != nil {
+			return "", fmt.Errorf("field %s: %w", fields[i], err)
+		}
+		serialize.WriteString(write)
+
+		read, err := g.readValue(&element.Type)
+		if err != nil {
+			return "", fmt.Errorf("field %s: %w", fields[i], err)
+		}
+		fmt.Fprintf(&deserialize, "%s.%s = %s\n", recv, fields[i], read)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "type %s struct {\n%s}\n\n", name, decl.String())
+	fmt.Fprintf(&b, "func (%s *%s) Serialize(writer *spacetimedb.BinaryWriter) error {\n%sreturn nil\n}\n\n", recv, name, serialize.String())
+	fmt.Fprintf(&b, "func (%s *%s) Deserialize(reader *spacetimedb.BinaryReader) error {\n%sreturn reader.Err()\n}\n\n", recv, name, deserialize.String())
+	fmt.Fprintf(&b, "func Deserialize%[1]s(reader *spacetimedb.BinaryReader) (*%[1]s, error) {\n", name)
+	fmt.Fprintf(&b, "row := &%s{}\nif err := row.Deserialize(reader); err != nil {\nreturn nil, err\n}\nreturn row, nil\n}\n", name)
+	return b.String(), nil
+}
+
+func (g *generator) sumType(name string, sum *spacetimedb.SumType) (string, error) {
+	recv := receiverName(name)
+	variants := make([]string, len(sum.Variants))
+	hasPayload := false
+	for i, variant := range sum.Variants {
+		variants[i] = name + fieldName(variant.Name, i)
+		if !variant.Type.IsUnit() {
+			hasPayload = true
+		}
+	}
+
+	var decls, serialize, deserialize strings.Builder
+	for i, variant := range sum.Variants {
+		fmt.Fprintf(&serialize, "case *%s:\nwriter.WriteU8(%d)\n", variants[i], i)
+		if variant.Type.IsUnit() {
+			fmt.Fprintf(&decls, "type %s struct{}\n\n", variants[i])
+			fmt.Fprintf(&deserialize, "case %d:\n%s.Value = &%s{}\n", i, recv, variants[i])
+			continue
+		}
+
+		goType, err := g.goType(&variant.Type)
+		if err != nil {
+			return "", fmt.Errorf("variant %s: %w", variants[i], err)
+		}
+		fmt.Fprintf(&decls, "type %s struct {\nValue %s\n}\n\n", variants[i], goType)
+
+		write, err := g.writeValue("variant.Value", &variant.Type, "return err", 0)
+		if err != nil {
+			return "", fmt.Errorf("variant %s: %w", variants[i], err)
+		}
+		serialize.WriteString(write)
+
+		read, err := g.readValue(&variant.Type)
+		if err != nil {
+			return "", fmt.Errorf("variant %s: %w", variants[i], err)
+		}
+		fmt.Fprintf(&deserialize, "case %d:\n%s.Value = &%s{Value: %s}\n", i, recv, variants[i], read)
+	}
+
+	switchExpr := recv + ".Value.(type)"
+	if hasPayload {
+		switchExpr = "variant := " + switchExpr
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "type %s struct {\n// Value is one of *%s.\nValue any\n}\n\n", name, strings.Join(variants, ", *"))
+	b.WriteString(decls.String())
+	fmt.Fprintf(&b, "func (%s *%s) Serialize(writer *spacetimedb.BinaryWriter) error {\nswitch %s {\n%s", recv, name, switchExpr, serialize.String())
+	fmt.Fprintf(&b, "default:\nreturn fmt.Errorf(\"%s.Serialize: unknown variant %%T\", %s.Value)\n}\nreturn nil\n}\n\n", name, recv)
+	fmt.Fprintf(&b, "func (%s *%s) Deserialize(reader *spacetimedb.BinaryReader) error {\nswitch tag := reader.ReadU8(); tag {\n%s", recv, name, deserialize.String())
+	fmt.Fprintf(&b, "default:\nreturn fmt.Errorf(\"%s.Deserialize: unknown union type 0x%%02x\", tag)\n}\nreturn reader.Err()\n}\n", name)
+	return b.String(), nil
+}
+
+// rowType returns the product type and Go name of the rows of a table.
+func (g *generator) rowType(table spacetimedb.TableDef) (*spacetimedb.ProductType, string, error) {
+	ty, err := g.resolve(table.ProductTypeRef)
+	if err != nil {
+		return nil, "", err
+	}
+	if ty.Kind != spacetimedb.AlgebraicTypeProduct {
+		return nil, "", fmt.Errorf("row type %d is not a product type", table.ProductTypeRef)
+	}
+	name, err := g.typeName(table.ProductTypeRef)
+	if err != nil {
+		return nil, "", err
+	}
+	return ty.Product, name, nil
+}
+
+func (g *generator) generateTable(packageName string, table spacetimedb.TableDef) error {
+	product, rowName, err := g.rowType(table)
+	if err != nil {
+		return err
+	}
+	tableName := pascalCase(table.Name) + "Table"
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s is the client cache of the %s table.\n", tableName, table.Name)
+	fmt.Fprintf(&b, "type %s struct {\n*spacetimedb.TableCache[*%s]\n}\n\n", tableName, rowName)
+
+	if len(table.PrimaryKey) > 1 {
+		return fmt.Errorf("primary keys with more than one column are not supported")
+	}
+	if len(table.PrimaryKey) == 0 {
+		fmt.Fprintf(&b, "func New%s() *%s {\nreturn &%[2]s{\nTableCache: spacetimedb.NewTableCache(Deserialize%s, nil),\n}\n}\n", tableName, tableName, rowName)
+		return g.addFile(table.Name+"_table.go", packageName, b.String())
+	}
+
+	column := int(table.PrimaryKey[0])
+	if column >= len(product.Elements) {
+		return fmt.Errorf("primary key column %d does not exist", column)
+	}
+	element := product.Elements[column]
+	field := fieldName(element.Name, column)
+	param := paramName(element.Name, column)
+	goType, err := g.goType(&element.Type)
+	if err != nil {
+		return err
+	}
+	rowKey, err := keyValue("row."+field, &element.Type)
+	if err != nil {
+		return fmt.Errorf("primary key %s: %w", field, err)
+	}
+	paramKey, _ := keyValue(param, &element.Type)
+	keyFunc := lowerFirst(pascalCase(table.Name)) + "PrimaryKey"
+
+	fmt.Fprintf(&b, "func New%s() *%s {\nreturn &%[2]s{\nTableCache: spacetimedb.NewTableCache(Deserialize%s, %s),\n}\n}\n\n", tableName, tableName, rowName, keyFunc)
+	fmt.Fprintf(&b, "func %s(row *%s) string {\nreturn %s\n}\n\n", keyFunc, rowName, rowKey)
+	fmt.Fprintf(&b, "// FindBy%s returns the cached row with the given %s.\n", field, elementName(element.Name, column))
+	fmt.Fprintf(&b, "func (t *%s) FindBy%s(%s %s) (*%s, bool) {\n", tableName, field, param, goType, rowName)
+	fmt.Fprintf(&b, "return t.Get(%s)\n}\n", paramKey)
+	return g.addFile(table.Name+"_table.go", packageName, b.String())
+}
+
+// generateTables adds RemoteTables with the cache of every table, its constructor and TableNameMap
+// method, and the default Db and Tables for programs with a single connection.
+func (g *generator) generateTables(packageName string) error {
+	var fields, values, entries strings.Builder
+	for _, table := range g.def.Tables {
+		name := pascalCase(table.Name)
+		fmt.Fprintf(&fields, "%s *%sTable\n", name, name)
+		fmt.Fprintf(&values, "%s: New%sTable(),\n", name, name)
+		fmt.Fprintf(&entries, "%q: t.%s,\n", table.Name, name)
+	}
+
+	var b strings.Builder
+	b.WriteString("// RemoteTables holds the client cache of every table. Every DBConnection needs its own.\n")
+	fmt.Fprintf(&b, "type RemoteTables struct {\n%s}\n\n", fields.String())
+	b.WriteString("// NewRemoteTables creates empty client caches for all tables.\n")
+	fmt.Fprintf(&b, "func NewRemoteTables() *RemoteTables {\nreturn &RemoteTables{\n%s}\n}\n\n", values.String())
+	b.WriteString("// TableNameMap returns the tables by name, to pass to spacetimedb.WithTableNameMap.\n")
+	fmt.Fprintf(&b, "func (t *RemoteTables) TableNameMap() spacetimedb.TableNameMap {\nreturn spacetimedb.TableNameMap{\n%s}\n}\n\n", entries.String())
+	b.WriteString("// Db holds the tables of a program with a single connection. Use NewRemoteTables for more connections.\n")
+	b.WriteString("var Db = NewRemoteTables()\n\n")
+	b.WriteString("// Tables is the TableNameMap of Db.\n")
+	b.WriteString("var Tables = Db.TableNameMap()\n")
+	return g.addFile("tables.go", packageName, b.String())
+}
+
+func (g *generator) generateReducer(packageName string, reducer spacetimedb.ReducerDef) error {
+	name := pascalCase(reducer.Name)
+	params := make([]string, len(reducer.Params.Elements))
+	args := make([]string, len(reducer.Params.Elements))
+	var serialize strings.Builder
+	for i, element := range reducer.Params.Elements {
+		param := paramName(element.Name, i)
+		goType, err := g.goType(&element.Type)
+		if err != nil {
+			return fmt.Errorf("param %s: %w", param, err)
+		}
+		params[i] = param + " " + goType
+		args[i] = param
+
+		write, err := g.writeValue(param, &element.Type, "return nil, err", 0)
+		if err != nil {
+			return fmt.Errorf("param %s: %w", param, err)
+		}
+		serialize.WriteString(write)
+	}
+	paramList := strings.Join(params, ", ")
+	if paramList != "" {
+		paramList = ", " + paramList
+	}
+	argList := strings.Join(args, ", ")
+	serializeFunc := "serialize" + name + "Args"
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s calls the %s reducer with the DefaultReducerFlags of conn, without waiting for its result.\n", name, reducer.Name)
+	fmt.Fprintf(&b, "func %s(conn *spacetimedb.DBConnection%s) error {\n", name, paramList)
+	fmt.Fprintf(&b, "args, err := %s(%s)\nif err != nil {\nreturn err\n}\n", serializeFunc, argList)
+	fmt.Fprintf(&b, "if err := conn.CallReducer(%q, args, 0, conn.DefaultReducerFlags); err != nil {\n", reducer.Name)
+	fmt.Fprintf(&b, "return fmt.Errorf(\"failed to call %s reducer: %%w\", err)\n}\nreturn nil\n}\n\n", reducer.Name)
+
+	fmt.Fprintf(&b, "// %sAsync calls the %s reducer and waits for its result.\n", name, reducer.Name)
+	fmt.Fprintf(&b, "func %sAsync(ctx context.Context, conn *spacetimedb.DBConnection%s) (*spacetimedb.ReducerResult, error) {\n", name, paramList)
+	fmt.Fprintf(&b, "args, err := %s(%s)\nif err != nil {\nreturn nil, err\n}\n", serializeFunc, argList)
+	fmt.Fprintf(&b, "return conn.CallReducerAsync(ctx, %q, args)\n}\n\n", reducer.Name)
+
+	fmt.Fprintf(&b, "func %s(%s) ([]byte, error) {\n", serializeFunc, strings.TrimPrefix(paramList, ", "))
+	fmt.Fprintf(&b, "writer := spacetimedb.NewBinaryWriter()\n%sreturn writer.GetBuffer(), nil\n}\n", serialize.String())
+	return g.addFile(reducer.Name+"_reducer.go", packageName, b.String())
+}
+
+// goType returns the Go type of values of ty. Named types are values, and Options are pointers,
+// which are nil for None.
+func (g *generator) goType(ty *spacetimedb.AlgebraicType) (string, error) {
+	if special, ok := specialTypeOf(ty); ok {
+		return special.goType, nil
+	}
+	if inner, ok := ty.Option(); ok {
+		if _, nested := inner.Option(); nested {
+			return "", fmt.Errorf("nested options are not supported")
+		}
+		value, err := g.goType(&inner)
+		if err != nil {
+			return "", err
+		}
+		if strings.HasPrefix(value, "*") {
+			return value, nil
+		}
+		return "*" + value, nil
+	}
+	switch ty.Kind {
+	case spacetimedb.AlgebraicTypeRef:
+		return g.typeName(ty.Ref)
+	case spacetimedb.AlgebraicTypeArray:
+		if ty.Elem.Kind == spacetimedb.AlgebraicTypeU8 {
+			return "[]byte", nil
+		}
+		elem, err := g.goType(ty.Elem)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + elem, nil
+	case spacetimedb.AlgebraicTypeProduct, spacetimedb.AlgebraicTypeSum:
+		return "", fmt.Errorf("anonymous product and sum types are not supported, give the type a name")
+	}
+	if builtin, ok := builtinTypes[ty.Kind]; ok {
+		return builtin.goType, nil
+	}
+	return "", fmt.Errorf("%s types are not supported", ty.Kind)
+}
+
+// readValue returns an expression reading a value of ty from reader.
+func (g *generator) readValue(ty *spacetimedb.AlgebraicType) (string, error) {
+	if special, ok := specialTypeOf(ty); ok {
+		return special.read, nil
+	}
+	if inner, ok := ty.Option(); ok {
+		value, err := g.goType(&inner)
+		if err != nil {
+			return "", err
+		}
+		if strings.HasPrefix(value, "*") {
+			return g.readWithElement("spacetimedb.ReadNullable", &inner)
+		}
+		return g.readWithElement("spacetimedb.ReadOption", &inner)
+	}
+	switch ty.Kind {
+	case spacetimedb.AlgebraicTypeRef:
+		name, err := g.typeName(ty.Ref)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("*spacetimedb.ReadValue[%s](reader)", name), nil
+	case spacetimedb.AlgebraicTypeArray:
+		if ty.Elem.Kind == spacetimedb.AlgebraicTypeU8 {
+			return "reader.ReadUInt8Array()", nil
+		}
+		return g.readWithElement("spacetimedb.ReadArray", ty.Elem)
+	}
+	if builtin, ok := builtinTypes[ty.Kind]; ok {
+		return "reader.Read" + builtin.method + "()", nil
+	}
+	_, err := g.goType(ty)
+	return "", err
+}
+
+// readWithElement returns a call to a generic reader function that takes a function reading one element.
+func (g *generator) readWithElement(function string, elem *spacetimedb.AlgebraicType) (string, error) {
+	read, err := g.readValue(elem)
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(read, "reader.Read") && strings.HasSuffix(read, "()") {
+		return fmt.Sprintf("%s(reader, %s)", function, strings.TrimSuffix(read, "()")), nil
+	}
+	goType, err := g.goType(elem)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s(reader, func() %s {\nreturn %s\n})", function, goType, read), nil
+}
+
+// writeValue returns the statements writing the value of expr to writer. onError is the statement
+// returning the error of a Serialize method.
+func (g *generator) writeValue(expr string, ty *spacetimedb.AlgebraicType, onError string, depth int) (string, error) {
+	if special, ok := specialTypeOf(ty); ok {
+		return fmt.Sprintf(special.write, expr, onError), nil
+	}
+	if inner, ok := ty.Option(); ok {
+		value, err := g.goType(&inner)
+		if err != nil {
+			return "", err
+		}
+		// Special and named types are serialized by methods, which can be called on the pointer.
+		innerExpr := expr
+		if _, special := specialTypeOf(&inner); !special && inner.Kind != spacetimedb.AlgebraicTypeRef && !strings.HasPrefix(value, "*") {
+			innerExpr = "*" + expr
+		}
+		write, err := g.writeValue(innerExpr, &inner, onError, depth)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("if %s == nil {\nwriter.WriteU8(1)\n} else {\nwriter.WriteU8(0)\n%s}\n", expr, write), nil
+	}
+	switch ty.Kind {
+	case spacetimedb.AlgebraicTypeRef:
+		if _, err := g.typeName(ty.Ref); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("if err := %s.Serialize(writer); err != nil {\n%s\n}\n", expr, onError), nil
+	case spacetimedb.AlgebraicTypeArray:
+		if ty.Elem.Kind == spacetimedb.AlgebraicTypeU8 {
+			return fmt.Sprintf("writer.WriteUInt8Array(%s)\n", expr), nil
+		}
+		item := "item"
+		if depth > 0 {
+			item = fmt.Sprintf("item%d", depth)
+		}
+		write, err := g.writeValue(item, ty.Elem, onError, depth+1)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("writer.WriteU32(uint32(len(%s)))\nfor _, %s := range %s {\n%s}\n", expr, item, expr, write), nil
+	}
+	if builtin, ok := builtinTypes[ty.Kind]; ok {
+		return fmt.Sprintf("writer.Write%s(%s)\n", builtin.method, expr), nil
+	}
+	_, err := g.goType(ty)
+	return "", err
+}
+
+// keyValue returns an expression converting expr to the string key of a TableCache row.
+func keyValue(expr string, ty *spacetimedb.AlgebraicType) (string, error) {
+	switch {
+	case ty.Kind == spacetimedb.AlgebraicTypeString:
+		return expr, nil
+	case ty.Kind >= spacetimedb.AlgebraicTypeBool && ty.Kind <= spacetimedb.AlgebraicTypeU64:
+		return fmt.Sprintf("fmt.Sprint(%s)", expr), nil
+	case ty.Kind >= spacetimedb.AlgebraicTypeI128 && ty.Kind <= spacetimedb.AlgebraicTypeU256:
+		return expr + ".String()", nil
+	case ty.IsIdentity():
+		return expr + ".ToHexString()", nil
+	case ty.IsConnectionId():
+		return expr + ".ToHexString()", nil
+	case ty.IsTimestamp():
+		return fmt.Sprintf("fmt.Sprint(%s.MicrosSinceUnixEpoch())", expr), nil
+	}
+	return "", fmt.Errorf("unsupported primary key type")
+}
+
+type builtinType struct {
+	goType string
+	// method is the suffix of the BinaryReader and BinaryWriter methods for the type.
+	method string
+}
+
+var builtinTypes = map[spacetimedb.AlgebraicTypeKind]builtinType{
+	spacetimedb.AlgebraicTypeBool:   {"bool", "Bool"},
+	spacetimedb.AlgebraicTypeI8:     {"int8", "I8"},
+	spacetimedb.AlgebraicTypeU8:     {"uint8", "U8"},
+	spacetimedb.AlgebraicTypeI16:    {"int16", "I16"},
+	spacetimedb.AlgebraicTypeU16:    {"uint16", "U16"},
+	spacetimedb.AlgebraicTypeI32:    {"int32", "I32"},
+	spacetimedb.AlgebraicTypeU32:    {"uint32", "U32"},
+	spacetimedb.AlgebraicTypeI64:    {"int64", "I64"},
+	spacetimedb.AlgebraicTypeU64:    {"uint64", "U64"},
+	spacetimedb.AlgebraicTypeI128:   {"*big.Int", "I128"},
+	spacetimedb.AlgebraicTypeU128:   {"*big.Int", "U128"},
+	spacetimedb.AlgebraicTypeI256:   {"*big.Int", "I256"},
+	spacetimedb.AlgebraicTypeU256:   {"*big.Int", "U256"},
+	spacetimedb.AlgebraicTypeF32:    {"float32", "F32"},
+	spacetimedb.AlgebraicTypeF64:    {"float64", "F64"},
+	spacetimedb.AlgebraicTypeString: {"string", "String"},
+}
+
+type specialType struct {
+	goType string
+	read   string
+	// write is a format string taking the value and the statement returning an error.
+	write string
+}
+
+// specialTypeOf returns how the values of the types with a Go type in the SDK are generated.
+func specialTypeOf(ty *spacetimedb.AlgebraicType) (specialType, bool) {
+	switch {
+	case ty.IsIdentity():
+		return specialType{
+			goType: "spacetimedb.Identity",
+			read:   "*spacetimedb.ReadValue[spacetimedb.Identity](reader)",
+			write:  "if err := %s.Serialize(writer); err != nil {\n%s\n}\n",
+		}, true
+	case ty.IsConnectionId():
+		return specialType{
+			goType: "spacetimedb.ConnectionId",
+			read:   "*spacetimedb.ReadValue[spacetimedb.ConnectionId](reader)",
+			write:  "if err := %s.Serialize(writer); err != nil {\n%s\n}\n",
+		}, true
+	case ty.IsTimestamp():
+		return specialType{
+			goType: "*spacetimedb.Timestamp",
+			read:   "spacetimedb.ReadValue[spacetimedb.Timestamp](reader)",
+			write:  "if err := %s.Serialize(writer); err != nil {\n%s\n}\n",
+		}, true
+	case ty.IsTimeDuration():
+		return specialType{
+			goType: "*spacetimedb.TimeDuration",
+			read:   "spacetimedb.ReadValue[spacetimedb.TimeDuration](reader)",
+			write:  "if err := %s.Serialize(writer); err != nil {\n%s\n}\n",
+		}, true
+	}
+	return specialType{}, false
+}
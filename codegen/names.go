@@ -0,0 +1,92 @@
+package codegen
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+	"unicode"
+)
+
+// reservedNames are used by the generated code, so parameters with these names are renamed.
+var reservedNames = map[string]bool{
+	"args":        true,
+	"big":         true,
+	"conn":        true,
+	"context":     true,
+	"ctx":         true,
+	"err":         true,
+	"fmt":         true,
+	"spacetimedb": true,
+	"writer":      true,
+}
+
+// elementName returns the name of a product element or sum variant, or a name based on its index
+// if it has none.
+func elementName(name string, index int) string {
+	if name == "" {
+		return fmt.Sprintf("field_%d", index)
+	}
+	return name
+}
+
+// fieldName returns the exported Go name of a product element or sum variant.
+func fieldName(name string, index int) string {
+	return pascalCase(elementName(name, index))
+}
+
+// paramName returns the unexported Go name of a reducer parameter or primary key column.
+func paramName(name string, index int) string {
+	param := lowerFirst(pascalCase(elementName(name, index)))
+	if token.IsKeyword(param) || reservedNames[param] {
+		param += "_"
+	}
+	return param
+}
+
+// receiverName returns the receiver name of the methods of a generated type.
+func receiverName(typeName string) string {
+	return strings.ToLower(typeName[:1])
+}
+
+// pascalCase converts a snake_case name to PascalCase, keeping letters that are already upper case.
+func pascalCase(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if r == '_' || r == '-' || r == ' ' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() == 0 || !unicode.IsLetter([]rune(b.String())[0]) {
+		return "X" + b.String()
+	}
+	return b.String()
+}
+
+// snakeCase converts a PascalCase name to snake_case.
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func lowerFirst(name string) string {
+	runes := []rune(name)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
@@ -0,0 +1,52 @@
+package spacetimedb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/andybalholm/brotli"
+)
+
+// compressionQueryValue returns the value of the `compression` query parameter
+// used when subscribing with the given compression type.
+func compressionQueryValue(compression uint8) (string, error) {
+	switch compression {
+	case CompressionTypeNone:
+		return "None", nil
+	case CompressionTypeBrotly:
+		return "Brotli", nil
+	case CompressionTypeGzip:
+		return "Gzip", nil
+	default:
+		return "", fmt.Errorf("unknown compression type: %d", compression)
+	}
+}
+
+// decompress returns the decompressed form of data, which was compressed with the given compression type.
+func decompress(compression uint8, data []byte) ([]byte, error) {
+	switch compression {
+	case CompressionTypeNone:
+		return data, nil
+	case CompressionTypeBrotly:
+		decompressed, err := io.ReadAll(brotli.NewReader(bytes.NewReader(data)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress brotli data: %w", err)
+		}
+		return decompressed, nil
+	case CompressionTypeGzip:
+		gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+		defer gzipReader.Close()
+		decompressed, err := io.ReadAll(gzipReader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress gzip data: %w", err)
+		}
+		return decompressed, nil
+	default:
+		return nil, fmt.Errorf("unknown compression type: %d", compression)
+	}
+}
@@ -0,0 +1,135 @@
+package spacetimedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gorilla/websocket"
+)
+
+// ConnectContext opens the websocket and waits until the server has accepted the connection and
+// sent its identity. ctx only limits connecting; the connection stays open until Close is called.
+// If ctx expires first, the connection is closed and the error of ctx is returned.
+//
+// In DispatchFrameTick mode it returns once the identity has been received, and OnConnect runs in
+// the next FrameTick.
+func (db *DBConnection) ConnectContext(ctx context.Context) error {
+	handshake := make(chan error, 1)
+	if err := db.connect(ctx, handshake); err != nil {
+		return err
+	}
+
+	select {
+	case err := <-handshake:
+		return err
+	case <-ctx.Done():
+		err := fmt.Errorf("failed to connect: %w", ctx.Err())
+		if db.finishHandshake(err) {
+			db.connectFailed(err)
+		}
+		db.Close()
+		return err
+	}
+}
+
+// Done returns a channel that is closed when the connection has ended for good: after Close, when
+// connecting fails, or when the connection is lost and not reconnected.
+func (db *DBConnection) Done() <-chan struct{} {
+	return db.done
+}
+
+// DisconnectError describes why the connection to the server was lost.
+type DisconnectError struct {
+	// Code is the websocket close code, such as 1000 for a normal closure, or 1006 if the
+	// connection was lost without a close frame. It is 0 if reading failed for another reason.
+	Code int
+	// Reason is the text sent by the server with the close code.
+	Reason string
+	Err    error
+}
+
+func newDisconnectError(err error) *DisconnectError {
+	disconnectErr := &DisconnectError{Err: err}
+	var closeErr *websocket.CloseError
+	if errors.As(err, &closeErr) {
+		disconnectErr.Code = closeErr.Code
+		disconnectErr.Reason = closeErr.Text
+	}
+	return disconnectErr
+}
+
+func (e *DisconnectError) Error() string {
+	if e.Code != 0 {
+		return fmt.Sprintf("connection closed with code %d: %s", e.Code, e.Reason)
+	}
+	return fmt.Sprintf("connection lost: %v", e.Err)
+}
+
+func (e *DisconnectError) Unwrap() error {
+	return e.Err
+}
+
+type handshakeState uint8
+
+const (
+	// handshakeNone is the state of websockets opened by reconnecting, which are not waited for.
+	handshakeNone handshakeState = iota
+	handshakePending
+	handshakeDone
+	handshakeFailed
+)
+
+// finishHandshake reports the outcome of the identity handshake of the current websocket, if it
+// was still pending, and returns whether it was.
+func (db *DBConnection) finishHandshake(err error) bool {
+	db.mu.Lock()
+	if db.handshakeState != handshakePending {
+		db.mu.Unlock()
+		return false
+	}
+	handshake := db.handshake
+	db.handshake = nil
+	db.handshakeState = handshakeDone
+	if err != nil {
+		db.handshakeState = handshakeFailed
+	}
+	db.mu.Unlock()
+	if handshake != nil {
+		handshake <- err
+	}
+	return true
+}
+
+func (db *DBConnection) handshakeFailed() bool {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.handshakeState == handshakeFailed
+}
+
+// connectFailed reports an error connecting to OnConnectError, ends the connection and returns err.
+func (db *DBConnection) connectFailed(err error) error {
+	db.Logger("Failed to connect: %v", err)
+	if db.OnConnectError != nil {
+		db.OnConnectError(err)
+	}
+	db.terminate()
+	return err
+}
+
+// disconnected runs the disconnect callbacks after the websocket was closed.
+func (db *DBConnection) disconnected(err error) {
+	if db.OnDisconnect != nil {
+		db.OnDisconnect(db)
+	}
+	if db.OnDisconnectError != nil {
+		db.OnDisconnectError(db, err)
+	}
+}
+
+// terminate marks the connection as ended for good.
+func (db *DBConnection) terminate() {
+	db.doneOnce.Do(func() {
+		close(db.done)
+	})
+}
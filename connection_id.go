@@ -2,43 +2,39 @@ package spacetimedb
 
 import (
 	"crypto/rand"
+	"encoding/json"
 	"fmt"
 	"math/big"
+	"strings"
 )
 
-// ConnectionId is a unique identifier for a client connected to a database.
-type ConnectionId struct {
-	data *big.Int
-}
+// ConnectionId is a unique identifier for a client connected to a database. It holds the 128-bit
+// value in BSATN byte order, little endian, and is comparable with == and usable as a map key.
+type ConnectionId [16]byte
 
-// NewConnectionId creates a new ConnectionID with the given big.Int data.
-// The provided data is used directly.
-func NewConnectionId(data *big.Int) *ConnectionId {
+// NewConnectionId creates a new ConnectionId with the given big.Int data. A nil data is zero, and
+// bits above the lowest 128 are dropped.
+func NewConnectionId(data *big.Int) ConnectionId {
+	var cid ConnectionId
 	if data == nil {
-		// Ensure data is not nil, similar to how bigint in TS is non-nullable.
-		// Or, document that data must not be nil.
-		// For now, let's initialize to 0 if nil is passed, though this might hide issues.
-		// A better approach might be to panic or return an error if data is nil.
-		// However, internal calls like RandomConnectionID ensure data is non-nil.
-		return &ConnectionId{data: big.NewInt(0)}
+		return cid
 	}
-	return &ConnectionId{data: data}
+	arr, _ := U128ToUint8Array(data)
+	copy(cid[:], arr)
+	return cid
 }
 
-// IsZero checks if the ConnectionID is zero.
-func (cid *ConnectionId) IsZero() bool {
-	if cid == nil || cid.data == nil {
-		return true // Consider a nil ConnectionID or nil data as effectively zero or an invalid state.
-	}
-	return cid.data.Cmp(big.NewInt(0)) == 0
+// IsZero checks if the ConnectionId is zero.
+func (cid ConnectionId) IsZero() bool {
+	return cid == ConnectionId{}
 }
 
-// NullIfZero returns nil if the ConnectionID is zero, otherwise returns the ConnectionID.
-func NullIfZero(addr *ConnectionId) *ConnectionId {
-	if addr == nil || addr.IsZero() {
+// NullIfZero returns nil if the ConnectionId is zero, otherwise returns a pointer to the ConnectionId.
+func NullIfZero(cid ConnectionId) *ConnectionId {
+	if cid.IsZero() {
 		return nil
 	}
-	return addr
+	return &cid
 }
 
 // randomPseudoByte generates a random integer in [0, 254], mimicking Math.floor(Math.random() * 0xff).
@@ -51,98 +47,117 @@ func randomPseudoByte() (uint8, error) {
 	return uint8(n.Uint64()), nil // n.Uint64() is safe as n is small.
 }
 
-// RandomConnectionId creates a new random ConnectionID.
+// RandomConnectionId creates a new random ConnectionId.
 // It replicates the TypeScript logic of building a 128-bit number
 // from 16 "bytes", each in the range [0, 254].
-func RandomConnectionId() (*ConnectionId, error) {
-	pseudoBytes := make([]byte, 16)
-	for i := 0; i < 16; i++ {
+func RandomConnectionId() (ConnectionId, error) {
+	var cid ConnectionId
+	for i := range cid {
 		pb, err := randomPseudoByte()
 		if err != nil {
-			return nil, fmt.Errorf("failed to generate pseudo-byte for ConnectionID: %w", err)
+			return ConnectionId{}, fmt.Errorf("failed to generate pseudo-byte for ConnectionID: %w", err)
 		}
-		pseudoBytes[i] = pb
+		cid[i] = pb
 	}
-	// new(big.Int).SetBytes interprets pseudoBytes as a big-endian unsigned integer.
-	data := new(big.Int).SetBytes(pseudoBytes)
-	return NewConnectionId(data), nil
+	return cid, nil
 }
 
-// IsEqual compares two ConnectionIDs for equality.
-func (cid *ConnectionId) IsEqual(other *ConnectionId) bool {
-	if cid == other { // Handles both being nil
-		return true
-	}
-	if cid == nil || other == nil || cid.data == nil || other.data == nil {
-		return false // One is nil, or internal data is nil
-	}
-	return cid.data.Cmp(other.data) == 0
+// IsEqual compares two ConnectionIds for equality. It is the same as ==.
+func (cid ConnectionId) IsEqual(other ConnectionId) bool {
+	return cid == other
 }
 
-// ToHexString converts the ConnectionID to a hexadecimal string.
-// It relies on U128ToHexString from the utils package.
-func (cid *ConnectionId) ToHexString() (string, error) {
-	if cid == nil || cid.data == nil {
-		return "", fmt.Errorf("cannot convert nil ConnectionID or ConnectionID with nil data to hex string")
-	}
-	return U128ToHexString(cid.data)
+// ToHexString converts the ConnectionId to a hexadecimal string.
+func (cid ConnectionId) ToHexString() string {
+	return Uint8ArrayToHexString(cid[:])
 }
 
-// ToUint8Array converts the ConnectionID to a byte array.
-// It relies on U128ToUint8Array from the utils package.
-func (cid *ConnectionId) ToUint8Array() ([]byte, error) {
-	if cid == nil || cid.data == nil {
-		return nil, fmt.Errorf("cannot convert nil ConnectionID or ConnectionID with nil data to byte array")
-	}
-	return U128ToUint8Array(cid.data)
+// ToUint8Array converts the ConnectionId to a byte array, in BSATN byte order.
+func (cid ConnectionId) ToUint8Array() []byte {
+	return append([]byte(nil), cid[:]...)
 }
 
-// ConnectionIDFromString parses a ConnectionID from a hexadecimal string.
-// It relies on HexStringToU128 from the utils package.
-func ConnectionIDFromString(str string) (*ConnectionId, error) {
-	data, err := HexStringToU128(str)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse ConnectionID from string: %w", err)
-	}
-	if data == nil {
-		// HexStringToU128 should ideally not return (nil, nil)
-		return nil, fmt.Errorf("HexStringToU128 returned nil data without error for string: %s", str)
+func (cid ConnectionId) String() string {
+	return cid.ToHexString()
+}
+
+// ConnectionIDFromString parses a ConnectionId from a hexadecimal string.
+func ConnectionIDFromString(str string) (ConnectionId, error) {
+	var cid ConnectionId
+	if err := parseReversedHex(cid[:], str); err != nil {
+		return ConnectionId{}, fmt.Errorf("failed to parse ConnectionID from string: %w", err)
 	}
-	return NewConnectionId(data), nil
+	return cid, nil
 }
 
-// ConnectionIDFromStringOrNull parses a ConnectionID from a hexadecimal string,
+// ConnectionIDFromStringOrNull parses a ConnectionId from a hexadecimal string,
 // returning (nil, nil) if the parsed ID is zero.
 func ConnectionIDFromStringOrNull(str string) (*ConnectionId, error) {
 	cid, err := ConnectionIDFromString(str)
 	if err != nil {
-		return nil, err // Error during parsing
+		return nil, err
 	}
-	// ConnectionIDFromString should ensure cid and cid.data are not nil if err is nil.
-	if cid.IsZero() {
-		return nil, nil // Parsed to zero, return (nil, nil)
-	}
-	return cid, nil
+	return NullIfZero(cid), nil
 }
 
-// GetData returns the internal *big.Int data.
-// This is provided if direct access to the *big.Int is needed,
-// similar to accessing the `data` property in the TypeScript version.
-func (cid *ConnectionId) GetData() *big.Int {
-	if cid == nil {
-		return nil
-	}
-	return cid.data
+// GetData returns the value of the ConnectionId as a *big.Int.
+func (cid ConnectionId) GetData() *big.Int {
+	value, _ := Uint8ArrayToU128(cid[:])
+	return value
 }
 
-// Serialize serializes the ConnectionID to a BinaryWriter.
-func (cid *ConnectionId) Serialize(writer *BinaryWriter) error {
-	panic("ConnectionId.Serialize: not implemented")
+// Serialize serializes the ConnectionId to a BinaryWriter.
+func (cid ConnectionId) Serialize(writer *BinaryWriter) error {
+	writer.WriteBytes(cid[:])
+	return nil
 }
 
-// Deserialize deserializes a ConnectionID from a BinaryReader.
+// Deserialize deserializes a ConnectionId from a BinaryReader.
 func (cid *ConnectionId) Deserialize(reader *BinaryReader) error {
-	data := reader.ReadU128()
-	cid.data = data
+	copy(cid[:], reader.ReadBytes(len(cid)))
+	return reader.Err()
+}
+
+// MarshalText encodes the ConnectionId as the hex string of ToHexString.
+func (cid ConnectionId) MarshalText() ([]byte, error) {
+	return []byte(cid.ToHexString()), nil
+}
+
+func (cid *ConnectionId) UnmarshalText(text []byte) error {
+	parsed, err := ConnectionIDFromString(string(text))
+	if err != nil {
+		return err
+	}
+	*cid = parsed
+	return nil
+}
+
+// MarshalJSON encodes the ConnectionId in the SATS JSON format, as {"__connection_id__": "0x..."}.
+func (cid ConnectionId) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"__connection_id__": "0x" + cid.ToHexString()})
+}
+
+// UnmarshalJSON decodes a ConnectionId sent either as a hex string or in the SATS JSON format. The
+// value in the SATS JSON format may also be a number, as in the messages of the JSON protocol.
+func (cid *ConnectionId) UnmarshalJSON(data []byte) error {
+	var wrapped struct {
+		ConnectionId json.RawMessage `json:"__connection_id__"`
+	}
+	if err := json.Unmarshal(data, &wrapped); err == nil && wrapped.ConnectionId != nil {
+		data = wrapped.ConnectionId
+	}
+	// Hex strings without a prefix are always 32 digits, which parseJSONBigInt would read as decimal.
+	var hexString string
+	if err := json.Unmarshal(data, &hexString); err == nil && !strings.HasPrefix(hexString, "0x") {
+		return cid.UnmarshalText([]byte(hexString))
+	}
+	value, err := parseJSONBigInt(data)
+	if err != nil {
+		return fmt.Errorf("failed to decode connection id: %w", err)
+	}
+	if value.Sign() < 0 || value.BitLen() > 128 {
+		return fmt.Errorf("failed to decode connection id: value %s is out of range", value)
+	}
+	*cid = NewConnectionId(value)
 	return nil
 }
@@ -3,7 +3,11 @@ package spacetimedb
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
@@ -11,22 +15,79 @@ import (
 type DBConnection struct {
 	Host           string
 	NameOrIdentity string
-	IsConnected    bool
-	WS             *websocket.Conn
+	TokenStore     TokenStore
 
 	ctx    context.Context
 	cancel context.CancelFunc
 
-	Identity     *Identity
-	Token        string
-	ConnectionId *ConnectionId
+	// mu guards the connection state below, which the read loop updates while the application
+	// reads it, and the subscriptions. Use the accessor methods to read the state.
+	mu           sync.RWMutex
+	ws           *websocket.Conn
+	isConnected  bool
+	identity     Identity
+	token        string
+	connectionId ConnectionId
+
+	// handshake receives the outcome of the identity handshake of the websocket, for ConnectContext.
+	handshake      chan error
+	handshakeState handshakeState
+	done           chan struct{}
+	doneOnce       sync.Once
+
+	// writeMu serializes writes to the websocket, which does not support concurrent writers.
+	writeMu sync.Mutex
 
 	Compression uint8
+	// LightMode asks the server to send TransactionUpdateLight, without the caller and reducer
+	// details, for transactions this connection did not cause.
+	LightMode bool
+	Protocol  Protocol
+	// DefaultReducerFlags are the flags generated reducer functions call reducers with.
+	DefaultReducerFlags CallReducerFlags
+	// ModuleDef is the definition of the module, which the JSON protocol needs to decode rows.
+	ModuleDef *ModuleDef
+
+	ReconnectPolicy *ReconnectPolicy
+
+	// PingInterval is how often a ping is sent to the server. Zero disables pings.
+	PingInterval time.Duration
+	// IdleTimeout is how long to wait for a message or pong from the server before the connection
+	// is considered dead and closed. Zero disables the timeout.
+	IdleTimeout time.Duration
+
+	DispatchMode DispatchMode
+	events       chan Event
+
+	subscriptionQueries []string
+	subscriptions       map[uint32]*SubscriptionHandle
+
+	// The client cache and reconnect state are only used by the goroutine dispatching messages,
+	// which is the read loop or, in DispatchFrameTick mode, the goroutine calling FrameTick or
+	// ProcessEvent. They are never used concurrently.
+	cache *clientCache
+	// legacyRows holds the rows of the latest Subscribe within cache, which the next Subscribe replaces.
+	legacyRows   *clientCache
+	reconnecting bool
+	reconcile    *reconcileState
+
+	lastRequestId atomic.Uint32
+	lastQueryId   atomic.Uint32
+
+	pendingMu           sync.Mutex
+	pendingReducerCalls map[uint32]*pendingReducerCall
+	pendingQueries      map[string]*pendingOneOffQuery
 
 	TableNameMap TableNameMap
 
-	OnConnect    func(conn *DBConnection, identity *Identity, token string, connectionId *ConnectionId)
+	OnConnect    func(conn *DBConnection, identity Identity, token string, connectionId ConnectionId)
 	OnDisconnect func(*DBConnection)
+	// OnDisconnectError runs after OnDisconnect with the reason the connection was lost: a
+	// *DisconnectError, or nil if it was closed with Close.
+	OnDisconnectError func(conn *DBConnection, err error)
+	// OnConnectError runs when connecting fails, including when the websocket closes before the
+	// server accepted the connection and when reconnecting gives up.
+	OnConnectError func(err error)
 
 	Logger func(format string, args ...interface{})
 }
@@ -39,12 +100,22 @@ const (
 
 func NewDBConnection(opts ...DBConnectionOption) *DBConnection {
 	conn := &DBConnection{
-		Host: "wss://maincloud.spacetimedb.com",
+		Host:          "wss://maincloud.spacetimedb.com",
+		cache:         newClientCache(),
+		legacyRows:    newClientCache(),
+		subscriptions: make(map[uint32]*SubscriptionHandle),
+
+		done:                make(chan struct{}),
+		pendingReducerCalls: make(map[uint32]*pendingReducerCall),
+		pendingQueries:      make(map[string]*pendingOneOffQuery),
 	}
 
 	for _, opt := range opts {
 		opt(conn)
 	}
+	if conn.DispatchMode == DispatchFrameTick {
+		conn.events = make(chan Event, eventQueueSize)
+	}
 
 	return conn
 }
@@ -61,7 +132,17 @@ func WithNameOrIdentity(nameOrIdentity string) DBConnectionOption {
 		opts.NameOrIdentity = nameOrIdentity
 	}
 }
-func WithOnConnect(onConnect func(conn *DBConnection, identity *Identity, token string, connectionId *ConnectionId)) DBConnectionOption {
+func WithToken(token string) DBConnectionOption {
+	return func(opts *DBConnection) {
+		opts.token = token
+	}
+}
+func WithTokenStore(tokenStore TokenStore) DBConnectionOption {
+	return func(opts *DBConnection) {
+		opts.TokenStore = tokenStore
+	}
+}
+func WithOnConnect(onConnect func(conn *DBConnection, identity Identity, token string, connectionId ConnectionId)) DBConnectionOption {
 	return func(opts *DBConnection) {
 		opts.OnConnect = onConnect
 	}
@@ -71,11 +152,69 @@ func WithOnDisconnect(onDisconnect func(*DBConnection)) DBConnectionOption {
 		opts.OnDisconnect = onDisconnect
 	}
 }
+func WithOnDisconnectError(onDisconnectError func(conn *DBConnection, err error)) DBConnectionOption {
+	return func(opts *DBConnection) {
+		opts.OnDisconnectError = onDisconnectError
+	}
+}
+func WithOnConnectError(onConnectError func(err error)) DBConnectionOption {
+	return func(opts *DBConnection) {
+		opts.OnConnectError = onConnectError
+	}
+}
 func WithTableNameMap(tableNameMap TableNameMap) DBConnectionOption {
 	return func(opts *DBConnection) {
 		opts.TableNameMap = tableNameMap
 	}
 }
+func WithCompression(compression uint8) DBConnectionOption {
+	return func(opts *DBConnection) {
+		opts.Compression = compression
+	}
+}
+func WithLightMode() DBConnectionOption {
+	return func(opts *DBConnection) {
+		opts.LightMode = true
+	}
+}
+func WithProtocol(protocol Protocol) DBConnectionOption {
+	return func(opts *DBConnection) {
+		opts.Protocol = protocol
+	}
+}
+func WithDefaultReducerFlags(flags CallReducerFlags) DBConnectionOption {
+	return func(opts *DBConnection) {
+		opts.DefaultReducerFlags = flags
+	}
+}
+func WithModuleDef(def *ModuleDef) DBConnectionOption {
+	return func(opts *DBConnection) {
+		opts.ModuleDef = def
+	}
+}
+func WithReconnect(policy ReconnectPolicy) DBConnectionOption {
+	return func(opts *DBConnection) {
+		opts.ReconnectPolicy = &policy
+	}
+}
+
+// WithKeepalive sends a ping every pingInterval and closes the connection when nothing, not even a
+// pong, has been received for idleTimeout. An idleTimeout of zero defaults to twice pingInterval.
+func WithKeepalive(pingInterval, idleTimeout time.Duration) DBConnectionOption {
+	return func(opts *DBConnection) {
+		if idleTimeout == 0 {
+			idleTimeout = 2 * pingInterval
+		}
+		opts.PingInterval = pingInterval
+		opts.IdleTimeout = idleTimeout
+	}
+}
+func WithDispatchMode(mode DispatchMode) DBConnectionOption {
+	return func(opts *DBConnection) {
+		opts.DispatchMode = mode
+	}
+}
+
 func WithLogger(logger func(format string, args ...interface{})) DBConnectionOption {
 	return func(opts *DBConnection) {
 		opts.Logger = logger
@@ -89,86 +228,196 @@ func WithStdLogger() DBConnectionOption {
 	}
 }
 
+// Connect opens the websocket and returns without waiting for the server to accept the
+// connection. OnConnect runs once it has, and OnConnectError if it fails. Use ConnectContext to
+// wait for it.
 func (db *DBConnection) Connect() error {
+	return db.connect(context.Background(), nil)
+}
+
+// connect opens the websocket, using ctx for dialing only. handshake receives the outcome of the
+// identity handshake, if it is not nil.
+func (db *DBConnection) connect(ctx context.Context, handshake chan error) error {
 	if db.Host == "" {
-		return fmt.Errorf("host cannot be empty")
+		return db.connectFailed(fmt.Errorf("host cannot be empty"))
 	}
 
 	db.ctx, db.cancel = context.WithCancel(context.Background())
+	if db.DispatchMode == DispatchFrameTick && db.events == nil {
+		db.events = make(chan Event, eventQueueSize)
+	}
+
+	token := db.Token()
+	if token == "" && db.TokenStore != nil {
+		var err error
+		token, err = db.TokenStore.Load(db.Host, db.NameOrIdentity)
+		if err != nil {
+			return db.connectFailed(fmt.Errorf("failed to load token: %w", err))
+		}
+		db.mu.Lock()
+		db.token = token
+		db.mu.Unlock()
+	}
+
+	// The dial is cancelled by ctx or by Close.
+	dialCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(db.ctx, cancel)
+	defer stop()
+	if db.Protocol == ProtocolJSON && db.ModuleDef == nil {
+		def, err := db.HTTPClient().Schema(dialCtx)
+		if err != nil {
+			return db.connectFailed(fmt.Errorf("failed to get the module definition for the JSON protocol: %w", err))
+		}
+		db.ModuleDef = def
+	}
+	c, err := db.dial(dialCtx, token)
+	if err != nil {
+		return db.connectFailed(err)
+	}
+
+	db.mu.Lock()
+	db.ws = c
+	db.handshake = handshake
+	db.handshakeState = handshakePending
+	db.mu.Unlock()
+	db.Logger("Connected to websocket at %s", db.Host)
 
-	dialer := websocket.DefaultDialer
-	//dialer.Subprotocols = []string{"v1.json.spacetimedb"}
-	dialer.Subprotocols = []string{"v1.bsatn.spacetimedb"}
+	go db.readLoop(c)
+	return nil
+}
+
+// dial opens a new websocket to the database. If token is not empty it is sent
+// as a bearer token so the server associates the connection with its identity.
+func (db *DBConnection) dial(ctx context.Context, token string) (*websocket.Conn, error) {
+	dialer := *websocket.DefaultDialer
+	dialer.Subprotocols = []string{db.Protocol.subprotocol()}
 	url, err := url.JoinPath(db.Host, "v1", "database", db.NameOrIdentity, "subscribe")
 
 	if err != nil {
-		return fmt.Errorf("failed to join URL path: %w", err)
+		return nil, fmt.Errorf("failed to join URL path: %w", err)
 	}
 
-	c, _, err := dialer.DialContext(db.ctx, url+"?compression=None", nil) // TODO: Add compression support
+	compression, err := compressionQueryValue(db.Compression)
 	if err != nil {
-		return fmt.Errorf("failed to connect to websocket: %w", err)
+		return nil, err
+	}
+	query := "?compression=" + compression
+	if db.LightMode {
+		query += "&light=true"
 	}
 
-	db.WS = c
-	db.Logger("Connected to websocket at %s", db.Host)
+	header := http.Header{}
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
 
-	go func() {
-		defer func() {
-			db.IsConnected = false
-			if db.WS != nil {
-				db.WS.Close()
-			}
-		}()
-		for {
-			select {
-			case <-db.ctx.Done():
-				db.Logger("context cancelled, exiting message read loop")
-				return
-			default:
-				if db.WS == nil {
-					db.Logger("connection is nil, exiting message read loop")
-					return
+	c, _, err := dialer.DialContext(ctx, url+query, header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to websocket: %w", err)
+	}
+	return c, nil
+}
+
+func (db *DBConnection) readLoop(ws *websocket.Conn) {
+	reconnect := false
+	// closeErr is why the websocket was closed, or nil if it was closed by Close.
+	var closeErr error
+	defer func() {
+		db.mu.Lock()
+		db.isConnected = false
+		db.mu.Unlock()
+		ws.Close()
+		db.failPendingReducerCalls(fmt.Errorf("connection closed before the reducer call completed"))
+		db.failPendingOneOffQueries(fmt.Errorf("connection closed before the query completed"))
+		// If the server never accepted the connection, it is a failed connect and not a disconnect.
+		handshakeErr := closeErr
+		if handshakeErr == nil {
+			handshakeErr = fmt.Errorf("connection closed")
+		}
+		handshakeErr = fmt.Errorf("connection closed before the identity handshake: %w", handshakeErr)
+		if db.finishHandshake(handshakeErr) {
+			db.connectFailed(handshakeErr)
+			return
+		}
+		if db.handshakeFailed() {
+			db.terminate()
+			return
+		}
+		if db.events != nil {
+			db.queueEvent(Event{Err: closeErr})
+		} else {
+			db.disconnected(closeErr)
+		}
+		if reconnect {
+			go db.reconnect()
+		} else {
+			db.terminate()
+		}
+	}()
+	stopKeepalive := make(chan struct{})
+	defer close(stopKeepalive)
+	db.keepalive(ws, stopKeepalive)
+	for {
+		select {
+		case <-db.ctx.Done():
+			db.Logger("context cancelled, exiting message read loop")
+			return
+		default:
+			// Only time spent waiting for the server counts towards the idle timeout, not time
+			// spent handling messages or waiting for room in the event queue.
+			db.extendReadDeadline(ws)
+			messageType, rawMessage, err := ws.ReadMessage()
+			if err != nil {
+				select {
+				case <-db.ctx.Done():
+					db.Logger("context cancelled, exiting message read loop after read error")
+				default:
+					err = db.idleError(err)
+					db.Logger("Error reading message: %v", err)
+					closeErr = newDisconnectError(err)
+					reconnect = db.ReconnectPolicy != nil
 				}
-				messageType, rawMessage, err := db.WS.ReadMessage()
+				return
+			}
+			if messageType == websocket.TextMessage {
+				db.Logger("Received text message: %s", rawMessage)
+				err = db.receiveMessage(messageType, rawMessage)
 				if err != nil {
-					select {
-					case <-db.ctx.Done():
-						db.Logger("context cancelled, exiting message read loop after read error")
-					default:
-						db.Logger("Error reading message: %v", err)
-					}
-					return
-				}
-				if messageType == websocket.TextMessage {
-					db.Logger("Received text message: %s", rawMessage)
+					db.Logger("Error parsing text message: %v", err)
 				}
-				if messageType == websocket.BinaryMessage {
-					db.Logger("Received binary message: %x", rawMessage)
-					err = db.parseBsantMessage(rawMessage)
-					if err != nil {
-						db.Logger("Error parsing binary message: %v", err)
-					}
-				}
-				if messageType == websocket.CloseMessage {
-					db.Logger("Received close message, closing connection")
-					return
-				}
-				if messageType == websocket.PongMessage {
-					db.Logger("Received pong message")
+			}
+			if messageType == websocket.BinaryMessage {
+				db.Logger("Received binary message: %x", rawMessage)
+				err = db.receiveMessage(messageType, rawMessage)
+				if err != nil {
+					db.Logger("Error parsing binary message: %v", err)
 				}
 			}
+			if messageType == websocket.CloseMessage {
+				db.Logger("Received close message, closing connection")
+				return
+			}
+			if messageType == websocket.PongMessage {
+				db.Logger("Received pong message")
+			}
 		}
-	}()
-	return nil
+	}
 }
 
 func (db *DBConnection) Close() {
 	if db.cancel != nil {
 		db.cancel()
 	}
-	if db.WS != nil {
-		err := db.WS.Close()
+	db.mu.RLock()
+	ws := db.ws
+	db.mu.RUnlock()
+	if ws == nil {
+		// Without a websocket there is no read loop to end the connection.
+		db.terminate()
+	}
+	if ws != nil {
+		err := ws.Close()
 		if err != nil {
 			db.Logger("Error closing connection: %v", err)
 		} else {
@@ -177,13 +426,79 @@ func (db *DBConnection) Close() {
 	}
 }
 
+// SendMessage sends a raw message over the websocket. It is safe to call from several goroutines.
 func (db *DBConnection) SendMessage(data []byte) error {
-	if db.WS == nil {
+	db.mu.RLock()
+	ws := db.ws
+	db.mu.RUnlock()
+	if ws == nil {
 		return fmt.Errorf("cannot send message: not connected")
 	}
-	err := db.WS.WriteMessage(websocket.BinaryMessage, data)
+	db.writeMu.Lock()
+	err := ws.WriteMessage(websocket.BinaryMessage, data)
+	db.writeMu.Unlock()
 	if err != nil {
 		return fmt.Errorf("failed to write message: %w", err)
 	}
 	return nil
 }
+
+// ConnectionState is a snapshot of the state of a DBConnection.
+type ConnectionState struct {
+	IsConnected  bool
+	Identity     Identity
+	Token        string
+	ConnectionId ConnectionId
+}
+
+// State returns the current state of the connection. The fields are read together, so they
+// always belong to the same session.
+func (db *DBConnection) State() ConnectionState {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return ConnectionState{
+		IsConnected:  db.isConnected,
+		Identity:     db.identity,
+		Token:        db.token,
+		ConnectionId: db.connectionId,
+	}
+}
+
+// IsConnected reports whether the server has accepted the connection and it has not been lost since.
+func (db *DBConnection) IsConnected() bool {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.isConnected
+}
+
+// Identity returns the identity the server assigned to the connection, or the zero Identity before
+// it is connected.
+func (db *DBConnection) Identity() Identity {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.identity
+}
+
+// Token returns the auth token of the connection. It is the token passed with WithToken or loaded
+// from the TokenStore until the server sends one.
+func (db *DBConnection) Token() string {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.token
+}
+
+// ConnectionId returns the ID the server assigned to the connection, or the zero ConnectionId
+// before it is connected.
+func (db *DBConnection) ConnectionId() ConnectionId {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.connectionId
+}
+
+func (db *DBConnection) newRequestId() uint32 {
+	return db.lastRequestId.Add(1)
+}
+
+func (db *DBConnection) newQueryId() uint32 {
+	return db.lastQueryId.Add(1)
+}
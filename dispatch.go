@@ -0,0 +1,110 @@
+package spacetimedb
+
+import (
+	"fmt"
+
+	"github.com/gorilla/websocket"
+)
+
+// DispatchMode selects the goroutine that applies server messages to the client cache and runs
+// the callbacks.
+type DispatchMode uint8
+
+const (
+	// DispatchBackground handles messages on the goroutine reading the websocket, as soon as they
+	// arrive. Callbacks can run concurrently with the rest of the application.
+	DispatchBackground DispatchMode = iota
+	// DispatchFrameTick only decodes messages on the goroutine reading the websocket and queues
+	// them. The application handles them on its own goroutine with FrameTick, or by receiving from
+	// Events and passing them to ProcessEvent, so the cache only changes and callbacks only run there.
+	DispatchFrameTick
+)
+
+// eventQueueSize is the number of events queued in DispatchFrameTick mode. When the queue is full
+// the connection stops reading from the websocket until the application processes events.
+const eventQueueSize = 1024
+
+// Event is a message received in DispatchFrameTick mode, waiting to be processed.
+type Event struct {
+	// Message is the message received from the server, or nil for the event that reports that
+	// the websocket was closed.
+	Message *ServerMessage
+	// Err is why the websocket was closed, for the event that reports it. See OnDisconnectError.
+	Err error
+}
+
+// FrameTick processes the events queued in DispatchFrameTick mode: it applies the messages
+// received since the last call to the client cache and runs their callbacks on the calling
+// goroutine. It does not wait for new messages. Errors are logged, as in DispatchBackground mode.
+//
+// A reducer call or query waiting for its result is only completed by FrameTick, so do not wait
+// for one on the goroutine that calls FrameTick.
+func (db *DBConnection) FrameTick() {
+	for {
+		select {
+		case event := <-db.events:
+			if err := db.ProcessEvent(event); err != nil {
+				db.Logger("Error processing message: %v", err)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// Events returns the queue of events in DispatchFrameTick mode, for applications that wait for
+// messages in a select loop instead of polling FrameTick. Pass every event received to
+// ProcessEvent. It returns nil in DispatchBackground mode.
+func (db *DBConnection) Events() <-chan Event {
+	return db.events
+}
+
+// ProcessEvent applies an event received from Events to the client cache and runs its callbacks.
+func (db *DBConnection) ProcessEvent(event Event) error {
+	if event.Message == nil {
+		db.disconnected(event.Err)
+		return nil
+	}
+	if err := db.handleServerMessage(event.Message); err != nil {
+		return fmt.Errorf("failed to handle %T: %w", event.Message.Message, err)
+	}
+	return nil
+}
+
+// receiveMessage handles a message from the server, or queues it in DispatchFrameTick mode. Text
+// messages are decoded as JSON and binary messages as BSATN.
+func (db *DBConnection) receiveMessage(messageType int, rawMessage []byte) error {
+	var serverMsg *ServerMessage
+	var err error
+	if messageType == websocket.TextMessage {
+		serverMsg, err = decodeJSONMessage(rawMessage, db.ModuleDef)
+	} else {
+		serverMsg, err = decodeBsatnMessage(rawMessage)
+	}
+	if err != nil {
+		return err
+	}
+	if _, ok := serverMsg.Message.(*IdentityToken); ok {
+		// The handshake is done once the identity has been applied, or queued in DispatchFrameTick mode.
+		defer db.finishHandshake(nil)
+	}
+	if db.events == nil {
+		return db.handleServerMessage(serverMsg)
+	}
+	db.queueEvent(Event{Message: serverMsg})
+	return nil
+}
+
+// queueEvent adds an event to the queue, waiting while it is full unless the connection is closed.
+func (db *DBConnection) queueEvent(event Event) {
+	select {
+	case db.events <- event:
+		return
+	default:
+	}
+	select {
+	case db.events <- event:
+	case <-db.ctx.Done():
+		db.Logger("Dropping %T event, connection closed while the event queue was full", event.Message)
+	}
+}
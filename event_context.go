@@ -0,0 +1,8 @@
+package spacetimedb
+
+// EventContext is passed to table callbacks and describes what caused the change.
+type EventContext struct {
+	Conn *DBConnection
+	// Event is the server message that caused the change, e.g. *InitialSubscription or *TransactionUpdate.
+	Event any
+}
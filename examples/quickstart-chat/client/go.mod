@@ -10,6 +10,7 @@ require (
 
 require (
 	github.com/alexanderbh/bubblezone/v2 v2.0.0-20250522173625-92991368b8ed // indirect
+	github.com/andybalholm/brotli v1.1.1 // indirect
 	github.com/charmbracelet/bubbles/v2 v2.0.0-beta.1.0.20250516174717-081e9986600c // indirect
 	github.com/charmbracelet/colorprofile v0.3.1 // indirect
 	github.com/charmbracelet/lipgloss/v2 v2.0.0-beta.1.0.20250516180252-2c4751e06ce4 // indirect
@@ -28,3 +29,6 @@ require (
 	golang.org/x/sync v0.13.0 // indirect
 	golang.org/x/sys v0.32.0 // indirect
 )
+
+// The example is built against the SDK in this repository, so the bindings it generates match.
+replace github.com/alexanderbh/spacetimedb-go-sdk => ../../..
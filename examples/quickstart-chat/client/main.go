@@ -19,6 +19,9 @@ func main() {
 		spacetimedb.WithTableNameMap(module_bindings.Tables),
 		spacetimedb.WithLogger(Logger),
 	)
+	module_bindings.Db.User.OnInsert(onUserInsert)
+	module_bindings.Db.User.OnUpdate(onUserUpdate)
+
 	err := db.Connect()
 	if err != nil {
 		log.Fatalln("Error connecting to database:", err)
@@ -35,31 +38,61 @@ func main() {
 	}
 }
 
-func onConnect(db *spacetimedb.DBConnection, identity *spacetimedb.Identity, token string, connectionId *spacetimedb.ConnectionId) {
+// subscription is created on the first connect. The connection replays it after a reconnect, so
+// it must not be subscribed again.
+var subscription *spacetimedb.SubscriptionHandle
+
+func onConnect(db *spacetimedb.DBConnection, identity spacetimedb.Identity, token string, connectionId spacetimedb.ConnectionId) {
 
 	db.Logger("Connected to database with identity: %s", identity.ToHexString())
 	db.Logger("Token: %s", token)
-	connId, err := connectionId.ToHexString()
-	if err != nil {
-		db.Logger("Error converting connection ID to hex string:", err)
-	} else {
-		db.Logger("Connection ID: %s", connId)
+	db.Logger("Connection ID: %s", connectionId.ToHexString())
+
+	if subscription != nil {
+		return
 	}
 
-	err = module_bindings.SetName(db, "Setname called with this")
+	err := module_bindings.SetName(db, "Setname called with this")
 
 	if err != nil {
 		log.Println("Error sending message:", err)
 		return
 	}
 
-	err = db.Subscribe("SELECT * FROM user")
+	subscription = db.NewSubscription()
+	subscription.OnError(func(ctx *spacetimedb.EventContext, err error) {
+		db.Logger("Subscription error: %v", err)
+	})
+	err = subscription.Subscribe("SELECT * FROM user")
 	if err != nil {
 		log.Println("Error subscribing to query:", err)
 		return
 	}
 }
 
+func onUserInsert(ctx *spacetimedb.EventContext, user *module_bindings.User) {
+	if user.Online {
+		Logger("User %s connected.", userNameOrIdentity(user))
+	}
+}
+
+func onUserUpdate(ctx *spacetimedb.EventContext, oldUser *module_bindings.User, newUser *module_bindings.User) {
+	if oldUser.Online != newUser.Online {
+		if newUser.Online {
+			Logger("User %s connected.", userNameOrIdentity(newUser))
+		} else {
+			Logger("User %s disconnected.", userNameOrIdentity(newUser))
+		}
+	}
+}
+
+func userNameOrIdentity(user *module_bindings.User) string {
+	if user.Name != nil {
+		return *user.Name
+	}
+	return user.Identity.ToHexString()[:8]
+}
+
 func onDisconnect(db *spacetimedb.DBConnection) {
 	db.Logger("Disconnected from database.")
 }
@@ -0,0 +1,18 @@
+// Code generated by spacetimedb-go-codegen. DO NOT EDIT.
+
+package module_bindings
+
+import (
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+)
+
+// MessageTable is the client cache of the message table.
+type MessageTable struct {
+	*spacetimedb.TableCache[*Message]
+}
+
+func NewMessageTable() *MessageTable {
+	return &MessageTable{
+		TableCache: spacetimedb.NewTableCache(DeserializeMessage, nil),
+	}
+}
@@ -0,0 +1,39 @@
+// Code generated by spacetimedb-go-codegen. DO NOT EDIT.
+
+package module_bindings
+
+import (
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+)
+
+type Message struct {
+	Sender spacetimedb.Identity
+	Sent   *spacetimedb.Timestamp
+	Text   string
+}
+
+func (m *Message) Serialize(writer *spacetimedb.BinaryWriter) error {
+	if err := m.Sender.Serialize(writer); err != nil {
+		return err
+	}
+	if err := m.Sent.Serialize(writer); err != nil {
+		return err
+	}
+	writer.WriteString(m.Text)
+	return nil
+}
+
+func (m *Message) Deserialize(reader *spacetimedb.BinaryReader) error {
+	m.Sender = *spacetimedb.ReadValue[spacetimedb.Identity](reader)
+	m.Sent = spacetimedb.ReadValue[spacetimedb.Timestamp](reader)
+	m.Text = reader.ReadString()
+	return reader.Err()
+}
+
+func DeserializeMessage(reader *spacetimedb.BinaryReader) (*Message, error) {
+	row := &Message{}
+	if err := row.Deserialize(reader); err != nil {
+		return nil, err
+	}
+	return row, nil
+}
@@ -0,0 +1,37 @@
+// Code generated by spacetimedb-go-codegen. DO NOT EDIT.
+
+package module_bindings
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+)
+
+// SendMessage calls the send_message reducer with the DefaultReducerFlags of conn, without waiting for its result.
+func SendMessage(conn *spacetimedb.DBConnection, text string) error {
+	args, err := serializeSendMessageArgs(text)
+	if err != nil {
+		return err
+	}
+	if err := conn.CallReducer("send_message", args, 0, conn.DefaultReducerFlags); err != nil {
+		return fmt.Errorf("failed to call send_message reducer: %w", err)
+	}
+	return nil
+}
+
+// SendMessageAsync calls the send_message reducer and waits for its result.
+func SendMessageAsync(ctx context.Context, conn *spacetimedb.DBConnection, text string) (*spacetimedb.ReducerResult, error) {
+	args, err := serializeSendMessageArgs(text)
+	if err != nil {
+		return nil, err
+	}
+	return conn.CallReducerAsync(ctx, "send_message", args)
+}
+
+func serializeSendMessageArgs(text string) ([]byte, error) {
+	writer := spacetimedb.NewBinaryWriter()
+	writer.WriteString(text)
+	return writer.GetBuffer(), nil
+}
@@ -1,22 +1,37 @@
+// Code generated by spacetimedb-go-codegen. DO NOT EDIT.
+
 package module_bindings
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/alexanderbh/spacetimedb-go-sdk"
 )
 
+// SetName calls the set_name reducer with the DefaultReducerFlags of conn, without waiting for its result.
 func SetName(conn *spacetimedb.DBConnection, name string) error {
-	writer := spacetimedb.NewBinaryWriter(1024)
-	writer.WriteString(name)
-	err := conn.CallReducer(
-		"set_name",
-		writer.GetBuffer(),
-		0,
-		0,
-	)
+	args, err := serializeSetNameArgs(name)
 	if err != nil {
-		return fmt.Errorf("Error calling set_name reducer: %v", err)
+		return err
+	}
+	if err := conn.CallReducer("set_name", args, 0, conn.DefaultReducerFlags); err != nil {
+		return fmt.Errorf("failed to call set_name reducer: %w", err)
 	}
 	return nil
 }
+
+// SetNameAsync calls the set_name reducer and waits for its result.
+func SetNameAsync(ctx context.Context, conn *spacetimedb.DBConnection, name string) (*spacetimedb.ReducerResult, error) {
+	args, err := serializeSetNameArgs(name)
+	if err != nil {
+		return nil, err
+	}
+	return conn.CallReducerAsync(ctx, "set_name", args)
+}
+
+func serializeSetNameArgs(name string) ([]byte, error) {
+	writer := spacetimedb.NewBinaryWriter()
+	writer.WriteString(name)
+	return writer.GetBuffer(), nil
+}
@@ -1,7 +1,35 @@
+// Code generated by spacetimedb-go-codegen. DO NOT EDIT.
+
 package module_bindings
 
-import "github.com/alexanderbh/spacetimedb-go-sdk"
+import (
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+)
+
+// RemoteTables holds the client cache of every table. Every DBConnection needs its own.
+type RemoteTables struct {
+	Message *MessageTable
+	User    *UserTable
+}
 
-var Tables = map[string]spacetimedb.Table{
-	"user": NewUserTable(),
+// NewRemoteTables creates empty client caches for all tables.
+func NewRemoteTables() *RemoteTables {
+	return &RemoteTables{
+		Message: NewMessageTable(),
+		User:    NewUserTable(),
+	}
 }
+
+// TableNameMap returns the tables by name, to pass to spacetimedb.WithTableNameMap.
+func (t *RemoteTables) TableNameMap() spacetimedb.TableNameMap {
+	return spacetimedb.TableNameMap{
+		"message": t.Message,
+		"user":    t.User,
+	}
+}
+
+// Db holds the tables of a program with a single connection. Use NewRemoteTables for more connections.
+var Db = NewRemoteTables()
+
+// Tables is the TableNameMap of Db.
+var Tables = Db.TableNameMap()
@@ -1,67 +1,27 @@
+// Code generated by spacetimedb-go-codegen. DO NOT EDIT.
+
 package module_bindings
 
 import (
-	"fmt"
-
 	"github.com/alexanderbh/spacetimedb-go-sdk"
 )
 
-type User struct {
-	Identity *spacetimedb.Identity
-	Name     *string
-	Online   bool
-}
-
-func (u *User) PrimaryKey() string {
-	return u.Identity.ToHexString()
-}
-
-func (u *User) Deserialize(reader *spacetimedb.BinaryReader) error {
-	u.Identity = &spacetimedb.Identity{}
-	if err := u.Identity.Deserialize(reader); err != nil {
-		return fmt.Errorf("failed to deserialize User.Identity: %w", err)
-	}
-	if reader.ReadU8() == 0 {
-		name := reader.ReadString()
-		u.Name = &name
-	} else {
-		u.Name = nil
-	}
-	u.Online = reader.ReadBool()
-
-	return nil
-}
-
+// UserTable is the client cache of the user table.
 type UserTable struct {
-	TableCache *spacetimedb.TableCache[*User]
+	*spacetimedb.TableCache[*User]
 }
 
 func NewUserTable() *UserTable {
-	tableCache := &spacetimedb.TableCache[*User]{
-		Rows: make(map[string]*User),
-	}
 	return &UserTable{
-		TableCache: tableCache,
+		TableCache: spacetimedb.NewTableCache(DeserializeUser, userPrimaryKey),
 	}
 }
 
-func (t *UserTable) Insert(reader *spacetimedb.BinaryReader) error {
-	row := &User{}
-	if err := row.Deserialize(reader); err != nil {
-		return fmt.Errorf("failed to deserialize User row for insert: %w", err)
-	}
-
-	t.TableCache.Rows[row.PrimaryKey()] = row
-	return nil
+func userPrimaryKey(row *User) string {
+	return row.Identity.ToHexString()
 }
 
-func (t *UserTable) Delete(reader *spacetimedb.BinaryReader) error {
-	row := &User{}
-	if err := row.Deserialize(reader); err != nil {
-		return fmt.Errorf("failed to deserialize User row for delete: %w", err)
-	}
-
-	delete(t.TableCache.Rows, row.PrimaryKey())
-
-	return nil
+// FindByIdentity returns the cached row with the given identity.
+func (t *UserTable) FindByIdentity(identity spacetimedb.Identity) (*User, bool) {
+	return t.Get(identity.ToHexString())
 }
@@ -0,0 +1,42 @@
+// Code generated by spacetimedb-go-codegen. DO NOT EDIT.
+
+package module_bindings
+
+import (
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+)
+
+type User struct {
+	Identity spacetimedb.Identity
+	Name     *string
+	Online   bool
+}
+
+func (u *User) Serialize(writer *spacetimedb.BinaryWriter) error {
+	if err := u.Identity.Serialize(writer); err != nil {
+		return err
+	}
+	if u.Name == nil {
+		writer.WriteU8(1)
+	} else {
+		writer.WriteU8(0)
+		writer.WriteString(*u.Name)
+	}
+	writer.WriteBool(u.Online)
+	return nil
+}
+
+func (u *User) Deserialize(reader *spacetimedb.BinaryReader) error {
+	u.Identity = *spacetimedb.ReadValue[spacetimedb.Identity](reader)
+	u.Name = spacetimedb.ReadOption(reader, reader.ReadString)
+	u.Online = reader.ReadBool()
+	return reader.Err()
+}
+
+func DeserializeUser(reader *spacetimedb.BinaryReader) (*User, error) {
+	row := &User{}
+	if err := row.Deserialize(reader); err != nil {
+		return nil, err
+	}
+	return row, nil
+}
@@ -2,4 +2,7 @@ module github.com/alexanderbh/spacetimedb-go-sdk
 
 go 1.24.3
 
-require github.com/gorilla/websocket v1.5.3
+require (
+	github.com/andybalholm/brotli v1.1.1
+	github.com/gorilla/websocket v1.5.3
+)
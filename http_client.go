@@ -0,0 +1,307 @@
+package spacetimedb
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// HTTPClient calls the HTTP API of a SpacetimeDB host. It is configured like a DBConnection, with
+// the same host, database and token, and can be created from one with DBConnection.HTTPClient.
+type HTTPClient struct {
+	// Host is the address of the host. ws:// and wss:// addresses are accepted and converted to
+	// http:// and https://.
+	Host           string
+	NameOrIdentity string
+	Token          string
+	TokenStore     TokenStore
+
+	// Client sends the requests. http.DefaultClient is used if it is nil.
+	Client *http.Client
+}
+
+// NewHTTPClient creates an HTTPClient configured by the same options as NewDBConnection. Options
+// that only apply to the websocket connection are ignored.
+func NewHTTPClient(opts ...DBConnectionOption) *HTTPClient {
+	return NewDBConnection(opts...).HTTPClient()
+}
+
+// HTTPClient returns an HTTPClient for the host and database of the connection. It uses the token
+// of the connection, so requests are made as the identity of the connection.
+func (db *DBConnection) HTTPClient() *HTTPClient {
+	return &HTTPClient{
+		Host:           db.Host,
+		NameOrIdentity: db.NameOrIdentity,
+		Token:          db.Token(),
+		TokenStore:     db.TokenStore,
+	}
+}
+
+// HTTPError is returned when the host responds with a status other than 2xx.
+type HTTPError struct {
+	StatusCode int
+	// Body is the body of the response, which usually describes the error.
+	Body string
+}
+
+func (e *HTTPError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("http status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("http status %d: %s", e.StatusCode, e.Body)
+}
+
+// CreatedIdentity is a new identity together with the token that authenticates as it.
+type CreatedIdentity struct {
+	Identity Identity
+	Token    string
+}
+
+// CreateIdentity asks the host to create a new identity.
+func (c *HTTPClient) CreateIdentity(ctx context.Context) (*CreatedIdentity, error) {
+	var response struct {
+		Identity string `json:"identity"`
+		Token    string `json:"token"`
+	}
+	if err := c.do(ctx, http.MethodPost, []string{"v1", "identity"}, nil, nil, &response); err != nil {
+		return nil, fmt.Errorf("failed to create identity: %w", err)
+	}
+	identity, err := NewIdentity(response.Identity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create identity: %w", err)
+	}
+	return &CreatedIdentity{Identity: identity, Token: response.Token}, nil
+}
+
+// Schema returns the schema of the database.
+func (c *HTTPClient) Schema(ctx context.Context) (*ModuleDef, error) {
+	var raw json.RawMessage
+	query := url.Values{"version": {"9"}}
+	if err := c.do(ctx, http.MethodGet, c.databasePath("schema"), query, nil, &raw); err != nil {
+		return nil, fmt.Errorf("failed to get schema: %w", err)
+	}
+	return ParseModuleDef(raw)
+}
+
+// SQLResult is the result of one statement of an SQL query.
+type SQLResult struct {
+	Schema ProductType `json:"schema"`
+	// Rows holds a JSON array for each row, with an element for each column of the schema.
+	Rows                [][]json.RawMessage `json:"rows"`
+	TotalDurationMicros uint64              `json:"total_duration_micros"`
+}
+
+// SQL runs an SQL query on the database and returns a result for each statement.
+func (c *HTTPClient) SQL(ctx context.Context, query string) ([]SQLResult, error) {
+	var results []SQLResult
+	body := strings.NewReader(query)
+	if err := c.do(ctx, http.MethodPost, c.databasePath("sql"), nil, body, &results); err != nil {
+		return nil, fmt.Errorf("failed to run sql query: %w", err)
+	}
+	return results, nil
+}
+
+// CallReducer calls a reducer with args encoded as JSON. Unlike DBConnection.CallReducer it waits
+// for the reducer to finish, and returns an *HTTPError if the reducer fails.
+func (c *HTTPClient) CallReducer(ctx context.Context, reducer string, args ...any) error {
+	if args == nil {
+		args = []any{}
+	}
+	body, err := json.Marshal(args)
+	if err != nil {
+		return fmt.Errorf("failed to encode arguments of reducer %s: %w", reducer, err)
+	}
+	req, err := c.newRequest(ctx, http.MethodPost, c.databasePath("call", reducer), nil, bytes.NewReader(body))
+	if err == nil {
+		req.Header.Set("Content-Type", "application/json")
+		err = c.send(req, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to call reducer %s: %w", reducer, err)
+	}
+	return nil
+}
+
+// LogRecord is a line of the module log.
+type LogRecord struct {
+	// Level is one of Error, Warn, Info, Debug, Trace and Panic.
+	Level string `json:"level"`
+	// TimestampMicros is the time of the record in microseconds since the Unix epoch.
+	TimestampMicros int64  `json:"ts"`
+	Target          string `json:"target"`
+	Filename        string `json:"filename"`
+	LineNumber      uint32 `json:"line_number"`
+	Message         string `json:"message"`
+}
+
+// Timestamp returns the time of the record.
+func (r LogRecord) Timestamp() *Timestamp {
+	return NewTimestamp(r.TimestampMicros)
+}
+
+// Logs returns the last numLines lines of the module log. The database owner's token is needed.
+func (c *HTTPClient) Logs(ctx context.Context, numLines int) ([]LogRecord, error) {
+	var raw bytes.Buffer
+	query := url.Values{"num_lines": {strconv.Itoa(numLines)}}
+	if err := c.do(ctx, http.MethodGet, c.databasePath("logs"), query, nil, &raw); err != nil {
+		return nil, fmt.Errorf("failed to get logs: %w", err)
+	}
+
+	var records []LogRecord
+	scanner := bufio.NewScanner(&raw)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var record LogRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, fmt.Errorf("failed to decode log record: %w", err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read logs: %w", err)
+	}
+	return records, nil
+}
+
+// DatabaseInfo describes a database.
+type DatabaseInfo struct {
+	DatabaseIdentity Identity
+	OwnerIdentity    Identity
+	// HostType is the kind of module, such as Wasm.
+	HostType string
+	// InitialProgram is the hash of the module the database was created with.
+	InitialProgram string
+}
+
+// DatabaseInfo returns information about the database.
+func (c *HTTPClient) DatabaseInfo(ctx context.Context) (*DatabaseInfo, error) {
+	var response struct {
+		DatabaseIdentity Identity   `json:"database_identity"`
+		OwnerIdentity    Identity   `json:"owner_identity"`
+		HostType         jsonSumTag `json:"host_type"`
+		InitialProgram   string     `json:"initial_program"`
+	}
+	if err := c.do(ctx, http.MethodGet, c.databasePath(), nil, nil, &response); err != nil {
+		return nil, fmt.Errorf("failed to get database info: %w", err)
+	}
+	return &DatabaseInfo{
+		DatabaseIdentity: response.DatabaseIdentity,
+		OwnerIdentity:    response.OwnerIdentity,
+		HostType:         string(response.HostType),
+		InitialProgram:   response.InitialProgram,
+	}, nil
+}
+
+func (c *HTTPClient) databasePath(elem ...string) []string {
+	return append([]string{"v1", "database", c.NameOrIdentity}, elem...)
+}
+
+func (c *HTTPClient) baseURL() (string, error) {
+	if c.Host == "" {
+		return "", fmt.Errorf("host cannot be empty")
+	}
+	host := c.Host
+	if rest, ok := strings.CutPrefix(host, "ws://"); ok {
+		host = "http://" + rest
+	} else if rest, ok := strings.CutPrefix(host, "wss://"); ok {
+		host = "https://" + rest
+	}
+	return host, nil
+}
+
+func (c *HTTPClient) token() (string, error) {
+	if c.Token != "" || c.TokenStore == nil {
+		return c.Token, nil
+	}
+	token, err := c.TokenStore.Load(c.Host, c.NameOrIdentity)
+	if err != nil {
+		return "", fmt.Errorf("failed to load token: %w", err)
+	}
+	return token, nil
+}
+
+// newRequest creates a request to the host, authenticated with the token of the client.
+func (c *HTTPClient) newRequest(ctx context.Context, method string, path []string, query url.Values, body io.Reader) (*http.Request, error) {
+	base, err := c.baseURL()
+	if err != nil {
+		return nil, err
+	}
+	for i := range path {
+		path[i] = url.PathEscape(path[i])
+	}
+	u, err := url.JoinPath(base, path...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to join URL path: %w", err)
+	}
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	token, err := c.token()
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req, nil
+}
+
+// do creates and sends a request, see send.
+func (c *HTTPClient) do(ctx context.Context, method string, path []string, query url.Values, body io.Reader, out any) error {
+	req, err := c.newRequest(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	return c.send(req, out)
+}
+
+// send sends a request and decodes the response into out. out may be nil to discard the response,
+// a *bytes.Buffer to receive it unparsed, or a value to decode JSON into.
+func (c *HTTPClient) send(req *http.Request, out any) error {
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &HTTPError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(data))}
+	}
+
+	switch out := out.(type) {
+	case nil:
+		return nil
+	case *bytes.Buffer:
+		out.Write(data)
+		return nil
+	default:
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+		return nil
+	}
+}
@@ -1,63 +1,141 @@
 package spacetimedb
 
 import (
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"math/big"
+	"strings"
 )
 
-// Identity is a unique identifier for a user connected to a database.
-type Identity struct {
-	data *big.Int
-}
+// Identity is a unique identifier for a user connected to a database. It holds the 256-bit value in
+// BSATN byte order, little endian, and is comparable with == and usable as a map key.
+type Identity [32]byte
 
-func NewIdentity(data interface{}) (*Identity, error) {
+// NewIdentity creates an Identity from a hex string, as returned by ToHexString, or a *big.Int.
+func NewIdentity(data interface{}) (Identity, error) {
 	switch v := data.(type) {
 	case string:
-		val, err := HexStringToU256(v)
-		if err != nil {
-			return nil, fmt.Errorf("invalid hex string for Identity: %w", err)
+		var id Identity
+		if err := parseReversedHex(id[:], v); err != nil {
+			return Identity{}, fmt.Errorf("invalid hex string for Identity: %w", err)
 		}
-		return &Identity{data: val}, nil
+		return id, nil
 	case *big.Int:
-		return &Identity{data: v}, nil
+		if v.Sign() < 0 || v.BitLen() > 256 {
+			return Identity{}, fmt.Errorf("value %s is out of range for Identity", v)
+		}
+		var id Identity
+		arr, _ := U256ToUint8Array(v)
+		copy(id[:], arr)
+		return id, nil
 	default:
-		return nil, fmt.Errorf("unsupported type for Identity")
+		return Identity{}, fmt.Errorf("unsupported type for Identity")
 	}
 }
 
 // Data returns the underlying bigint value.
-func (id *Identity) Data() *big.Int {
-	return id.data
+func (id Identity) Data() *big.Int {
+	value, _ := Uint8ArrayToU256(id[:])
+	return value
+}
+
+// IsEqual compares two identities for equality. It is the same as ==.
+func (id Identity) IsEqual(other Identity) bool {
+	return id == other
 }
 
-// IsEqual compares two identities for equality.
-func (id *Identity) IsEqual(other *Identity) bool {
-	return id.ToHexString() == other.ToHexString()
+// IsZero reports whether the identity is the zero value.
+func (id Identity) IsZero() bool {
+	return id == Identity{}
 }
 
 // ToHexString prints the identity as a hexadecimal string.
-func (id *Identity) ToHexString() string {
-	s, _ := U256ToHexString(id.data)
-	return s
+func (id Identity) ToHexString() string {
+	return Uint8ArrayToHexString(id[:])
 }
 
-// ToUint8Array converts the address to a byte array.
-func (id *Identity) ToUint8Array() []byte {
-	arr, _ := U256ToUint8Array(id.data)
-	return arr
+// ToUint8Array converts the identity to a byte array, in BSATN byte order.
+func (id Identity) ToUint8Array() []byte {
+	return append([]byte(nil), id[:]...)
+}
+
+func (id Identity) String() string {
+	return id.ToHexString()
 }
 
 // FromString parses an Identity from a hexadecimal string.
-func FromString(str string) (*Identity, error) {
+func FromString(str string) (Identity, error) {
 	return NewIdentity(str)
 }
 
-func (id *Identity) Serialize(writer *BinaryWriter) error {
-	panic("Identity.Serialize: not implemented")
+func (id Identity) Serialize(writer *BinaryWriter) error {
+	writer.WriteBytes(id[:])
+	return nil
 }
 
 func (id *Identity) Deserialize(reader *BinaryReader) error {
-	bigint := reader.ReadU256()
-	id.data = bigint
+	copy(id[:], reader.ReadBytes(len(id)))
+	return reader.Err()
+}
+
+// MarshalText encodes the identity as the hex string of ToHexString.
+func (id Identity) MarshalText() ([]byte, error) {
+	return []byte(id.ToHexString()), nil
+}
+
+func (id *Identity) UnmarshalText(text []byte) error {
+	parsed, err := NewIdentity(string(text))
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
+
+// MarshalJSON encodes the identity in the SATS JSON format, as {"__identity__": "0x..."}.
+func (id Identity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"__identity__": "0x" + id.ToHexString()})
+}
+
+// UnmarshalJSON decodes an identity sent either as a hex string or in the SATS JSON format. The
+// value in the SATS JSON format may also be a number, as in the messages of the JSON protocol.
+func (id *Identity) UnmarshalJSON(data []byte) error {
+	var wrapped struct {
+		Identity json.RawMessage `json:"__identity__"`
+	}
+	if err := json.Unmarshal(data, &wrapped); err == nil && wrapped.Identity != nil {
+		data = wrapped.Identity
+	}
+	// Hex strings without a prefix are always 64 digits, which parseJSONBigInt would read as decimal.
+	var hexString string
+	if err := json.Unmarshal(data, &hexString); err == nil && !strings.HasPrefix(hexString, "0x") {
+		return id.UnmarshalText([]byte(hexString))
+	}
+	value, err := parseJSONBigInt(data)
+	if err != nil {
+		return fmt.Errorf("failed to decode identity: %w", err)
+	}
+	parsed, err := NewIdentity(value)
+	if err != nil {
+		return fmt.Errorf("failed to decode identity: %w", err)
+	}
+	*id = parsed
+	return nil
+}
+
+// parseReversedHex parses a hex string, with or without a 0x prefix, holding exactly len(dst)
+// bytes, into dst in reversed byte order. This is the inverse of Uint8ArrayToHexString.
+func parseReversedHex(dst []byte, str string) error {
+	data, err := hex.DecodeString(strings.TrimPrefix(str, "0x"))
+	if err != nil {
+		return err
+	}
+	if len(data) != len(dst) {
+		return fmt.Errorf("expected %d bytes, got %d", len(dst), len(data))
+	}
+	for i, b := range data {
+		dst[len(dst)-1-i] = b
+	}
 	return nil
 }
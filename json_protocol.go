@@ -0,0 +1,451 @@
+package spacetimedb
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Protocol is the format of the messages the server sends.
+type Protocol uint8
+
+const (
+	// ProtocolBSATN is the binary v1.bsatn.spacetimedb protocol.
+	ProtocolBSATN Protocol = iota
+	// ProtocolJSON is the text v1.json.spacetimedb protocol. Its messages are decoded into the same
+	// structs as BSATN messages. Rows are sent as SATS JSON and converted to BSATN using the module
+	// definition, so table caches work unchanged. The definition is set with WithModuleDef, or
+	// fetched from the host when connecting.
+	//
+	// Client messages are still sent as binary BSATN frames, which the host accepts on both
+	// protocols.
+	ProtocolJSON
+)
+
+func (p Protocol) subprotocol() string {
+	if p == ProtocolJSON {
+		return "v1.json.spacetimedb"
+	}
+	return "v1.bsatn.spacetimedb"
+}
+
+func (p Protocol) String() string {
+	switch p {
+	case ProtocolBSATN:
+		return "BSATN"
+	case ProtocolJSON:
+		return "JSON"
+	default:
+		return fmt.Sprintf("Protocol(%d)", uint8(p))
+	}
+}
+
+// decodeJSONMessage decodes a text message of the JSON protocol. def is needed to convert rows and
+// reducer arguments to BSATN.
+func decodeJSONMessage(data []byte, def *ModuleDef) (*ServerMessage, error) {
+	name, content, err := unmarshalJSONSum(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode JSON message: %w", err)
+	}
+	d := &jsonMessageDecoder{def: def}
+	var message any
+	switch name {
+	case "IdentityToken":
+		message, err = d.identityToken(content)
+	case "InitialSubscription":
+		message, err = d.initialSubscription(content)
+	case "TransactionUpdate":
+		message, err = d.transactionUpdate(content)
+	case "TransactionUpdateLight":
+		message, err = d.transactionUpdateLight(content)
+	case "OneOffQueryResponse":
+		message, err = d.oneOffQueryResponse(content)
+	case "SubscribeApplied":
+		var applied *SubscribeApplied
+		applied, err = d.subscribeApplied(content)
+		message = applied
+	case "UnsubscribeApplied":
+		var applied *SubscribeApplied
+		applied, err = d.subscribeApplied(content)
+		if applied != nil {
+			message = (*UnsubscribeApplied)(applied)
+		}
+	case "SubscriptionError":
+		message, err = d.subscriptionError(content)
+	case "SubscribeMultiApplied":
+		var applied *SubscribeMultiApplied
+		applied, err = d.subscribeMultiApplied(content)
+		message = applied
+	case "UnsubscribeMultiApplied":
+		var applied *SubscribeMultiApplied
+		applied, err = d.subscribeMultiApplied(content)
+		if applied != nil {
+			message = (*UnsubscribeMultiApplied)(applied)
+		}
+	default:
+		return nil, fmt.Errorf("unknown JSON message type: %s", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode JSON %s: %w", name, err)
+	}
+	return &ServerMessage{Message: message}, nil
+}
+
+// jsonMessageDecoder converts the parts of JSON messages that depend on the module to BSATN.
+type jsonMessageDecoder struct {
+	def *ModuleDef
+}
+
+type jsonQueryId struct {
+	Id uint32 `json:"id"`
+}
+
+// jsonOption decodes an Option sent as {"some": value} or {"none": []}, or as a value or null.
+type jsonOption[T any] struct {
+	value *T
+}
+
+func (o *jsonOption[T]) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if name, content, err := unmarshalJSONSum(data); err == nil && (name == "some" || name == "none") {
+		if name == "none" {
+			return nil
+		}
+		data = content
+	}
+	o.value = new(T)
+	return json.Unmarshal(data, o.value)
+}
+
+type jsonQueryUpdate struct {
+	Deletes []json.RawMessage `json:"deletes"`
+	Inserts []json.RawMessage `json:"inserts"`
+}
+
+type jsonTableUpdate struct {
+	TableId   uint32            `json:"table_id"`
+	TableName string            `json:"table_name"`
+	NumRows   uint64            `json:"num_rows"`
+	Updates   []jsonQueryUpdate `json:"updates"`
+}
+
+type jsonDatabaseUpdate struct {
+	Tables []jsonTableUpdate `json:"tables"`
+}
+
+// jsonText returns the JSON held by a string, as rows and reducer arguments are sent, or data
+// itself if it is not a string.
+func jsonText(data json.RawMessage) []byte {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		return []byte(text)
+	}
+	return data
+}
+
+// rows converts the rows of a table to BSATN.
+func (d *jsonMessageDecoder) rows(tableName string, rows []json.RawMessage) (*BsatnRowList, error) {
+	if len(rows) == 0 {
+		return NewBsatnRowList(nil), nil
+	}
+	if d.def == nil {
+		return nil, fmt.Errorf("the module definition is needed to decode rows of table %s", tableName)
+	}
+	var rowType *AlgebraicType
+	for _, table := range d.def.Tables {
+		if table.Name == tableName {
+			ty := NewRefType(table.ProductTypeRef)
+			rowType = &ty
+			break
+		}
+	}
+	if rowType == nil {
+		return nil, fmt.Errorf("table %s is not in the module definition", tableName)
+	}
+
+	encoded := make([][]byte, len(rows))
+	for i, row := range rows {
+		writer := NewBinaryWriter()
+		if err := d.def.Typespace.EncodeJSON(writer, jsonText(row), *rowType); err != nil {
+			return nil, fmt.Errorf("failed to decode row of table %s: %w", tableName, err)
+		}
+		encoded[i] = writer.GetBuffer()
+	}
+	return NewBsatnRowList(encoded), nil
+}
+
+func (d *jsonMessageDecoder) tableUpdate(update jsonTableUpdate) (*TableUpdate, error) {
+	tableUpdate := &TableUpdate{TableID: update.TableId, TableName: update.TableName, NumRows: update.NumRows}
+	for _, queryUpdate := range update.Updates {
+		deletes, err := d.rows(update.TableName, queryUpdate.Deletes)
+		if err != nil {
+			return nil, err
+		}
+		inserts, err := d.rows(update.TableName, queryUpdate.Inserts)
+		if err != nil {
+			return nil, err
+		}
+		tableUpdate.Updates = append(tableUpdate.Updates, &QueryUpdate{Deletes: deletes, Inserts: inserts})
+	}
+	return tableUpdate, nil
+}
+
+func (d *jsonMessageDecoder) databaseUpdate(update jsonDatabaseUpdate) (*DatabaseUpdate, error) {
+	databaseUpdate := &DatabaseUpdate{}
+	for _, table := range update.Tables {
+		tableUpdate, err := d.tableUpdate(table)
+		if err != nil {
+			return nil, err
+		}
+		databaseUpdate.Tables = append(databaseUpdate.Tables, tableUpdate)
+	}
+	return databaseUpdate, nil
+}
+
+// reducerArgs converts the arguments of a reducer call to BSATN. They are left empty for reducers
+// that are not in the module definition.
+func (d *jsonMessageDecoder) reducerArgs(reducerName string, args json.RawMessage) ([]byte, error) {
+	if d.def == nil || len(args) == 0 {
+		return nil, nil
+	}
+	for _, reducer := range d.def.Reducers {
+		if reducer.Name == reducerName {
+			writer := NewBinaryWriter()
+			params := AlgebraicType{Kind: AlgebraicTypeProduct, Product: &reducer.Params}
+			if err := d.def.Typespace.EncodeJSON(writer, jsonText(args), params); err != nil {
+				return nil, fmt.Errorf("failed to decode arguments of reducer %s: %w", reducerName, err)
+			}
+			return writer.GetBuffer(), nil
+		}
+	}
+	return nil, nil
+}
+
+func (d *jsonMessageDecoder) identityToken(data []byte) (*IdentityToken, error) {
+	var message struct {
+		Identity     Identity     `json:"identity"`
+		Token        string       `json:"token"`
+		ConnectionId ConnectionId `json:"connection_id"`
+	}
+	if err := json.Unmarshal(data, &message); err != nil {
+		return nil, err
+	}
+	return &IdentityToken{Identity: message.Identity, Token: message.Token, ConnectionId: message.ConnectionId}, nil
+}
+
+func (d *jsonMessageDecoder) initialSubscription(data []byte) (*InitialSubscription, error) {
+	var message struct {
+		DatabaseUpdate             jsonDatabaseUpdate `json:"database_update"`
+		RequestId                  uint32             `json:"request_id"`
+		TotalHostExecutionDuration TimeDuration       `json:"total_host_execution_duration"`
+	}
+	if err := json.Unmarshal(data, &message); err != nil {
+		return nil, err
+	}
+	update, err := d.databaseUpdate(message.DatabaseUpdate)
+	if err != nil {
+		return nil, err
+	}
+	return &InitialSubscription{
+		DatabaseUpdate:             update,
+		RequestId:                  message.RequestId,
+		TotalHostExecutionDuration: &message.TotalHostExecutionDuration,
+	}, nil
+}
+
+func (d *jsonMessageDecoder) updateStatus(data []byte) (*UpdateStatus, error) {
+	name, content, err := unmarshalJSONSum(data)
+	if err != nil {
+		return nil, err
+	}
+	switch name {
+	case "Committed":
+		var update jsonDatabaseUpdate
+		if err := json.Unmarshal(content, &update); err != nil {
+			return nil, err
+		}
+		databaseUpdate, err := d.databaseUpdate(update)
+		if err != nil {
+			return nil, err
+		}
+		return &UpdateStatus{Status: &UpdateStatusComitted{DatabaseUpdate: databaseUpdate}}, nil
+	case "Failed":
+		var errorMessage string
+		if err := json.Unmarshal(content, &errorMessage); err != nil {
+			return nil, err
+		}
+		return &UpdateStatus{Status: &UpdateStatusFailed{ErrorMessage: errorMessage}}, nil
+	case "OutOfEnergy":
+		return &UpdateStatus{Status: &UpdateStatusOutOfEnergy{}}, nil
+	default:
+		return nil, fmt.Errorf("unknown update status: %s", name)
+	}
+}
+
+func (d *jsonMessageDecoder) transactionUpdate(data []byte) (*TransactionUpdate, error) {
+	var message struct {
+		Status             json.RawMessage `json:"status"`
+		Timestamp          Timestamp       `json:"timestamp"`
+		CallerIdentity     Identity        `json:"caller_identity"`
+		CallerConnectionId ConnectionId    `json:"caller_connection_id"`
+		ReducerCall        struct {
+			ReducerName string          `json:"reducer_name"`
+			ReducerId   uint32          `json:"reducer_id"`
+			Args        json.RawMessage `json:"args"`
+			RequestId   uint32          `json:"request_id"`
+		} `json:"reducer_call"`
+		EnergyQuantaUsed struct {
+			Quanta json.RawMessage `json:"quanta"`
+		} `json:"energy_quanta_used"`
+		TotalHostExecutionDuration TimeDuration `json:"total_host_execution_duration"`
+	}
+	if err := json.Unmarshal(data, &message); err != nil {
+		return nil, err
+	}
+	status, err := d.updateStatus(message.Status)
+	if err != nil {
+		return nil, err
+	}
+	args, err := d.reducerArgs(message.ReducerCall.ReducerName, message.ReducerCall.Args)
+	if err != nil {
+		return nil, err
+	}
+	energy := &EnergyQuanta{}
+	if message.EnergyQuantaUsed.Quanta != nil {
+		quanta, err := parseJSONBigInt(message.EnergyQuantaUsed.Quanta)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode energy: %w", err)
+		}
+		energy.Quanta = *quanta
+	}
+	return &TransactionUpdate{
+		Status:             status,
+		Timestamp:          &message.Timestamp,
+		CallerIdentity:     message.CallerIdentity,
+		CallerConnectionId: message.CallerConnectionId,
+		ReducerCall: &ReducerCallInfo{
+			ReducerName: message.ReducerCall.ReducerName,
+			ReducerID:   message.ReducerCall.ReducerId,
+			Args:        args,
+			RequestID:   message.ReducerCall.RequestId,
+		},
+		EnergyQuantaUsed:           energy,
+		TotalHostExecutionDuration: &message.TotalHostExecutionDuration,
+	}, nil
+}
+
+func (d *jsonMessageDecoder) transactionUpdateLight(data []byte) (*TransactionUpdateLight, error) {
+	var message struct {
+		RequestId uint32             `json:"request_id"`
+		Update    jsonDatabaseUpdate `json:"update"`
+	}
+	if err := json.Unmarshal(data, &message); err != nil {
+		return nil, err
+	}
+	update, err := d.databaseUpdate(message.Update)
+	if err != nil {
+		return nil, err
+	}
+	return &TransactionUpdateLight{RequestId: message.RequestId, Update: update}, nil
+}
+
+func (d *jsonMessageDecoder) oneOffQueryResponse(data []byte) (*OneOffQueryResponse, error) {
+	var message struct {
+		MessageId json.RawMessage    `json:"message_id"`
+		Error     jsonOption[string] `json:"error"`
+		Tables    []struct {
+			TableName string            `json:"table_name"`
+			Rows      []json.RawMessage `json:"rows"`
+		} `json:"tables"`
+		TotalHostExecutionDuration TimeDuration `json:"total_host_execution_duration"`
+	}
+	if err := json.Unmarshal(data, &message); err != nil {
+		return nil, err
+	}
+	messageId, err := parseJSONBytes(message.MessageId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode message id: %w", err)
+	}
+	response := &OneOffQueryResponse{
+		MessageId:                  messageId,
+		Error:                      message.Error.value,
+		TotalHostExecutionDuration: &message.TotalHostExecutionDuration,
+	}
+	for _, table := range message.Tables {
+		rows, err := d.rows(table.TableName, table.Rows)
+		if err != nil {
+			return nil, err
+		}
+		response.Tables = append(response.Tables, &OneOffTable{TableName: table.TableName, Rows: rows})
+	}
+	return response, nil
+}
+
+func (d *jsonMessageDecoder) subscribeApplied(data []byte) (*SubscribeApplied, error) {
+	var message struct {
+		RequestId                        uint32      `json:"request_id"`
+		TotalHostExecutionDurationMicros uint64      `json:"total_host_execution_duration_micros"`
+		QueryId                          jsonQueryId `json:"query_id"`
+		Rows                             struct {
+			TableId   uint32          `json:"table_id"`
+			TableName string          `json:"table_name"`
+			TableRows jsonTableUpdate `json:"table_rows"`
+		} `json:"rows"`
+	}
+	if err := json.Unmarshal(data, &message); err != nil {
+		return nil, err
+	}
+	tableRows, err := d.tableUpdate(message.Rows.TableRows)
+	if err != nil {
+		return nil, err
+	}
+	return &SubscribeApplied{
+		RequestId:                  message.RequestId,
+		TotalHostExecutionDuration: NewTimeDuration(int64(message.TotalHostExecutionDurationMicros)),
+		QueryId:                    message.QueryId.Id,
+		Rows:                       &SubscribeRows{TableID: message.Rows.TableId, TableName: message.Rows.TableName, TableRows: tableRows},
+	}, nil
+}
+
+func (d *jsonMessageDecoder) subscribeMultiApplied(data []byte) (*SubscribeMultiApplied, error) {
+	var message struct {
+		RequestId                        uint32             `json:"request_id"`
+		TotalHostExecutionDurationMicros uint64             `json:"total_host_execution_duration_micros"`
+		QueryId                          jsonQueryId        `json:"query_id"`
+		Update                           jsonDatabaseUpdate `json:"update"`
+	}
+	if err := json.Unmarshal(data, &message); err != nil {
+		return nil, err
+	}
+	update, err := d.databaseUpdate(message.Update)
+	if err != nil {
+		return nil, err
+	}
+	return &SubscribeMultiApplied{
+		RequestId:                  message.RequestId,
+		TotalHostExecutionDuration: NewTimeDuration(int64(message.TotalHostExecutionDurationMicros)),
+		QueryId:                    message.QueryId.Id,
+		Update:                     update,
+	}, nil
+}
+
+func (d *jsonMessageDecoder) subscriptionError(data []byte) (*SubscriptionError, error) {
+	var message struct {
+		TotalHostExecutionDurationMicros uint64             `json:"total_host_execution_duration_micros"`
+		RequestId                        jsonOption[uint32] `json:"request_id"`
+		QueryId                          jsonOption[uint32] `json:"query_id"`
+		TableId                          jsonOption[uint32] `json:"table_id"`
+		Error                            string             `json:"error"`
+	}
+	if err := json.Unmarshal(data, &message); err != nil {
+		return nil, err
+	}
+	return &SubscriptionError{
+		TotalHostExecutionDuration: NewTimeDuration(int64(message.TotalHostExecutionDurationMicros)),
+		RequestId:                  message.RequestId.value,
+		QueryId:                    message.QueryId.value,
+		TableId:                    message.TableId.value,
+		ErrorMessage:               message.Error,
+	}, nil
+}
@@ -0,0 +1,56 @@
+package spacetimedb
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// keepalive sends pings on ws every PingInterval until stop is closed, and makes reads fail when
+// nothing has been received from the server for IdleTimeout. A failed read ends the read loop, so
+// a dead connection is reported as disconnected and reconnected if a ReconnectPolicy is set.
+func (db *DBConnection) keepalive(ws *websocket.Conn, stop <-chan struct{}) {
+	if db.IdleTimeout > 0 {
+		ws.SetPongHandler(func(string) error {
+			db.extendReadDeadline(ws)
+			return nil
+		})
+	}
+	if db.PingInterval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(db.PingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(db.PingInterval)); err != nil {
+					db.Logger("Error sending ping: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// extendReadDeadline gives the server another IdleTimeout to send a message or pong.
+func (db *DBConnection) extendReadDeadline(ws *websocket.Conn) {
+	if db.IdleTimeout > 0 {
+		ws.SetReadDeadline(time.Now().Add(db.IdleTimeout))
+	}
+}
+
+// idleError explains a read that failed because the read deadline set by keepalive passed.
+func (db *DBConnection) idleError(err error) error {
+	var netErr net.Error
+	if db.IdleTimeout > 0 && errors.As(err, &netErr) && netErr.Timeout() {
+		return fmt.Errorf("nothing received from the server for %s: %w", db.IdleTimeout, err)
+	}
+	return err
+}
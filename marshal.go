@@ -0,0 +1,574 @@
+package spacetimedb
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Sum is implemented by Go types that represent a SATS sum type, which holds one of several
+// variants. The variants are Go types, and the index of a variant in SumVariants is its tag.
+//
+// SetSumValue usually has a pointer receiver, so Marshal and Unmarshal look for Sum on a pointer
+// to the type.
+type Sum interface {
+	// SumVariants returns a value of every variant type, in tag order. It is called on a zero value
+	// and only the types of the values are used.
+	SumVariants() []any
+	// SumValue returns the current variant.
+	SumValue() any
+	// SetSumValue sets the current variant to a value of one of the variant types.
+	SetSumValue(value any)
+}
+
+// Marshal returns the BSATN encoding of v. If v is a pointer, the value it points to is encoded.
+//
+// Structs are encoded as products of their exported fields, in order. Slices, arrays and maps are
+// encoded as arrays, with map entries sorted by the encoding of their keys. Pointers are encoded as
+// Options, where nil is None. Types with their own Serialize and Deserialize methods, such as
+// Identity, ConnectionId, Timestamp and TimeDuration, use them, and a pointer to such a type is the
+// value itself rather than an Option. Types implementing Sum are encoded as sums.
+//
+// Fields are configured with a bsatn struct tag holding comma separated options:
+//
+//	Secret string   `bsatn:"-"`    // the field is skipped
+//	Score  *big.Int `bsatn:"u128"` // *big.Int needs one of i128, u128, i256 or u256
+//
+// int and uint are not supported, as BSATN integers have a fixed size.
+func Marshal(v any) ([]byte, error) {
+	if v == nil {
+		return nil, fmt.Errorf("bsatn: cannot marshal nil")
+	}
+	value := reflect.ValueOf(v)
+	if value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+	c, err := codecFor(value.Type(), "")
+	if err != nil {
+		return nil, err
+	}
+	writer := NewBinaryWriter()
+	if err := c.encode(writer, value); err != nil {
+		return nil, err
+	}
+	return writer.GetBuffer(), nil
+}
+
+// Unmarshal decodes the BSATN encoded data into the value v points to. See Marshal for how Go
+// types map to BSATN. All of data must be used.
+func Unmarshal(data []byte, v any) error {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Pointer || value.IsNil() {
+		return fmt.Errorf("bsatn: Unmarshal needs a non-nil pointer, got %T", v)
+	}
+	c, err := codecFor(value.Type().Elem(), "")
+	if err != nil {
+		return err
+	}
+	reader := NewBinaryReader(data)
+	if err := c.decode(reader, value.Elem()); err != nil {
+		return err
+	}
+	if err := reader.Err(); err != nil {
+		return fmt.Errorf("bsatn: failed to decode %s: %w", value.Type().Elem(), err)
+	}
+	if reader.Remaining() != 0 {
+		return fmt.Errorf("bsatn: %d bytes left over after decoding %s", reader.Remaining(), value.Type().Elem())
+	}
+	return nil
+}
+
+// codec encodes and decodes the values of a Go type. It is built once per type and cached.
+type codec struct {
+	encode func(writer *BinaryWriter, v reflect.Value) error
+	// decode reads into v, which is settable.
+	decode func(reader *BinaryReader, v reflect.Value) error
+}
+
+// codecKey identifies a codec. bigInt is the size option of *big.Int values, which comes from the
+// struct tag of the field they are in.
+type codecKey struct {
+	t      reflect.Type
+	bigInt string
+}
+
+var (
+	codecs   sync.Map // codecKey -> *codec
+	codecsMu sync.Mutex
+
+	serializerType   = reflect.TypeFor[interface{ Serialize(*BinaryWriter) error }]()
+	deserializerType = reflect.TypeFor[interface{ Deserialize(*BinaryReader) error }]()
+	sumType          = reflect.TypeFor[Sum]()
+	bigIntType       = reflect.TypeFor[*big.Int]()
+)
+
+func codecFor(t reflect.Type, bigInt string) (*codec, error) {
+	key := codecKey{t: t, bigInt: bigInt}
+	if c, ok := codecs.Load(key); ok {
+		return c.(*codec), nil
+	}
+
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	// Codecs are only cached once the whole type has been built, so a type that fails to build
+	// leaves nothing behind.
+	building := make(map[codecKey]*codec)
+	c, err := buildCodec(key, building)
+	if err != nil {
+		return nil, err
+	}
+	for key, c := range building {
+		codecs.Store(key, c)
+	}
+	return c, nil
+}
+
+// buildCodec builds the codec of a type. Codecs being built are kept in building, so recursive
+// types refer to the codec that is being filled in.
+func buildCodec(key codecKey, building map[codecKey]*codec) (*codec, error) {
+	if c, ok := codecs.Load(key); ok {
+		return c.(*codec), nil
+	}
+	if c, ok := building[key]; ok {
+		return c, nil
+	}
+	c := &codec{}
+	building[key] = c
+
+	t := key.t
+	var err error
+	switch {
+	case t == bigIntType:
+		err = bigIntCodec(c, key.bigInt)
+	case t.Kind() == reflect.Pointer && isValueType(t.Elem()):
+		err = valuePointerCodec(c, t, building)
+	case isValueType(t):
+		err = methodCodec(c, t)
+	case reflect.PointerTo(t).Implements(sumType):
+		err = sumCodec(c, t, building)
+	default:
+		err = kindCodec(c, key, building)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// isValueType reports whether t is encoded by its own methods. A pointer to such a type is the
+// value, rather than an Option.
+func isValueType(t reflect.Type) bool {
+	p := reflect.PointerTo(t)
+	return p.Implements(serializerType) && p.Implements(deserializerType)
+}
+
+// addressable returns a pointer to the value of v, copying it if v is not addressable.
+func addressable(v reflect.Value) reflect.Value {
+	if v.CanAddr() {
+		return v.Addr()
+	}
+	p := reflect.New(v.Type())
+	p.Elem().Set(v)
+	return p
+}
+
+func methodCodec(c *codec, t reflect.Type) error {
+	c.encode = func(writer *BinaryWriter, v reflect.Value) error {
+		return addressable(v).Interface().(interface{ Serialize(*BinaryWriter) error }).Serialize(writer)
+	}
+	c.decode = func(reader *BinaryReader, v reflect.Value) error {
+		return v.Addr().Interface().(interface{ Deserialize(*BinaryReader) error }).Deserialize(reader)
+	}
+	return nil
+}
+
+func valuePointerCodec(c *codec, t reflect.Type, building map[codecKey]*codec) error {
+	elem, err := buildCodec(codecKey{t: t.Elem()}, building)
+	if err != nil {
+		return err
+	}
+	c.encode = func(writer *BinaryWriter, v reflect.Value) error {
+		if v.IsNil() {
+			return fmt.Errorf("bsatn: cannot encode nil %s", t)
+		}
+		return elem.encode(writer, v.Elem())
+	}
+	c.decode = func(reader *BinaryReader, v reflect.Value) error {
+		value := reflect.New(t.Elem())
+		if err := elem.decode(reader, value.Elem()); err != nil {
+			return err
+		}
+		v.Set(value)
+		return nil
+	}
+	return nil
+}
+
+func bigIntCodec(c *codec, size string) error {
+	var write func(*BinaryWriter, *big.Int)
+	var read func(*BinaryReader) *big.Int
+	switch size {
+	case "i128":
+		write, read = (*BinaryWriter).WriteI128, (*BinaryReader).ReadI128
+	case "u128":
+		write, read = (*BinaryWriter).WriteU128, (*BinaryReader).ReadU128
+	case "i256":
+		write, read = (*BinaryWriter).WriteI256, (*BinaryReader).ReadI256
+	case "u256":
+		write, read = (*BinaryWriter).WriteU256, (*BinaryReader).ReadU256
+	default:
+		return fmt.Errorf("bsatn: *big.Int needs a bsatn tag with its size: i128, u128, i256 or u256")
+	}
+	c.encode = func(writer *BinaryWriter, v reflect.Value) error {
+		if v.IsNil() {
+			return fmt.Errorf("bsatn: cannot encode nil *big.Int")
+		}
+		write(writer, v.Interface().(*big.Int))
+		return nil
+	}
+	c.decode = func(reader *BinaryReader, v reflect.Value) error {
+		v.Set(reflect.ValueOf(read(reader)))
+		return nil
+	}
+	return nil
+}
+
+func sumCodec(c *codec, t reflect.Type, building map[codecKey]*codec) error {
+	variants := reflect.New(t).Interface().(Sum).SumVariants()
+	if len(variants) > 256 {
+		return fmt.Errorf("bsatn: sum %s has %d variants, at most 256 are supported", t, len(variants))
+	}
+	types := make([]reflect.Type, len(variants))
+	variantCodecs := make([]*codec, len(variants))
+	for i, variant := range variants {
+		if variant == nil {
+			return fmt.Errorf("bsatn: variant %d of sum %s is nil", i, t)
+		}
+		types[i] = reflect.TypeOf(variant)
+		variantCodec, err := buildCodec(codecKey{t: types[i]}, building)
+		if err != nil {
+			return err
+		}
+		variantCodecs[i] = variantCodec
+	}
+
+	c.encode = func(writer *BinaryWriter, v reflect.Value) error {
+		value := reflect.ValueOf(addressable(v).Interface().(Sum).SumValue())
+		if value.IsValid() && value.Kind() == reflect.Pointer && !slices.Contains(types, value.Type()) && !value.IsNil() {
+			value = value.Elem()
+		}
+		tag := -1
+		if value.IsValid() {
+			tag = slices.Index(types, value.Type())
+		}
+		if tag < 0 {
+			return fmt.Errorf("bsatn: value of sum %s has unknown variant type %s", t, value.Type())
+		}
+		writer.WriteU8(uint8(tag))
+		return variantCodecs[tag].encode(writer, value)
+	}
+	c.decode = func(reader *BinaryReader, v reflect.Value) error {
+		tag := int(reader.ReadU8())
+		if reader.Err() != nil {
+			return nil
+		}
+		if tag >= len(types) {
+			return fmt.Errorf("bsatn: unknown tag %d of sum %s", tag, t)
+		}
+		value := reflect.New(types[tag]).Elem()
+		if err := variantCodecs[tag].decode(reader, value); err != nil {
+			return err
+		}
+		v.Addr().Interface().(Sum).SetSumValue(value.Interface())
+		return nil
+	}
+	return nil
+}
+
+// kindCodec builds the codec of the types that are encoded based on their kind.
+func kindCodec(c *codec, key codecKey, building map[codecKey]*codec) error {
+	t := key.t
+	switch t.Kind() {
+	case reflect.Bool:
+		c.encode = func(writer *BinaryWriter, v reflect.Value) error { writer.WriteBool(v.Bool()); return nil }
+		c.decode = func(reader *BinaryReader, v reflect.Value) error { v.SetBool(reader.ReadBool()); return nil }
+	case reflect.Int8:
+		c.encode = func(writer *BinaryWriter, v reflect.Value) error { writer.WriteI8(int8(v.Int())); return nil }
+		c.decode = func(reader *BinaryReader, v reflect.Value) error { v.SetInt(int64(reader.ReadI8())); return nil }
+	case reflect.Int16:
+		c.encode = func(writer *BinaryWriter, v reflect.Value) error { writer.WriteI16(int16(v.Int())); return nil }
+		c.decode = func(reader *BinaryReader, v reflect.Value) error { v.SetInt(int64(reader.ReadI16())); return nil }
+	case reflect.Int32:
+		c.encode = func(writer *BinaryWriter, v reflect.Value) error { writer.WriteI32(int32(v.Int())); return nil }
+		c.decode = func(reader *BinaryReader, v reflect.Value) error { v.SetInt(int64(reader.ReadI32())); return nil }
+	case reflect.Int64:
+		c.encode = func(writer *BinaryWriter, v reflect.Value) error { writer.WriteI64(v.Int()); return nil }
+		c.decode = func(reader *BinaryReader, v reflect.Value) error { v.SetInt(reader.ReadI64()); return nil }
+	case reflect.Uint8:
+		c.encode = func(writer *BinaryWriter, v reflect.Value) error { writer.WriteU8(uint8(v.Uint())); return nil }
+		c.decode = func(reader *BinaryReader, v reflect.Value) error { v.SetUint(uint64(reader.ReadU8())); return nil }
+	case reflect.Uint16:
+		c.encode = func(writer *BinaryWriter, v reflect.Value) error { writer.WriteU16(uint16(v.Uint())); return nil }
+		c.decode = func(reader *BinaryReader, v reflect.Value) error { v.SetUint(uint64(reader.ReadU16())); return nil }
+	case reflect.Uint32:
+		c.encode = func(writer *BinaryWriter, v reflect.Value) error { writer.WriteU32(uint32(v.Uint())); return nil }
+		c.decode = func(reader *BinaryReader, v reflect.Value) error { v.SetUint(uint64(reader.ReadU32())); return nil }
+	case reflect.Uint64:
+		c.encode = func(writer *BinaryWriter, v reflect.Value) error { writer.WriteU64(v.Uint()); return nil }
+		c.decode = func(reader *BinaryReader, v reflect.Value) error { v.SetUint(reader.ReadU64()); return nil }
+	case reflect.Float32:
+		c.encode = func(writer *BinaryWriter, v reflect.Value) error { writer.WriteF32(float32(v.Float())); return nil }
+		c.decode = func(reader *BinaryReader, v reflect.Value) error { v.SetFloat(float64(reader.ReadF32())); return nil }
+	case reflect.Float64:
+		c.encode = func(writer *BinaryWriter, v reflect.Value) error { writer.WriteF64(v.Float()); return nil }
+		c.decode = func(reader *BinaryReader, v reflect.Value) error { v.SetFloat(reader.ReadF64()); return nil }
+	case reflect.String:
+		c.encode = func(writer *BinaryWriter, v reflect.Value) error { writer.WriteString(v.String()); return nil }
+		c.decode = func(reader *BinaryReader, v reflect.Value) error { v.SetString(reader.ReadString()); return nil }
+	case reflect.Slice:
+		return sliceCodec(c, key, building)
+	case reflect.Array:
+		return arrayCodec(c, key, building)
+	case reflect.Map:
+		return mapCodec(c, key, building)
+	case reflect.Pointer:
+		return optionCodec(c, key, building)
+	case reflect.Struct:
+		return structCodec(c, t, building)
+	case reflect.Int, reflect.Uint:
+		return fmt.Errorf("bsatn: unsupported type %s, use a sized integer type such as int64", t)
+	default:
+		return fmt.Errorf("bsatn: unsupported type %s", t)
+	}
+	return nil
+}
+
+func sliceCodec(c *codec, key codecKey, building map[codecKey]*codec) error {
+	t := key.t
+	if t.Elem().Kind() == reflect.Uint8 {
+		c.encode = func(writer *BinaryWriter, v reflect.Value) error {
+			writer.WriteUInt8Array(v.Bytes())
+			return nil
+		}
+		c.decode = func(reader *BinaryReader, v reflect.Value) error {
+			v.SetBytes(reader.ReadUInt8Array())
+			return nil
+		}
+		return nil
+	}
+
+	elem, err := buildCodec(codecKey{t: t.Elem(), bigInt: key.bigInt}, building)
+	if err != nil {
+		return err
+	}
+	c.encode = func(writer *BinaryWriter, v reflect.Value) error {
+		writer.WriteU32(uint32(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			if err := elem.encode(writer, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	c.decode = func(reader *BinaryReader, v reflect.Value) error {
+		length := int(reader.ReadU32())
+		// The length comes from the data, so it is not trusted for preallocating.
+		slice := reflect.MakeSlice(t, 0, min(length, reader.Remaining()))
+		for i := 0; i < length && reader.Err() == nil; i++ {
+			slice = reflect.Append(slice, reflect.Zero(t.Elem()))
+			if err := elem.decode(reader, slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+	return nil
+}
+
+func arrayCodec(c *codec, key codecKey, building map[codecKey]*codec) error {
+	t := key.t
+	elem, err := buildCodec(codecKey{t: t.Elem(), bigInt: key.bigInt}, building)
+	if err != nil {
+		return err
+	}
+	c.encode = func(writer *BinaryWriter, v reflect.Value) error {
+		writer.WriteU32(uint32(v.Len()))
+		for i := 0; i < v.Len(); i++ {
+			if err := elem.encode(writer, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	c.decode = func(reader *BinaryReader, v reflect.Value) error {
+		length := int(reader.ReadU32())
+		if reader.Err() != nil {
+			return nil
+		}
+		if length != t.Len() {
+			return fmt.Errorf("bsatn: cannot decode array of %d elements into %s", length, t)
+		}
+		for i := 0; i < length; i++ {
+			if err := elem.decode(reader, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+func mapCodec(c *codec, key codecKey, building map[codecKey]*codec) error {
+	t := key.t
+	keyCodec, err := buildCodec(codecKey{t: t.Key()}, building)
+	if err != nil {
+		return err
+	}
+	valueCodec, err := buildCodec(codecKey{t: t.Elem(), bigInt: key.bigInt}, building)
+	if err != nil {
+		return err
+	}
+	c.encode = func(writer *BinaryWriter, v reflect.Value) error {
+		type entry struct {
+			key   []byte
+			value reflect.Value
+		}
+		// Entries are sorted by their encoded key, so a map always has the same encoding.
+		entries := make([]entry, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			keyWriter := NewBinaryWriter()
+			if err := keyCodec.encode(keyWriter, iter.Key()); err != nil {
+				return err
+			}
+			entries = append(entries, entry{key: keyWriter.GetBuffer(), value: iter.Value()})
+		}
+		slices.SortFunc(entries, func(a, b entry) int { return bytes.Compare(a.key, b.key) })
+
+		writer.WriteU32(uint32(len(entries)))
+		for _, e := range entries {
+			writer.WriteBytes(e.key)
+			if err := valueCodec.encode(writer, e.value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	c.decode = func(reader *BinaryReader, v reflect.Value) error {
+		length := int(reader.ReadU32())
+		m := reflect.MakeMapWithSize(t, min(length, reader.Remaining()))
+		for i := 0; i < length && reader.Err() == nil; i++ {
+			mapKey := reflect.New(t.Key()).Elem()
+			if err := keyCodec.decode(reader, mapKey); err != nil {
+				return err
+			}
+			mapValue := reflect.New(t.Elem()).Elem()
+			if err := valueCodec.decode(reader, mapValue); err != nil {
+				return err
+			}
+			m.SetMapIndex(mapKey, mapValue)
+		}
+		v.Set(m)
+		return nil
+	}
+	return nil
+}
+
+// optionCodec encodes a pointer as an Option, where tag 0 is Some followed by the value and tag 1 is None.
+func optionCodec(c *codec, key codecKey, building map[codecKey]*codec) error {
+	t := key.t
+	elem, err := buildCodec(codecKey{t: t.Elem(), bigInt: key.bigInt}, building)
+	if err != nil {
+		return err
+	}
+	c.encode = func(writer *BinaryWriter, v reflect.Value) error {
+		if v.IsNil() {
+			writer.WriteU8(1)
+			return nil
+		}
+		writer.WriteU8(0)
+		return elem.encode(writer, v.Elem())
+	}
+	c.decode = func(reader *BinaryReader, v reflect.Value) error {
+		switch tag := reader.ReadU8(); tag {
+		case 0:
+			value := reflect.New(t.Elem())
+			if err := elem.decode(reader, value.Elem()); err != nil {
+				return err
+			}
+			v.Set(value)
+		case 1:
+			v.SetZero()
+		default:
+			if reader.Err() == nil {
+				return fmt.Errorf("bsatn: invalid option tag %d for %s", tag, t)
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+type fieldCodec struct {
+	index int
+	name  string
+	codec *codec
+}
+
+func structCodec(c *codec, t reflect.Type, building map[codecKey]*codec) error {
+	var fields []fieldCodec
+	unexported := 0
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			unexported++
+			continue
+		}
+		tag := field.Tag.Get("bsatn")
+		if tag == "-" {
+			continue
+		}
+		key := codecKey{t: field.Type}
+		for _, option := range strings.Split(tag, ",") {
+			switch option {
+			case "":
+			case "i128", "u128", "i256", "u256":
+				key.bigInt = option
+			default:
+				return fmt.Errorf("bsatn: unknown option %q in tag of %s.%s", option, t, field.Name)
+			}
+		}
+		fc, err := buildCodec(key, building)
+		if err != nil {
+			return fmt.Errorf("%w (in field %s.%s)", err, t, field.Name)
+		}
+		fields = append(fields, fieldCodec{index: i, name: field.Name, codec: fc})
+	}
+	if len(fields) == 0 && unexported > 0 {
+		// Encoding it as an empty product would silently lose its data.
+		return fmt.Errorf("bsatn: unsupported type %s, it has no exported fields", t)
+	}
+
+	c.encode = func(writer *BinaryWriter, v reflect.Value) error {
+		for _, field := range fields {
+			if err := field.codec.encode(writer, v.Field(field.index)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	c.decode = func(reader *BinaryReader, v reflect.Value) error {
+		for _, field := range fields {
+			if err := field.codec.decode(reader, v.Field(field.index)); err != nil {
+				return fmt.Errorf("%w (in field %s.%s)", err, t, field.name)
+			}
+		}
+		return nil
+	}
+	return nil
+}
@@ -2,30 +2,36 @@ package spacetimedb
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 )
 
-func (db *DBConnection) parseBsantMessage(msg []byte) error {
-	reader := NewBinaryReader(msg)
+// decodeBsatnMessage decompresses and deserializes a binary message from the server.
+func decodeBsatnMessage(msg []byte) (*ServerMessage, error) {
+	if len(msg) == 0 {
+		return nil, fmt.Errorf("received empty message")
+	}
 
-	// Handle compression
-	compression := reader.ReadU8()
-	switch compression {
-	case CompressionTypeNone:
-		// No compression, read the message directly
-	case CompressionTypeBrotly:
-		return fmt.Errorf("Brotly compression is not implemented yet")
-	case CompressionTypeGzip:
-		return fmt.Errorf("Gzip compression is not implemented yet")
-	default:
-		return fmt.Errorf("unknown compression type: %d", compression)
+	// Handle compression. The first byte tells how the rest of the message is compressed.
+	payload, err := decompress(msg[0], msg[1:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress message: %w", err)
 	}
+	reader := NewBinaryReader(payload)
 
 	// Read the message type
 	serverMsg := &ServerMessage{}
 	if err := serverMsg.Deserialize(reader); err != nil {
-		return fmt.Errorf("failed to deserialize server message: %w", err)
+		return nil, fmt.Errorf("failed to deserialize server message: %w", err)
+	}
+	if reader.Offset() != len(payload) {
+		return nil, fmt.Errorf("failed to deserialize server message: %d trailing bytes after %T", len(payload)-reader.Offset(), serverMsg.Message)
 	}
+	return serverMsg, nil
+}
 
+// handleServerMessage applies a message from the server to the client cache and runs the callbacks.
+func (db *DBConnection) handleServerMessage(serverMsg *ServerMessage) error {
 	//db.Logger("Received message: %s", serverMsg)
 
 	switch msg := serverMsg.Message.(type) {
@@ -33,69 +39,268 @@ func (db *DBConnection) parseBsantMessage(msg []byte) error {
 
 		db.Logger("Received IdentityToken: %#v", serverMsg.Message)
 
-		db.IsConnected = true
-		db.Identity = msg.Identity
-		if db.Token == "" && msg.Token != "" {
-			db.Token = msg.Token
+		db.mu.Lock()
+		db.isConnected = true
+		db.identity = msg.Identity
+		tokenChanged := db.token != msg.Token && msg.Token != ""
+		if tokenChanged {
+			db.token = msg.Token
+		}
+		db.connectionId = msg.ConnectionId
+		db.mu.Unlock()
+		if tokenChanged && db.TokenStore != nil {
+			if err := db.TokenStore.Save(db.Host, db.NameOrIdentity, msg.Token); err != nil {
+				db.Logger("Error saving token: %v", err)
+			}
+		}
+		if db.reconnecting {
+			if err := db.resubscribe(); err != nil {
+				db.Logger("Error resubscribing after reconnect: %v", err)
+			}
 		}
-		db.ConnectionId = msg.ConnectionId
 		if db.OnConnect != nil {
 			db.OnConnect(db, msg.Identity, msg.Token, msg.ConnectionId)
 		}
 	case *TransactionUpdate:
+		// The waiting reducer call is resolved once the cache has been updated, even if that fails.
+		defer db.resolveReducerCall(msg)
 		db.Logger("Received TransactionUpdate:")
 		db.Logger("  Reducer:\t%s", msg.ReducerCall.String())
 		switch status := msg.Status.Status.(type) {
 		case *UpdateStatusComitted:
 			db.Logger("  Status:\tSuccess")
-			db.handleTableUpdates(status.DatabaseUpdate.Tables)
+			if err := db.applyTransactionUpdates(&EventContext{Conn: db, Event: msg}, status.DatabaseUpdate.Tables); err != nil {
+				return fmt.Errorf("failed to apply TransactionUpdate: %w", err)
+			}
 		case *UpdateStatusFailed:
 			db.Logger("  Status:\tFailed")
 			db.Logger("  Error:\t%s", status.ErrorMessage)
 		}
+	case *TransactionUpdateLight:
+		db.Logger("Received TransactionUpdateLight for request %d", msg.RequestId)
+		if err := db.applyTransactionUpdates(&EventContext{Conn: db, Event: msg}, msg.Update.Tables); err != nil {
+			return fmt.Errorf("failed to apply TransactionUpdateLight: %w", err)
+		}
+	case *OneOffQueryResponse:
+		db.Logger("Received OneOffQueryResponse for message %x", msg.MessageId)
+		db.resolveOneOffQuery(msg)
 	case *InitialSubscription:
 		db.Logger("Received InitialSubscription:")
 		db.Logger("  RequestId: %d", msg.RequestId)
 		db.Logger("  TotalHostExecutionDuration: %s", msg.TotalHostExecutionDuration.String())
-		if msg.DatabaseUpdate != nil && msg.DatabaseUpdate.Tables != nil {
-			db.handleTableUpdates(msg.DatabaseUpdate.Tables)
+		if msg.DatabaseUpdate != nil {
+			if err := db.applyLegacySubscription(&EventContext{Conn: db, Event: msg}, msg.RequestId, msg.DatabaseUpdate.Tables); err != nil {
+				return fmt.Errorf("failed to apply InitialSubscription: %w", err)
+			}
+		}
+	case *SubscribeApplied:
+		db.Logger("Received SubscribeApplied for query %d", msg.QueryId)
+		ctx := &EventContext{Conn: db, Event: msg}
+		if err := db.applySubscriptionUpdates(ctx, msg.RequestId, []*TableUpdate{msg.Rows.TableRows}); err != nil {
+			return fmt.Errorf("failed to apply SubscribeApplied: %w", err)
+		}
+		if handle := db.subscription(msg.QueryId); handle != nil {
+			handle.applied(ctx)
+		}
+	case *SubscribeMultiApplied:
+		db.Logger("Received SubscribeMultiApplied for query %d", msg.QueryId)
+		ctx := &EventContext{Conn: db, Event: msg}
+		if err := db.applySubscriptionUpdates(ctx, msg.RequestId, msg.Update.Tables); err != nil {
+			return fmt.Errorf("failed to apply SubscribeMultiApplied: %w", err)
+		}
+		if handle := db.subscription(msg.QueryId); handle != nil {
+			handle.applied(ctx)
+		}
+	case *UnsubscribeApplied:
+		db.Logger("Received UnsubscribeApplied for query %d", msg.QueryId)
+		ctx := &EventContext{Conn: db, Event: msg}
+		if err := db.applyTableUpdates(ctx, []*TableUpdate{msg.Rows.TableRows}); err != nil {
+			return fmt.Errorf("failed to apply UnsubscribeApplied: %w", err)
+		}
+		if handle := db.subscription(msg.QueryId); handle != nil {
+			handle.ended(ctx)
+		}
+	case *UnsubscribeMultiApplied:
+		db.Logger("Received UnsubscribeMultiApplied for query %d", msg.QueryId)
+		ctx := &EventContext{Conn: db, Event: msg}
+		if err := db.applyTableUpdates(ctx, msg.Update.Tables); err != nil {
+			return fmt.Errorf("failed to apply UnsubscribeMultiApplied: %w", err)
+		}
+		if handle := db.subscription(msg.QueryId); handle != nil {
+			handle.ended(ctx)
+		}
+	case *SubscriptionError:
+		db.Logger("Received SubscriptionError: %s", msg.ErrorMessage)
+		ctx := &EventContext{Conn: db, Event: msg}
+		if db.reconcile != nil {
+			// A replayed subscription that failed will never be applied, so the rebuild of the cache
+			// stops waiting for it.
+			db.reconcile.failed(msg)
+			if err := db.finishReconcile(ctx); err != nil {
+				return fmt.Errorf("failed to apply SubscriptionError: %w", err)
+			}
+		}
+		if msg.QueryId != nil {
+			if handle := db.subscription(*msg.QueryId); handle != nil {
+				handle.failed(ctx, msg)
+			}
+			break
+		}
+		// Without a query ID the error is not about a single subscription, and the server has dropped all of them.
+		for _, handle := range db.openSubscriptions() {
+			handle.failed(ctx, msg)
+		}
+	}
+
+	return nil
+}
+
+// applyTableUpdates applies table updates to the client cache, unless the cache is being rebuilt
+// after a reconnect, in which case they are collected until the rebuild is complete.
+func (db *DBConnection) applyTableUpdates(ctx *EventContext, updates []*TableUpdate) error {
+	if db.reconcile != nil {
+		return db.reconcile.cache.applyUpdates(updates)
+	}
+	return db.handleTableUpdates(ctx, updates)
+}
+
+// applyTransactionUpdates applies the table updates of a transaction, and keeps the rows of the
+// legacy subscription up to date with them.
+func (db *DBConnection) applyTransactionUpdates(ctx *EventContext, updates []*TableUpdate) error {
+	// A deleted row is gone from every subscription that held it. An inserted row cannot be told
+	// apart from the rows of subscription handles, so it is only counted for the legacy subscription
+	// while no handle is subscribed.
+	db.mu.RLock()
+	legacyOnly := len(db.subscriptionQueries) > 0 && len(db.subscriptions) == 0
+	db.mu.RUnlock()
+	legacy := db.legacyRows
+	if db.reconcile != nil {
+		legacy = db.reconcile.legacy
+		// Until the replayed Subscribe is applied, its rows are not part of the cache being rebuilt.
+		if _, pending := db.reconcile.pending[db.reconcile.legacyRequestId]; pending {
+			legacyOnly = false
 		}
 	}
+	err := eachQueryUpdate(updates, func(tableName string, deletes, inserts [][]byte) {
+		for _, row := range deletes {
+			legacy.delete(tableName, row)
+		}
+		if legacyOnly {
+			for _, row := range inserts {
+				legacy.insert(tableName, row)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return db.applyTableUpdates(ctx, updates)
+}
+
+// applySubscriptionUpdates applies the rows of a subscription that was applied by the server.
+func (db *DBConnection) applySubscriptionUpdates(ctx *EventContext, requestId uint32, updates []*TableUpdate) error {
+	if db.reconcile == nil {
+		return db.handleTableUpdates(ctx, updates)
+	}
+	if err := db.reconcile.cache.applyUpdates(updates); err != nil {
+		return err
+	}
+	delete(db.reconcile.pending, requestId)
+	return db.finishReconcile(ctx)
+}
+
+// applyLegacySubscription applies the rows of a legacy Subscribe. They replace the rows of the
+// previous Subscribe, so only the rows that differ between the two are deleted or inserted.
+func (db *DBConnection) applyLegacySubscription(ctx *EventContext, requestId uint32, updates []*TableUpdate) error {
+	if db.reconcile != nil {
+		rows, changes, err := replaceRows(db.reconcile.legacy, updates)
+		if err != nil {
+			return err
+		}
+		for tableName, changes := range changes {
+			for _, row := range changes.deletes {
+				db.reconcile.cache.delete(tableName, row)
+			}
+			for _, row := range changes.inserts {
+				db.reconcile.cache.insert(tableName, row)
+			}
+		}
+		db.reconcile.legacy = rows
+		delete(db.reconcile.pending, requestId)
+		return db.finishReconcile(ctx)
+	}
 
+	rows, changes, err := replaceRows(db.legacyRows, updates)
+	if err != nil {
+		return err
+	}
+	db.legacyRows = rows
+	for _, tableName := range slices.Sorted(maps.Keys(changes)) {
+		if err := db.applyRows(ctx, tableName, changes[tableName].deletes, changes[tableName].inserts); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (db *DBConnection) handleTableUpdates(updates []*TableUpdate) error {
+func (db *DBConnection) handleTableUpdates(ctx *EventContext, updates []*TableUpdate) error {
 	for _, tableUpdate := range updates {
 		if tableUpdate == nil || tableUpdate.NumRows == 0 {
 			continue
 		}
-		if db.TableNameMap[tableUpdate.TableName] == nil {
-			return fmt.Errorf("table %s not found in TableNameMap", tableUpdate.TableName)
-		}
+		// Collect the rows of all query updates, so a row deleted by one query and inserted by
+		// another is seen as a single update.
+		var deletes, inserts [][]byte
 		for _, update := range tableUpdate.Updates {
 			if update == nil {
 				continue
 			}
-			reader := NewBinaryReader(update.Inserts.RowsData)
-			// While reader is not at the end loop through the rows
-			for reader.offset < len(reader.buffer) {
-				err := db.TableNameMap[tableUpdate.TableName].Insert(reader)
-				if err != nil {
-					return fmt.Errorf("error inserting row: %w", err)
-				}
+			rows, err := update.Deletes.Rows()
+			if err != nil {
+				return fmt.Errorf("error reading deleted rows: %w", err)
 			}
+			deletes = append(deletes, rows...)
 
-			reader = NewBinaryReader(update.Deletes.RowsData)
-			// While reader is not at the end loop through the rows
-			for reader.offset < len(reader.buffer) {
-				err := db.TableNameMap[tableUpdate.TableName].Delete(reader)
-				if err != nil {
-					return fmt.Errorf("error deleting row: %w", err)
-				}
+			rows, err = update.Inserts.Rows()
+			if err != nil {
+				return fmt.Errorf("error reading inserted rows: %w", err)
 			}
+			inserts = append(inserts, rows...)
+		}
+		if err := db.applyRows(ctx, tableUpdate.TableName, deletes, inserts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyRows applies deleted and inserted rows to the client cache and passes the rows that leave or
+// enter it on to the table. Rows that are still covered by another subscription, or were already
+// covered, are left alone.
+func (db *DBConnection) applyRows(ctx *EventContext, tableName string, deletes, inserts [][]byte) error {
+	table := db.TableNameMap[tableName]
+	if table == nil {
+		return fmt.Errorf("table %s not found in TableNameMap", tableName)
+	}
+
+	var removed, added [][]byte
+	for _, row := range deletes {
+		if db.cache.delete(tableName, row) {
+			removed = append(removed, row)
 		}
 	}
+	for _, row := range inserts {
+		if db.cache.insert(tableName, row) {
+			added = append(added, row)
+		}
+	}
+	if len(removed) == 0 && len(added) == 0 {
+		return nil
+	}
 
+	if err := table.ApplyUpdate(ctx, removed, added); err != nil {
+		return fmt.Errorf("error applying update to table %s: %w", tableName, err)
+	}
 	return nil
 }
@@ -0,0 +1,119 @@
+package spacetimedb
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ModuleDef is the schema of a module (RawModuleDefV9), as returned by the
+// /v1/database/{name}/schema endpoint and `spacetime describe --json`. Only the parts needed by
+// clients are decoded.
+type ModuleDef struct {
+	Typespace Typespace    `json:"typespace"`
+	Tables    []TableDef   `json:"tables"`
+	Reducers  []ReducerDef `json:"reducers"`
+	Types     []TypeDef    `json:"types"`
+}
+
+type TableDef struct {
+	Name string
+	// ProductTypeRef is the index of the row type in the Typespace.
+	ProductTypeRef uint32
+	// PrimaryKey holds the index of the primary key column, or is empty if the table has none.
+	PrimaryKey []uint16
+	// TableType is "User" or "System".
+	TableType string
+	// TableAccess is "Public" or "Private".
+	TableAccess string
+}
+
+type ReducerDef struct {
+	Name   string
+	Params ProductType
+	// Lifecycle is "Init", "OnConnect" or "OnDisconnect" for reducers called by the host, and empty
+	// for reducers called by clients.
+	Lifecycle string
+}
+
+// TypeDef gives a name to a type in the Typespace.
+type TypeDef struct {
+	Name ScopedName `json:"name"`
+	Ty   uint32     `json:"ty"`
+}
+
+type ScopedName struct {
+	Scope []string `json:"scope"`
+	Name  string   `json:"name"`
+}
+
+// ParseModuleDef decodes the JSON schema of a module. Both a bare RawModuleDefV9 and one wrapped
+// as {"V9": ...} are accepted.
+func ParseModuleDef(data []byte) (*ModuleDef, error) {
+	var wrapped struct {
+		V9 *ModuleDef `json:"V9"`
+	}
+	if err := json.Unmarshal(data, &wrapped); err != nil {
+		return nil, fmt.Errorf("failed to decode module def: %w", err)
+	}
+	if wrapped.V9 != nil {
+		return wrapped.V9, nil
+	}
+
+	def := &ModuleDef{}
+	if err := json.Unmarshal(data, def); err != nil {
+		return nil, fmt.Errorf("failed to decode module def: %w", err)
+	}
+	return def, nil
+}
+
+// jsonSumTag decodes a sum value without a payload, such as {"Public": []}, to the name of its variant.
+type jsonSumTag string
+
+func (s *jsonSumTag) UnmarshalJSON(data []byte) error {
+	name, _, err := unmarshalJSONSum(data)
+	if err != nil {
+		return err
+	}
+	*s = jsonSumTag(name)
+	return nil
+}
+
+func (t *TableDef) UnmarshalJSON(data []byte) error {
+	var table struct {
+		Name           string     `json:"name"`
+		ProductTypeRef uint32     `json:"product_type_ref"`
+		PrimaryKey     []uint16   `json:"primary_key"`
+		TableType      jsonSumTag `json:"table_type"`
+		TableAccess    jsonSumTag `json:"table_access"`
+	}
+	if err := json.Unmarshal(data, &table); err != nil {
+		return err
+	}
+	*t = TableDef{
+		Name:           table.Name,
+		ProductTypeRef: table.ProductTypeRef,
+		PrimaryKey:     table.PrimaryKey,
+		TableType:      string(table.TableType),
+		TableAccess:    string(table.TableAccess),
+	}
+	return nil
+}
+
+func (r *ReducerDef) UnmarshalJSON(data []byte) error {
+	var reducer struct {
+		Name      string          `json:"name"`
+		Params    ProductType     `json:"params"`
+		Lifecycle json.RawMessage `json:"lifecycle"`
+	}
+	if err := json.Unmarshal(data, &reducer); err != nil {
+		return err
+	}
+	var lifecycle jsonSumTag
+	if len(reducer.Lifecycle) > 0 {
+		if err := unmarshalJSONOption(reducer.Lifecycle, &lifecycle); err != nil {
+			return fmt.Errorf("failed to decode lifecycle of reducer %s: %w", reducer.Name, err)
+		}
+	}
+	*r = ReducerDef{Name: reducer.Name, Params: reducer.Params, Lifecycle: string(lifecycle)}
+	return nil
+}
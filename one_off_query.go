@@ -0,0 +1,91 @@
+package spacetimedb
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+)
+
+// OneOffQueryResult holds the rows returned by OneOffQuery.
+type OneOffQueryResult struct {
+	// Tables has a row list for each table the query returned rows from. Decode the rows with
+	// DecodeRows of the table in the bindings, or with DecodeValue.
+	Tables                     []*OneOffTable
+	TotalHostExecutionDuration *TimeDuration
+}
+
+// Table returns the rows of the table with the given name, or nil if the result has none.
+func (r *OneOffQueryResult) Table(name string) *OneOffTable {
+	for _, table := range r.Tables {
+		if table.TableName == name {
+			return table
+		}
+	}
+	return nil
+}
+
+type pendingOneOffQuery struct {
+	result chan *OneOffQueryResponse
+	err    chan error
+}
+
+// OneOffQuery runs an SQL query once, without subscribing to it, and waits for its rows. The rows
+// are not added to the client cache. If the server rejects the query, its error message is returned
+// as the error.
+func (conn *DBConnection) OneOffQuery(ctx context.Context, sql string) (*OneOffQueryResult, error) {
+	messageId := binary.LittleEndian.AppendUint32(nil, conn.newRequestId())
+	query := &pendingOneOffQuery{
+		result: make(chan *OneOffQueryResponse, 1),
+		err:    make(chan error, 1),
+	}
+
+	conn.pendingMu.Lock()
+	conn.pendingQueries[string(messageId)] = query
+	conn.pendingMu.Unlock()
+	defer func() {
+		conn.pendingMu.Lock()
+		delete(conn.pendingQueries, string(messageId))
+		conn.pendingMu.Unlock()
+	}()
+
+	if err := conn.sendClientMessage(&OneOffQuery{MessageId: messageId, QueryString: sql}); err != nil {
+		return nil, err
+	}
+
+	select {
+	case response := <-query.result:
+		if response.Error != nil {
+			return nil, fmt.Errorf("one-off query failed: %s", *response.Error)
+		}
+		return &OneOffQueryResult{
+			Tables:                     response.Tables,
+			TotalHostExecutionDuration: response.TotalHostExecutionDuration,
+		}, nil
+	case err := <-query.err:
+		return nil, err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// resolveOneOffQuery completes the pending query that msg answers, if any.
+func (conn *DBConnection) resolveOneOffQuery(msg *OneOffQueryResponse) {
+	conn.pendingMu.Lock()
+	query := conn.pendingQueries[string(msg.MessageId)]
+	delete(conn.pendingQueries, string(msg.MessageId))
+	conn.pendingMu.Unlock()
+	if query == nil {
+		return
+	}
+	query.result <- msg
+}
+
+// failPendingOneOffQueries makes every query that is still waiting for its rows return err.
+func (conn *DBConnection) failPendingOneOffQueries(err error) {
+	conn.pendingMu.Lock()
+	defer conn.pendingMu.Unlock()
+	for messageId, query := range conn.pendingQueries {
+		query.err <- err
+		delete(conn.pendingQueries, messageId)
+	}
+}
@@ -0,0 +1,209 @@
+package spacetimedb
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// ReconnectPolicy configures how a DBConnection reconnects after the websocket is lost.
+// The delay before attempt n (starting at 0) is InitialDelay * Multiplier^n, capped at MaxDelay,
+// with up to Jitter (a fraction between 0 and 1) of it randomly subtracted.
+type ReconnectPolicy struct {
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Multiplier   float64
+	Jitter       float64
+	// MaxAttempts is the number of reconnect attempts before giving up. Zero means retry forever.
+	MaxAttempts int
+}
+
+// DefaultReconnectPolicy returns a policy that retries forever, starting at 500ms and backing off to 30s.
+func DefaultReconnectPolicy() ReconnectPolicy {
+	return ReconnectPolicy{
+		InitialDelay: 500 * time.Millisecond,
+		MaxDelay:     30 * time.Second,
+		Multiplier:   2,
+		Jitter:       0.2,
+	}
+}
+
+// Delay returns how long to wait before the given reconnect attempt.
+func (p *ReconnectPolicy) Delay(attempt int) time.Duration {
+	// float64(math.MaxInt64) rounds up to 2^63, so delays at or above it do not fit in a Duration.
+	const maxDuration = float64(math.MaxInt64)
+	delay := float64(p.InitialDelay)
+	if p.Multiplier > 1 && delay > 0 {
+		// The attempt is capped where the delay reaches the largest Duration, so the power does not
+		// overflow to +Inf.
+		maxAttempt := math.Ceil(math.Log(maxDuration/delay) / math.Log(p.Multiplier))
+		delay = math.Min(delay*math.Pow(p.Multiplier, math.Min(float64(attempt), maxAttempt)), maxDuration)
+	}
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		delay -= delay * math.Min(p.Jitter, 1) * rand.Float64()
+	}
+	if delay >= maxDuration {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(delay)
+}
+
+// reconnect redials the database until it succeeds, the policy gives up or the connection is closed.
+// The saved token is sent along so the connection keeps its identity, and the active subscriptions
+// are replayed once the server has sent the new IdentityToken.
+func (db *DBConnection) reconnect() {
+	policy := db.ReconnectPolicy
+	var lastErr error
+	for attempt := 0; policy.MaxAttempts == 0 || attempt < policy.MaxAttempts; attempt++ {
+		delay := policy.Delay(attempt)
+		db.Logger("Reconnecting in %s (attempt %d)", delay, attempt+1)
+		select {
+		case <-db.ctx.Done():
+			db.terminate()
+			return
+		case <-time.After(delay):
+		}
+
+		c, err := db.dial(db.ctx, db.Token())
+		if err != nil {
+			db.Logger("Reconnect attempt %d failed: %v", attempt+1, err)
+			lastErr = err
+			continue
+		}
+
+		db.mu.Lock()
+		db.ws = c
+		db.handshakeState = handshakeNone
+		db.mu.Unlock()
+		db.reconnecting = true
+		db.Logger("Reconnected to websocket at %s", db.Host)
+		go db.readLoop(c)
+		return
+	}
+	db.connectFailed(fmt.Errorf("giving up reconnecting after %d attempts: %w", policy.MaxAttempts, lastErr))
+}
+
+// reconcileState collects the rows of the subscriptions replayed after a reconnect.
+type reconcileState struct {
+	// pending maps the request IDs of the replayed subscriptions that have not been applied yet to
+	// their handles, which are nil for the queries of Subscribe.
+	pending map[uint32]*SubscriptionHandle
+	// cache holds the rows of the subscriptions applied since reconnecting, kept up to date by
+	// transaction updates that arrive while waiting for the rest.
+	cache *clientCache
+	// legacy holds the rows of the replayed Subscribe within cache, and legacyRequestId is the
+	// request ID it was replayed with.
+	legacy          *clientCache
+	legacyRequestId uint32
+	// unsubscribed holds the subscriptions that were being unsubscribed when the connection was
+	// lost. They are not replayed, and end once the cache has been rebuilt without their rows.
+	unsubscribed []*SubscriptionHandle
+}
+
+// failed stops waiting for the replayed subscriptions that msg reports as failed. An error without
+// a query ID means the server has dropped all of them.
+func (r *reconcileState) failed(msg *SubscriptionError) {
+	if msg.QueryId == nil {
+		clear(r.pending)
+		return
+	}
+	for requestId, handle := range r.pending {
+		if (msg.RequestId != nil && requestId == *msg.RequestId) || (handle != nil && handle.queryId == *msg.QueryId) {
+			delete(r.pending, requestId)
+		}
+	}
+}
+
+// finishReconcile replaces the client cache with the rows of the replayed subscriptions once none
+// of them is pending.
+func (db *DBConnection) finishReconcile(ctx *EventContext) error {
+	if db.reconcile == nil || len(db.reconcile.pending) > 0 {
+		return nil
+	}
+	fresh := db.reconcile.cache
+	unsubscribed := db.reconcile.unsubscribed
+	db.legacyRows = db.reconcile.legacy
+	db.reconcile = nil
+	if err := db.reconcileTableUpdates(ctx, fresh); err != nil {
+		return err
+	}
+	for _, handle := range unsubscribed {
+		handle.ended(ctx)
+	}
+	return nil
+}
+
+// resubscribe replays the active subscriptions on a new connection. The client cache is rebuilt
+// from their rows once all of them have been applied.
+func (db *DBConnection) resubscribe() error {
+	db.reconnecting = false
+	db.reconcile = &reconcileState{
+		pending: make(map[uint32]*SubscriptionHandle),
+		cache:   newClientCache(),
+		legacy:  newClientCache(),
+	}
+
+	db.mu.RLock()
+	queries := db.subscriptionQueries
+	db.mu.RUnlock()
+	if len(queries) > 0 {
+		requestId := db.newRequestId()
+		db.reconcile.pending[requestId] = nil
+		db.reconcile.legacyRequestId = requestId
+		err := db.sendClientMessage(&Subscribe{
+			QueryStrings: queries,
+			RequestId:    requestId,
+		})
+		if err != nil {
+			db.reconcile = nil
+			return err
+		}
+	}
+	for _, handle := range db.openSubscriptions() {
+		if handle.unsubscribing() {
+			db.reconcile.unsubscribed = append(db.reconcile.unsubscribed, handle)
+			continue
+		}
+		requestId := db.newRequestId()
+		db.reconcile.pending[requestId] = handle
+		if err := db.sendClientMessage(handle.subscribeMessage(requestId)); err != nil {
+			db.reconcile = nil
+			return err
+		}
+	}
+
+	return db.finishReconcile(&EventContext{Conn: db})
+}
+
+// reconcileTableUpdates replaces the client cache with the rows of the subscriptions replayed after
+// a reconnect. Cached rows that are missing from them were deleted while the client was offline,
+// and only rows that were not cached before are inserted.
+func (db *DBConnection) reconcileTableUpdates(ctx *EventContext, fresh *clientCache) error {
+	for tableName, table := range db.TableNameMap {
+		var removed, added [][]byte
+		for _, row := range db.cache.rows(tableName) {
+			if fresh.count(tableName, row) == 0 {
+				removed = append(removed, row)
+			}
+		}
+		for _, row := range fresh.rows(tableName) {
+			if db.cache.count(tableName, row) == 0 {
+				added = append(added, row)
+			}
+		}
+		db.cache.tables[tableName] = fresh.tables[tableName]
+		if len(removed) == 0 && len(added) == 0 {
+			continue
+		}
+
+		if err := table.ApplyUpdate(ctx, removed, added); err != nil {
+			return fmt.Errorf("error applying update to table %s: %w", tableName, err)
+		}
+	}
+
+	return nil
+}
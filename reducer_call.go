@@ -0,0 +1,122 @@
+package spacetimedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNoSuccessNotify is returned by CallReducerAsync once a call made with NoSuccessNotify has been
+// sent. The server does not answer a successful call, so there is no result to wait for.
+var ErrNoSuccessNotify = errors.New("reducer called with NoSuccessNotify, its result is not reported")
+
+// ReducerResult is the outcome of a reducer call made with CallReducerAsync.
+type ReducerResult struct {
+	// Status is one of *UpdateStatusComitted, *UpdateStatusFailed or *UpdateStatusOutOfEnergy.
+	Status                     any
+	Timestamp                  *Timestamp
+	EnergyQuantaUsed           *EnergyQuanta
+	TotalHostExecutionDuration *TimeDuration
+	TransactionUpdate          *TransactionUpdate
+}
+
+// Committed reports whether the reducer ran successfully and its changes were committed.
+func (r *ReducerResult) Committed() bool {
+	_, ok := r.Status.(*UpdateStatusComitted)
+	return ok
+}
+
+// Err returns an error describing why the reducer did not commit, or nil if it did.
+func (r *ReducerResult) Err() error {
+	switch status := r.Status.(type) {
+	case *UpdateStatusComitted:
+		return nil
+	case *UpdateStatusFailed:
+		return fmt.Errorf("reducer failed: %s", status.ErrorMessage)
+	case *UpdateStatusOutOfEnergy:
+		return fmt.Errorf("reducer ran out of energy")
+	default:
+		return fmt.Errorf("unknown reducer status: %T", r.Status)
+	}
+}
+
+type pendingReducerCall struct {
+	result chan *ReducerResult
+	err    chan error
+}
+
+// CallReducerAsync calls a reducer with a new request ID and waits for the TransactionUpdate
+// answering it. A reducer that fails is not an error; check the result with Committed or Err.
+// An error is returned if the call could not be sent, the connection is lost or ctx is done.
+//
+// The call is made with DefaultReducerFlags. If they include NoSuccessNotify, the server would not
+// answer a successful call, so ErrNoSuccessNotify is returned as soon as the call has been sent.
+func (conn *DBConnection) CallReducerAsync(ctx context.Context, reducer string, args []byte) (*ReducerResult, error) {
+	requestId := conn.newRequestId()
+	if conn.DefaultReducerFlags&NoSuccessNotify != 0 {
+		if err := conn.CallReducer(reducer, args, requestId, conn.DefaultReducerFlags); err != nil {
+			return nil, err
+		}
+		return nil, ErrNoSuccessNotify
+	}
+
+	call := &pendingReducerCall{
+		result: make(chan *ReducerResult, 1),
+		err:    make(chan error, 1),
+	}
+
+	conn.pendingMu.Lock()
+	conn.pendingReducerCalls[requestId] = call
+	conn.pendingMu.Unlock()
+	defer func() {
+		conn.pendingMu.Lock()
+		delete(conn.pendingReducerCalls, requestId)
+		conn.pendingMu.Unlock()
+	}()
+
+	if err := conn.CallReducer(reducer, args, requestId, conn.DefaultReducerFlags); err != nil {
+		return nil, err
+	}
+
+	select {
+	case result := <-call.result:
+		return result, nil
+	case err := <-call.err:
+		return nil, err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// resolveReducerCall completes the pending reducer call that msg answers, if any.
+func (conn *DBConnection) resolveReducerCall(msg *TransactionUpdate) {
+	if msg.ReducerCall == nil || msg.CallerConnectionId != conn.ConnectionId() {
+		return
+	}
+
+	conn.pendingMu.Lock()
+	call := conn.pendingReducerCalls[msg.ReducerCall.RequestID]
+	delete(conn.pendingReducerCalls, msg.ReducerCall.RequestID)
+	conn.pendingMu.Unlock()
+	if call == nil {
+		return
+	}
+
+	call.result <- &ReducerResult{
+		Status:                     msg.Status.Status,
+		Timestamp:                  msg.Timestamp,
+		EnergyQuantaUsed:           msg.EnergyQuantaUsed,
+		TotalHostExecutionDuration: msg.TotalHostExecutionDuration,
+		TransactionUpdate:          msg,
+	}
+}
+
+// failPendingReducerCalls makes every reducer call that is still waiting for a result return err.
+func (conn *DBConnection) failPendingReducerCalls(err error) {
+	conn.pendingMu.Lock()
+	defer conn.pendingMu.Unlock()
+	for requestId, call := range conn.pendingReducerCalls {
+		call.err <- err
+		delete(conn.pendingReducerCalls, requestId)
+	}
+}
@@ -0,0 +1,304 @@
+package spacetimedb
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// EncodeJSON converts a value of type ty from the SATS JSON format, used by the JSON protocol and
+// the HTTP API, to BSATN. ty must not contain Ref types; use Typespace.EncodeJSON for the types of a
+// module.
+func EncodeJSON(writer *BinaryWriter, data []byte, ty AlgebraicType) error {
+	return (*Typespace)(nil).EncodeJSON(writer, data, ty)
+}
+
+// EncodeJSON converts a value of type ty from the SATS JSON format to BSATN, resolving Ref types
+// in the typespace.
+//
+// Products are objects keyed by element name or arrays in element order, sums are objects with the
+// variant name as the only key, byte arrays are hex strings and 128 and 256 bit integers are
+// numbers or strings in decimal or 0x prefixed hex.
+func (ts *Typespace) EncodeJSON(writer *BinaryWriter, data []byte, ty AlgebraicType) error {
+	if err := ts.encodeJSON(writer, bytes.TrimSpace(data), ty); err != nil {
+		return fmt.Errorf("EncodeJSON: %w", err)
+	}
+	return nil
+}
+
+func (ts *Typespace) encodeJSON(writer *BinaryWriter, data []byte, ty AlgebraicType) error {
+	switch ty.Kind {
+	case AlgebraicTypeRef:
+		resolved, err := ts.Resolve(ty)
+		if err != nil {
+			return err
+		}
+		return ts.encodeJSON(writer, data, resolved)
+	case AlgebraicTypeBool:
+		var value bool
+		if err := json.Unmarshal(data, &value); err != nil {
+			return fmt.Errorf("invalid bool %s", data)
+		}
+		writer.WriteBool(value)
+	case AlgebraicTypeI8, AlgebraicTypeI16, AlgebraicTypeI32, AlgebraicTypeI64:
+		bits := map[AlgebraicTypeKind]int{AlgebraicTypeI8: 8, AlgebraicTypeI16: 16, AlgebraicTypeI32: 32, AlgebraicTypeI64: 64}[ty.Kind]
+		value, err := strconv.ParseInt(jsonNumberText(data), 0, bits)
+		if err != nil {
+			return fmt.Errorf("invalid %s %s", ty.Kind, data)
+		}
+		switch ty.Kind {
+		case AlgebraicTypeI8:
+			writer.WriteI8(int8(value))
+		case AlgebraicTypeI16:
+			writer.WriteI16(int16(value))
+		case AlgebraicTypeI32:
+			writer.WriteI32(int32(value))
+		default:
+			writer.WriteI64(value)
+		}
+	case AlgebraicTypeU8, AlgebraicTypeU16, AlgebraicTypeU32, AlgebraicTypeU64:
+		bits := map[AlgebraicTypeKind]int{AlgebraicTypeU8: 8, AlgebraicTypeU16: 16, AlgebraicTypeU32: 32, AlgebraicTypeU64: 64}[ty.Kind]
+		value, err := strconv.ParseUint(jsonNumberText(data), 0, bits)
+		if err != nil {
+			return fmt.Errorf("invalid %s %s", ty.Kind, data)
+		}
+		switch ty.Kind {
+		case AlgebraicTypeU8:
+			writer.WriteU8(uint8(value))
+		case AlgebraicTypeU16:
+			writer.WriteU16(uint16(value))
+		case AlgebraicTypeU32:
+			writer.WriteU32(uint32(value))
+		default:
+			writer.WriteU64(value)
+		}
+	case AlgebraicTypeI128, AlgebraicTypeU128, AlgebraicTypeI256, AlgebraicTypeU256:
+		value, err := parseJSONBigInt(data)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", ty.Kind, err)
+		}
+		switch ty.Kind {
+		case AlgebraicTypeI128:
+			writer.WriteI128(value)
+		case AlgebraicTypeU128:
+			writer.WriteU128(value)
+		case AlgebraicTypeI256:
+			writer.WriteI256(value)
+		default:
+			writer.WriteU256(value)
+		}
+	case AlgebraicTypeF32, AlgebraicTypeF64:
+		bits := 64
+		if ty.Kind == AlgebraicTypeF32 {
+			bits = 32
+		}
+		value, err := strconv.ParseFloat(jsonNumberText(data), bits)
+		if err != nil {
+			return fmt.Errorf("invalid %s %s", ty.Kind, data)
+		}
+		if bits == 32 {
+			writer.WriteF32(float32(value))
+		} else {
+			writer.WriteF64(value)
+		}
+	case AlgebraicTypeString:
+		var value string
+		if err := json.Unmarshal(data, &value); err != nil {
+			return fmt.Errorf("invalid string %s", data)
+		}
+		writer.WriteString(value)
+	case AlgebraicTypeArray:
+		if ty.Elem == nil {
+			return fmt.Errorf("array type without element type")
+		}
+		if ty.Elem.Kind == AlgebraicTypeU8 && len(data) > 0 && data[0] == '"' {
+			value, err := parseJSONBytes(data)
+			if err != nil {
+				return err
+			}
+			writer.WriteUInt8Array(value)
+			return nil
+		}
+		var elements []json.RawMessage
+		if err := json.Unmarshal(data, &elements); err != nil {
+			return fmt.Errorf("invalid array %s", data)
+		}
+		writer.WriteU32(uint32(len(elements)))
+		for _, element := range elements {
+			if err := ts.encodeJSON(writer, element, *ty.Elem); err != nil {
+				return err
+			}
+		}
+	case AlgebraicTypeMap:
+		if ty.Map == nil {
+			return fmt.Errorf("map type without key and value types")
+		}
+		return ts.encodeJSONMap(writer, data, ty.Map)
+	case AlgebraicTypeProduct:
+		return ts.encodeJSONProduct(writer, data, ty.Product)
+	case AlgebraicTypeSum:
+		if ty.Sum == nil {
+			return fmt.Errorf("sum type without variants")
+		}
+		return ts.encodeJSONSum(writer, data, ty.Sum)
+	default:
+		return fmt.Errorf("invalid type kind %s", ty.Kind)
+	}
+	return nil
+}
+
+func (ts *Typespace) encodeJSONProduct(writer *BinaryWriter, data []byte, product *ProductType) error {
+	var elements []ProductTypeElement
+	if product != nil {
+		elements = product.Elements
+	}
+	if len(data) > 0 && data[0] == '[' {
+		var values []json.RawMessage
+		if err := json.Unmarshal(data, &values); err != nil {
+			return fmt.Errorf("invalid product %s", data)
+		}
+		if len(values) != len(elements) {
+			return fmt.Errorf("product has %d elements, got %d values", len(elements), len(values))
+		}
+		for i, element := range elements {
+			if err := ts.encodeJSON(writer, values[i], element.Type); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+		return nil
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("invalid product %s", data)
+	}
+	for i, element := range elements {
+		name := element.Name
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		value, ok := values[name]
+		if !ok {
+			return fmt.Errorf("product is missing element %s", name)
+		}
+		if err := ts.encodeJSON(writer, value, element.Type); err != nil {
+			return fmt.Errorf("element %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (ts *Typespace) encodeJSONSum(writer *BinaryWriter, data []byte, sum *SumType) error {
+	name, value, err := unmarshalJSONSum(data)
+	if err != nil {
+		return err
+	}
+	for tag, variant := range sum.Variants {
+		if variant.Name == name || (variant.Name == "" && strconv.Itoa(tag) == name) {
+			writer.WriteU8(uint8(tag))
+			if err := ts.encodeJSON(writer, value, variant.Type); err != nil {
+				return fmt.Errorf("variant %s: %w", name, err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("sum has no variant %s", name)
+}
+
+// encodeJSONMap reads a map from an object, or from an array of [key, value] pairs if the keys are
+// not strings.
+func (ts *Typespace) encodeJSONMap(writer *BinaryWriter, data []byte, mapType *MapType) error {
+	if len(data) > 0 && data[0] == '[' {
+		var entries [][2]json.RawMessage
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return fmt.Errorf("invalid map %s", data)
+		}
+		writer.WriteU32(uint32(len(entries)))
+		for _, entry := range entries {
+			if err := ts.encodeJSON(writer, entry[0], mapType.Key); err != nil {
+				return err
+			}
+			if err := ts.encodeJSON(writer, entry[1], mapType.Value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	keyType, err := ts.Resolve(mapType.Key)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return fmt.Errorf("invalid map %s", data)
+	}
+	entries := NewBinaryWriter()
+	count := uint32(0)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("invalid map %s", data)
+		}
+		// Object keys are strings, so keys of other types hold their JSON inside the string.
+		key := []byte(token.(string))
+		if keyType.Kind == AlgebraicTypeString {
+			key, _ = json.Marshal(token)
+		}
+		if err := ts.encodeJSON(entries, key, keyType); err != nil {
+			return err
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return fmt.Errorf("invalid map %s", data)
+		}
+		if err := ts.encodeJSON(entries, value, mapType.Value); err != nil {
+			return err
+		}
+		count++
+	}
+	writer.WriteU32(count)
+	writer.WriteBytes(entries.GetBuffer())
+	return nil
+}
+
+// jsonNumberText returns the text of a JSON number, which may also be sent as a string.
+func jsonNumberText(data []byte) string {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		return text
+	}
+	return string(data)
+}
+
+// parseJSONBigInt parses a JSON number or a string holding a decimal or 0x prefixed hex number.
+func parseJSONBigInt(data []byte) (*big.Int, error) {
+	value, ok := new(big.Int).SetString(jsonNumberText(data), 0)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer %s", data)
+	}
+	return value, nil
+}
+
+// parseJSONBytes parses a hex string, with or without a 0x prefix, or an array of numbers.
+func parseJSONBytes(data []byte) ([]byte, error) {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		var value []uint8
+		if err := json.Unmarshal(data, &value); err != nil {
+			return nil, fmt.Errorf("invalid bytes %s", data)
+		}
+		return value, nil
+	}
+	if len(text) >= 2 && text[0] == '0' && (text[1] == 'x' || text[1] == 'X') {
+		text = text[2:]
+	}
+	value, err := hex.DecodeString(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bytes %s: %w", data, err)
+	}
+	return value, nil
+}
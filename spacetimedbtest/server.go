@@ -0,0 +1,324 @@
+// Package spacetimedbtest runs an in-process SpacetimeDB server for testing code built on
+// spacetimedb.DBConnection without a SpacetimeDB host.
+//
+// The server accepts websocket connections speaking the v1.bsatn.spacetimedb subprotocol, sends
+// each of them an IdentityToken and records the messages they send. Tests push table updates to
+// the connected clients and assert on the reducer calls and subscriptions they made:
+//
+//	server := spacetimedbtest.NewServer(t)
+//	conn := server.Connect(spacetimedb.WithTableNameMap(module_bindings.Tables))
+//	server.SendInitialSubscription(spacetimedbtest.Insert("user", alice))
+//	module_bindings.SendMessage(conn, "hello")
+//	call := server.WaitForReducerCall("send_message")
+package spacetimedbtest
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+	"github.com/gorilla/websocket"
+)
+
+// Subprotocol is the websocket subprotocol spoken by the server.
+const Subprotocol = "v1.bsatn.spacetimedb"
+
+// DefaultToken is the token sent to clients unless WithIdentityToken sets another.
+const DefaultToken = "spacetimedbtest-token"
+
+// Server is a fake SpacetimeDB host. It is safe for concurrent use.
+type Server struct {
+	tb       testing.TB
+	server   *httptest.Server
+	upgrader websocket.Upgrader
+
+	// Identity, Token and ConnectionId are sent in the IdentityToken of every connection.
+	Identity     spacetimedb.Identity
+	Token        string
+	ConnectionId spacetimedb.ConnectionId
+	// Timeout limits how long Connect and the Wait methods wait.
+	Timeout time.Duration
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	conns   []*websocket.Conn
+	// messages holds the decoded client messages in the order they were received.
+	messages []any
+	// received is closed and replaced whenever a message is recorded.
+	received chan struct{}
+}
+
+type Option func(*Server)
+
+func WithIdentityToken(identity spacetimedb.Identity, token string, connectionId spacetimedb.ConnectionId) Option {
+	return func(s *Server) {
+		s.Identity = identity
+		s.Token = token
+		s.ConnectionId = connectionId
+	}
+}
+func WithTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.Timeout = timeout
+	}
+}
+
+// NewServer starts a server that is closed when the test finishes. Without WithIdentityToken it
+// sends a random identity and connection id with DefaultToken.
+func NewServer(tb testing.TB, opts ...Option) *Server {
+	tb.Helper()
+	s := &Server{
+		tb:       tb,
+		upgrader: websocket.Upgrader{Subprotocols: []string{Subprotocol}},
+		Token:    DefaultToken,
+		Timeout:  5 * time.Second,
+		received: make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.Identity.IsZero() {
+		if _, err := rand.Read(s.Identity[:]); err != nil {
+			tb.Fatalf("spacetimedbtest: failed to create identity: %v", err)
+		}
+	}
+	if s.ConnectionId.IsZero() {
+		connectionId, err := spacetimedb.RandomConnectionId()
+		if err != nil {
+			tb.Fatalf("spacetimedbtest: failed to create connection id: %v", err)
+		}
+		s.ConnectionId = connectionId
+	}
+
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveWebsocket))
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// URL returns the ws:// address of the server, for spacetimedb.WithHost.
+func (s *Server) URL() string {
+	return "ws" + strings.TrimPrefix(s.server.URL, "http")
+}
+
+// Options returns the options connecting a DBConnection to the server, with logging disabled.
+func (s *Server) Options() []spacetimedb.DBConnectionOption {
+	return []spacetimedb.DBConnectionOption{
+		spacetimedb.WithHost(s.URL()),
+		spacetimedb.WithNameOrIdentity("test"),
+		spacetimedb.WithLogger(func(format string, args ...interface{}) {}),
+	}
+}
+
+// Connect creates a DBConnection with Options followed by opts and waits until it has received
+// its identity. The connection is closed when the test finishes.
+func (s *Server) Connect(opts ...spacetimedb.DBConnectionOption) *spacetimedb.DBConnection {
+	s.tb.Helper()
+	conn := spacetimedb.NewDBConnection(append(s.Options(), opts...)...)
+	ctx, cancel := context.WithTimeout(context.Background(), s.Timeout)
+	defer cancel()
+	if err := conn.ConnectContext(ctx); err != nil {
+		s.tb.Fatalf("spacetimedbtest: failed to connect: %v", err)
+	}
+	s.tb.Cleanup(conn.Close)
+	return conn
+}
+
+// Close closes all connections and shuts the server down.
+func (s *Server) Close() {
+	s.mu.Lock()
+	conns := s.conns
+	s.conns = nil
+	s.mu.Unlock()
+	for _, ws := range conns {
+		ws.Close()
+	}
+	s.server.Close()
+}
+
+// CloseConnections closes the connections to the server, which stays up for reconnecting.
+func (s *Server) CloseConnections() {
+	s.mu.Lock()
+	conns := s.conns
+	s.conns = nil
+	s.mu.Unlock()
+	for _, ws := range conns {
+		ws.Close()
+	}
+}
+
+func (s *Server) serveWebsocket(w http.ResponseWriter, r *http.Request) {
+	ws, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		s.tb.Errorf("spacetimedbtest: failed to upgrade connection: %v", err)
+		return
+	}
+	// The connection is registered first, so messages can be sent as soon as the client has its identity.
+	s.mu.Lock()
+	s.conns = append(s.conns, ws)
+	s.mu.Unlock()
+	go s.readLoop(ws)
+
+	identityToken := &spacetimedb.IdentityToken{Identity: s.Identity, Token: s.Token, ConnectionId: s.ConnectionId}
+	if err := s.write(ws, identityToken); err != nil {
+		s.tb.Errorf("spacetimedbtest: failed to send IdentityToken: %v", err)
+		ws.Close()
+	}
+}
+
+func (s *Server) readLoop(ws *websocket.Conn) {
+	defer s.removeConn(ws)
+	for {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		var clientMsg spacetimedb.ClientMessage
+		if err := clientMsg.Deserialize(spacetimedb.NewBinaryReader(data)); err != nil {
+			s.tb.Errorf("spacetimedbtest: failed to decode client message: %v", err)
+			continue
+		}
+		s.mu.Lock()
+		s.messages = append(s.messages, clientMsg.Message)
+		close(s.received)
+		s.received = make(chan struct{})
+		s.mu.Unlock()
+	}
+}
+
+func (s *Server) removeConn(ws *websocket.Conn) {
+	ws.Close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, conn := range s.conns {
+		if conn == ws {
+			s.conns = append(s.conns[:i], s.conns[i+1:]...)
+			return
+		}
+	}
+}
+
+// write sends message as an uncompressed ServerMessage.
+func (s *Server) write(ws *websocket.Conn, message any) error {
+	writer := spacetimedb.NewBinaryWriter()
+	writer.WriteU8(spacetimedb.CompressionTypeNone)
+	if err := (&spacetimedb.ServerMessage{Message: message}).Serialize(writer); err != nil {
+		return err
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return ws.WriteMessage(websocket.BinaryMessage, writer.GetBuffer())
+}
+
+// Send sends a server message, such as a *spacetimedb.SubscriptionError, to every open connection.
+func (s *Server) Send(message any) error {
+	s.mu.Lock()
+	conns := append([]*websocket.Conn(nil), s.conns...)
+	s.mu.Unlock()
+	if len(conns) == 0 {
+		return fmt.Errorf("spacetimedbtest: no open connections")
+	}
+	for _, ws := range conns {
+		if err := s.write(ws, message); err != nil {
+			return fmt.Errorf("spacetimedbtest: failed to send %T: %w", message, err)
+		}
+	}
+	return nil
+}
+
+// Messages returns the messages received from clients, such as *spacetimedb.CallReducer and
+// *spacetimedb.SubscribeSingle, in the order they were received.
+func (s *Server) Messages() []any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]any(nil), s.messages...)
+}
+
+// ReducerCalls returns the reducer calls received from clients.
+func (s *Server) ReducerCalls() []*spacetimedb.CallReducer {
+	var calls []*spacetimedb.CallReducer
+	for _, message := range s.Messages() {
+		if call, ok := message.(*spacetimedb.CallReducer); ok {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// SubscribedQueries returns the queries of the Subscribe, SubscribeSingle and SubscribeMulti
+// messages received from clients.
+func (s *Server) SubscribedQueries() []string {
+	var queries []string
+	for _, message := range s.Messages() {
+		queries = append(queries, subscribeQueries(message)...)
+	}
+	return queries
+}
+
+func subscribeQueries(message any) []string {
+	switch msg := message.(type) {
+	case *spacetimedb.Subscribe:
+		return msg.QueryStrings
+	case *spacetimedb.SubscribeSingle:
+		return []string{msg.Query}
+	case *spacetimedb.SubscribeMulti:
+		return msg.QueryStrings
+	}
+	return nil
+}
+
+// WaitForMessage returns the first received message for which match returns true, waiting for it
+// if needed. The test fails if no such message arrives within the timeout of the server.
+func (s *Server) WaitForMessage(match func(message any) bool) any {
+	s.tb.Helper()
+	timeout := time.After(s.Timeout)
+	for seen := 0; ; {
+		s.mu.Lock()
+		messages, received := s.messages[seen:], s.received
+		seen = len(s.messages)
+		s.mu.Unlock()
+
+		for _, message := range messages {
+			if match(message) {
+				return message
+			}
+		}
+		select {
+		case <-received:
+		case <-timeout:
+			s.tb.Fatalf("spacetimedbtest: timed out waiting for a message")
+			return nil
+		}
+	}
+}
+
+// WaitForReducerCall returns the first call to reducer, waiting for it if needed.
+func (s *Server) WaitForReducerCall(reducer string) *spacetimedb.CallReducer {
+	s.tb.Helper()
+	message := s.WaitForMessage(func(message any) bool {
+		call, ok := message.(*spacetimedb.CallReducer)
+		return ok && call.Reducer == reducer
+	})
+	call, _ := message.(*spacetimedb.CallReducer)
+	return call
+}
+
+// WaitForSubscribe returns the first Subscribe, SubscribeSingle or SubscribeMulti message
+// including query, waiting for it if needed. Pass it to SendSubscribeApplied to answer it.
+func (s *Server) WaitForSubscribe(query string) any {
+	s.tb.Helper()
+	return s.WaitForMessage(func(message any) bool {
+		for _, q := range subscribeQueries(message) {
+			if q == query {
+				return true
+			}
+		}
+		return false
+	})
+}
@@ -0,0 +1,180 @@
+package spacetimedbtest
+
+import (
+	"fmt"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+)
+
+// TableUpdate holds rows deleted from and inserted into a table. Rows are Go values encoded with
+// spacetimedb.Marshal, so generated row types and plain structs both work.
+type TableUpdate struct {
+	Table   string
+	Deletes []any
+	Inserts []any
+}
+
+// Insert returns an update inserting rows into table.
+func Insert(table string, rows ...any) TableUpdate {
+	return TableUpdate{Table: table, Inserts: rows}
+}
+
+// Delete returns an update deleting rows from table.
+func Delete(table string, rows ...any) TableUpdate {
+	return TableUpdate{Table: table, Deletes: rows}
+}
+
+// Update returns an update replacing oldRow with newRow in table.
+func Update(table string, oldRow, newRow any) TableUpdate {
+	return TableUpdate{Table: table, Deletes: []any{oldRow}, Inserts: []any{newRow}}
+}
+
+func encodeRows(rows []any) (*spacetimedb.BsatnRowList, error) {
+	encoded := make([][]byte, len(rows))
+	for i, row := range rows {
+		data, err := spacetimedb.Marshal(row)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode row %T: %w", row, err)
+		}
+		encoded[i] = data
+	}
+	return spacetimedb.NewBsatnRowList(encoded), nil
+}
+
+func (u TableUpdate) encode() (*spacetimedb.TableUpdate, error) {
+	deletes, err := encodeRows(u.Deletes)
+	if err != nil {
+		return nil, fmt.Errorf("table %s: %w", u.Table, err)
+	}
+	inserts, err := encodeRows(u.Inserts)
+	if err != nil {
+		return nil, fmt.Errorf("table %s: %w", u.Table, err)
+	}
+	return &spacetimedb.TableUpdate{
+		TableName: u.Table,
+		NumRows:   uint64(len(u.Deletes) + len(u.Inserts)),
+		Updates:   []*spacetimedb.QueryUpdate{{Deletes: deletes, Inserts: inserts}},
+	}, nil
+}
+
+func encodeDatabaseUpdate(tables []TableUpdate) (*spacetimedb.DatabaseUpdate, error) {
+	update := &spacetimedb.DatabaseUpdate{}
+	for _, table := range tables {
+		tableUpdate, err := table.encode()
+		if err != nil {
+			return nil, fmt.Errorf("spacetimedbtest: %w", err)
+		}
+		update.Tables = append(update.Tables, tableUpdate)
+	}
+	return update, nil
+}
+
+// SendInitialSubscription sends the rows matching the legacy Subscribe message.
+func (s *Server) SendInitialSubscription(tables ...TableUpdate) error {
+	update, err := encodeDatabaseUpdate(tables)
+	if err != nil {
+		return err
+	}
+	return s.Send(&spacetimedb.InitialSubscription{
+		DatabaseUpdate:             update,
+		TotalHostExecutionDuration: spacetimedb.NewTimeDuration(0),
+	})
+}
+
+// SendTransactionUpdate sends a committed transaction that was not caused by a client of the server.
+func (s *Server) SendTransactionUpdate(tables ...TableUpdate) error {
+	update, err := encodeDatabaseUpdate(tables)
+	if err != nil {
+		return err
+	}
+	return s.Send(&spacetimedb.TransactionUpdate{
+		Status:                     &spacetimedb.UpdateStatus{Status: &spacetimedb.UpdateStatusComitted{DatabaseUpdate: update}},
+		Timestamp:                  spacetimedb.Now(),
+		ReducerCall:                &spacetimedb.ReducerCallInfo{},
+		EnergyQuantaUsed:           &spacetimedb.EnergyQuanta{},
+		TotalHostExecutionDuration: spacetimedb.NewTimeDuration(0),
+	})
+}
+
+// SendTransactionUpdateLight sends a committed transaction in the form light mode connections
+// receive, without the caller and reducer details.
+func (s *Server) SendTransactionUpdateLight(tables ...TableUpdate) error {
+	update, err := encodeDatabaseUpdate(tables)
+	if err != nil {
+		return err
+	}
+	return s.Send(&spacetimedb.TransactionUpdateLight{Update: update})
+}
+
+// SendReducerResult answers a reducer call received from a client. The reducer failed with
+// errorMessage, or committed tables if errorMessage is empty.
+func (s *Server) SendReducerResult(call *spacetimedb.CallReducer, errorMessage string, tables ...TableUpdate) error {
+	status := &spacetimedb.UpdateStatus{Status: &spacetimedb.UpdateStatusFailed{ErrorMessage: errorMessage}}
+	if errorMessage == "" {
+		update, err := encodeDatabaseUpdate(tables)
+		if err != nil {
+			return err
+		}
+		status.Status = &spacetimedb.UpdateStatusComitted{DatabaseUpdate: update}
+	}
+	return s.Send(&spacetimedb.TransactionUpdate{
+		Status:             status,
+		Timestamp:          spacetimedb.Now(),
+		CallerIdentity:     s.Identity,
+		CallerConnectionId: s.ConnectionId,
+		ReducerCall: &spacetimedb.ReducerCallInfo{
+			ReducerName: call.Reducer,
+			Args:        call.Args,
+			RequestID:   call.RequestId,
+		},
+		EnergyQuantaUsed:           &spacetimedb.EnergyQuanta{},
+		TotalHostExecutionDuration: spacetimedb.NewTimeDuration(0),
+	})
+}
+
+// SendSubscribeApplied answers a subscribe message received from a client with the rows matching
+// its queries. A SubscribeSingle is answered with exactly one table, a SubscribeMulti with any
+// number and a legacy Subscribe with an InitialSubscription.
+func (s *Server) SendSubscribeApplied(subscribe any, tables ...TableUpdate) error {
+	switch msg := subscribe.(type) {
+	case *spacetimedb.Subscribe:
+		update, err := encodeDatabaseUpdate(tables)
+		if err != nil {
+			return err
+		}
+		return s.Send(&spacetimedb.InitialSubscription{
+			DatabaseUpdate:             update,
+			RequestId:                  msg.RequestId,
+			TotalHostExecutionDuration: spacetimedb.NewTimeDuration(0),
+		})
+	case *spacetimedb.SubscribeSingle:
+		if len(tables) != 1 {
+			return fmt.Errorf("spacetimedbtest: SubscribeSingle is answered with one table, got %d", len(tables))
+		}
+		update, err := encodeDatabaseUpdate(tables)
+		if err != nil {
+			return err
+		}
+		return s.Send(&spacetimedb.SubscribeApplied{
+			RequestId:                  msg.RequestId,
+			TotalHostExecutionDuration: spacetimedb.NewTimeDuration(0),
+			QueryId:                    msg.QueryId,
+			Rows: &spacetimedb.SubscribeRows{
+				TableName: update.Tables[0].TableName,
+				TableRows: update.Tables[0],
+			},
+		})
+	case *spacetimedb.SubscribeMulti:
+		update, err := encodeDatabaseUpdate(tables)
+		if err != nil {
+			return err
+		}
+		return s.Send(&spacetimedb.SubscribeMultiApplied{
+			RequestId:                  msg.RequestId,
+			TotalHostExecutionDuration: spacetimedb.NewTimeDuration(0),
+			QueryId:                    msg.QueryId,
+			Update:                     update,
+		})
+	}
+	return fmt.Errorf("spacetimedbtest: %T is not a subscribe message", subscribe)
+}
@@ -0,0 +1,198 @@
+package spacetimedb
+
+import "fmt"
+
+type subscriptionState int
+
+const (
+	subscriptionStateCreated subscriptionState = iota
+	subscriptionStatePending
+	subscriptionStateActive
+	subscriptionStateUnsubscribing
+	subscriptionStateEnded
+)
+
+// SubscriptionHandle is a set of queries that are subscribed to and unsubscribed from together.
+// Register callbacks with OnApplied and OnError before calling Subscribe.
+type SubscriptionHandle struct {
+	conn    *DBConnection
+	queries []string
+	queryId uint32
+	// state is guarded by the mutex of the connection.
+	state subscriptionState
+
+	onApplied callbacks[func(ctx *EventContext)]
+	onError   callbacks[func(ctx *EventContext, err error)]
+	onEnd     callbacks[func(ctx *EventContext)]
+}
+
+// NewSubscription creates a SubscriptionHandle. Nothing is sent to the server until Subscribe is called.
+func (db *DBConnection) NewSubscription() *SubscriptionHandle {
+	return &SubscriptionHandle{conn: db}
+}
+
+// OnApplied registers a callback that runs once the subscribed rows have been applied to the client cache.
+func (h *SubscriptionHandle) OnApplied(callback func(ctx *EventContext)) *CallbackHandle {
+	return h.onApplied.add(callback)
+}
+
+// OnError registers a callback that runs when the server rejects or drops the subscription.
+func (h *SubscriptionHandle) OnError(callback func(ctx *EventContext, err error)) *CallbackHandle {
+	return h.onError.add(callback)
+}
+
+// OnEnd registers a callback that runs once an unsubscribe has been applied and the rows of
+// the subscription have been removed from the client cache.
+func (h *SubscriptionHandle) OnEnd(callback func(ctx *EventContext)) *CallbackHandle {
+	return h.onEnd.add(callback)
+}
+
+// Queries returns the queries of the subscription.
+func (h *SubscriptionHandle) Queries() []string {
+	return h.queries
+}
+
+// IsActive reports whether the subscription has been applied and not yet unsubscribed.
+func (h *SubscriptionHandle) IsActive() bool {
+	h.conn.mu.RLock()
+	defer h.conn.mu.RUnlock()
+	return h.state == subscriptionStateActive
+}
+
+// IsEnded reports whether the subscription has been unsubscribed or failed.
+func (h *SubscriptionHandle) IsEnded() bool {
+	h.conn.mu.RLock()
+	defer h.conn.mu.RUnlock()
+	return h.state == subscriptionStateEnded
+}
+
+// unsubscribing reports whether an unsubscribe has been sent and not yet applied.
+func (h *SubscriptionHandle) unsubscribing() bool {
+	h.conn.mu.RLock()
+	defer h.conn.mu.RUnlock()
+	return h.state == subscriptionStateUnsubscribing
+}
+
+// Subscribe sends the queries to the server. A single query is sent as SubscribeSingle,
+// and several queries as SubscribeMulti.
+func (h *SubscriptionHandle) Subscribe(queries ...string) error {
+	if len(queries) == 0 {
+		return fmt.Errorf("subscription needs at least one query")
+	}
+	h.conn.mu.Lock()
+	if h.state != subscriptionStateCreated {
+		h.conn.mu.Unlock()
+		return fmt.Errorf("subscription has already been subscribed")
+	}
+	h.queries = queries
+	h.queryId = h.conn.newQueryId()
+	h.state = subscriptionStatePending
+	h.conn.subscriptions[h.queryId] = h
+	h.conn.mu.Unlock()
+
+	if err := h.conn.sendClientMessage(h.subscribeMessage(h.conn.newRequestId())); err != nil {
+		h.conn.mu.Lock()
+		delete(h.conn.subscriptions, h.queryId)
+		h.state = subscriptionStateEnded
+		h.conn.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (h *SubscriptionHandle) subscribeMessage(requestId uint32) any {
+	if len(h.queries) == 1 {
+		return &SubscribeSingle{
+			Query:     h.queries[0],
+			RequestId: requestId,
+			QueryId:   h.queryId,
+		}
+	}
+	return &SubscribeMulti{
+		QueryStrings: h.queries,
+		RequestId:    requestId,
+		QueryId:      h.queryId,
+	}
+}
+
+// Unsubscribe asks the server to end the subscription. The rows of the subscription are removed
+// from the client cache when the server has applied it.
+func (h *SubscriptionHandle) Unsubscribe() error {
+	h.conn.mu.Lock()
+	if h.state != subscriptionStateActive {
+		h.conn.mu.Unlock()
+		return fmt.Errorf("only active subscriptions can be unsubscribed")
+	}
+	h.state = subscriptionStateUnsubscribing
+	h.conn.mu.Unlock()
+
+	requestId := h.conn.newRequestId()
+	var err error
+	if len(h.queries) == 1 {
+		err = h.conn.sendClientMessage(&Unsubscribe{RequestId: requestId, QueryId: h.queryId})
+	} else {
+		err = h.conn.sendClientMessage(&UnsubscribeMulti{RequestId: requestId, QueryId: h.queryId})
+	}
+	if err != nil {
+		h.conn.mu.Lock()
+		h.state = subscriptionStateActive
+		h.conn.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// applied marks a pending subscription as active and runs the OnApplied callbacks. A subscription
+// replayed after a reconnect was applied before, so its callbacks do not run again.
+func (h *SubscriptionHandle) applied(ctx *EventContext) {
+	h.conn.mu.Lock()
+	if h.state != subscriptionStatePending {
+		h.conn.mu.Unlock()
+		return
+	}
+	h.state = subscriptionStateActive
+	h.conn.mu.Unlock()
+	h.onApplied.each(func(callback func(ctx *EventContext)) {
+		callback(ctx)
+	})
+}
+
+func (h *SubscriptionHandle) ended(ctx *EventContext) {
+	h.end()
+	h.onEnd.each(func(callback func(ctx *EventContext)) {
+		callback(ctx)
+	})
+}
+
+func (h *SubscriptionHandle) failed(ctx *EventContext, err error) {
+	h.end()
+	h.onError.each(func(callback func(ctx *EventContext, err error)) {
+		callback(ctx, err)
+	})
+}
+
+func (h *SubscriptionHandle) end() {
+	h.conn.mu.Lock()
+	defer h.conn.mu.Unlock()
+	h.state = subscriptionStateEnded
+	delete(h.conn.subscriptions, h.queryId)
+}
+
+// subscription returns the subscription with the given query ID, or nil if there is none.
+func (db *DBConnection) subscription(queryId uint32) *SubscriptionHandle {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.subscriptions[queryId]
+}
+
+// openSubscriptions returns the subscriptions that have been sent to the server and not ended,
+// whether they have been applied yet or not.
+func (db *DBConnection) openSubscriptions() []*SubscriptionHandle {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	handles := make([]*SubscriptionHandle, 0, len(db.subscriptions))
+	for _, handle := range db.subscriptions {
+		handles = append(handles, handle)
+	}
+	return handles
+}
@@ -1,12 +1,194 @@
 package spacetimedb
 
+import (
+	"fmt"
+	"sync"
+)
+
+// TableCache holds the rows of a table that the client is subscribed to. Rows are keyed by
+// primary key, or by their BSATN encoding for tables without one. The rows can be read from any
+// goroutine while the connection applies updates.
 type TableCache[T any] struct {
-	Rows map[string]T
+	mu   sync.RWMutex
+	rows map[string]T
+
+	decode     func(reader *BinaryReader) (T, error)
+	primaryKey func(row T) string
+
+	onInsert callbacks[func(ctx *EventContext, row T)]
+	onDelete callbacks[func(ctx *EventContext, row T)]
+	onUpdate callbacks[func(ctx *EventContext, oldRow T, newRow T)]
+}
+
+// NewTableCache creates an empty TableCache. decode reads a single row. primaryKey returns the
+// primary key of a row as a string, and must be nil for tables without a primary key.
+func NewTableCache[T any](decode func(reader *BinaryReader) (T, error), primaryKey func(row T) string) *TableCache[T] {
+	return &TableCache[T]{
+		rows:       make(map[string]T),
+		decode:     decode,
+		primaryKey: primaryKey,
+	}
+}
+
+// OnInsert registers a callback that runs when a row is inserted into the cache.
+func (tc *TableCache[T]) OnInsert(callback func(ctx *EventContext, row T)) *CallbackHandle {
+	return tc.onInsert.add(callback)
+}
+
+// OnDelete registers a callback that runs when a row is deleted from the cache.
+func (tc *TableCache[T]) OnDelete(callback func(ctx *EventContext, row T)) *CallbackHandle {
+	return tc.onDelete.add(callback)
+}
+
+// OnUpdate registers a callback that runs when a transaction deletes a row and inserts a row
+// with the same primary key. It only runs for tables with a primary key.
+func (tc *TableCache[T]) OnUpdate(callback func(ctx *EventContext, oldRow T, newRow T)) *CallbackHandle {
+	return tc.onUpdate.add(callback)
+}
+
+type decodedRow[T any] struct {
+	key string
+	row T
+}
+
+func (tc *TableCache[T]) decodeRows(rows [][]byte) ([]decodedRow[T], error) {
+	decoded := make([]decodedRow[T], 0, len(rows))
+	for _, bytes := range rows {
+		reader := NewBinaryReader(bytes)
+		row, err := tc.decode(reader)
+		if err == nil {
+			err = reader.Err()
+		}
+		if err != nil {
+			return nil, err
+		}
+		if reader.Remaining() != 0 {
+			return nil, fmt.Errorf("%d bytes left over after decoding row of %d bytes", reader.Remaining(), len(bytes))
+		}
+		key := string(bytes)
+		if tc.primaryKey != nil {
+			key = tc.primaryKey(row)
+		}
+		decoded = append(decoded, decodedRow[T]{key: key, row: row})
+	}
+	return decoded, nil
+}
+
+// Get returns the row with the given key, which is the primary key as formatted by the bindings.
+func (tc *TableCache[T]) Get(key string) (T, bool) {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	row, ok := tc.rows[key]
+	return row, ok
+}
+
+// Count returns the number of rows in the table.
+func (tc *TableCache[T]) Count() int {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	return len(tc.rows)
+}
+
+// All returns a snapshot of the rows in the table, in no particular order. Updates applied later
+// do not change the returned slice.
+func (tc *TableCache[T]) All() []T {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	rows := make([]T, 0, len(tc.rows))
+	for _, row := range tc.rows {
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// DecodeRows decodes the rows of a row list, such as a table in the result of OneOffQuery. The rows
+// are not added to the cache.
+func (tc *TableCache[T]) DecodeRows(list *BsatnRowList) ([]T, error) {
+	rows, err := list.Rows()
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := tc.decodeRows(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode row: %w", err)
+	}
+	result := make([]T, len(decoded))
+	for i, row := range decoded {
+		result[i] = row.row
+	}
+	return result, nil
+}
+
+// ApplyUpdate applies the rows deleted and inserted by a single table update. A deleted and an
+// inserted row with the same primary key are applied as an update. Deletes are applied before
+// inserts, and the callbacks run after the whole update is applied.
+func (tc *TableCache[T]) ApplyUpdate(ctx *EventContext, deletes [][]byte, inserts [][]byte) error {
+	deletedRows, err := tc.decodeRows(deletes)
+	if err != nil {
+		return fmt.Errorf("failed to decode deleted row: %w", err)
+	}
+	insertedRows, err := tc.decodeRows(inserts)
+	if err != nil {
+		return fmt.Errorf("failed to decode inserted row: %w", err)
+	}
+
+	// The rows are updated under the lock, but the callbacks run after it is released so they can
+	// read the table.
+	tc.mu.Lock()
+	deletedByKey := make(map[string]T, len(deletedRows))
+	for _, deleted := range deletedRows {
+		if cached, ok := tc.rows[deleted.key]; ok {
+			deleted.row = cached
+		}
+		deletedByKey[deleted.key] = deleted.row
+		delete(tc.rows, deleted.key)
+	}
+
+	type update struct {
+		oldRow T
+		newRow T
+	}
+	var updated []update
+	var inserted []T
+	for _, row := range insertedRows {
+		tc.rows[row.key] = row.row
+		if oldRow, ok := deletedByKey[row.key]; ok && tc.primaryKey != nil {
+			delete(deletedByKey, row.key)
+			updated = append(updated, update{oldRow: oldRow, newRow: row.row})
+			continue
+		}
+		inserted = append(inserted, row.row)
+	}
+	tc.mu.Unlock()
+
+	for _, deleted := range deletedRows {
+		row, ok := deletedByKey[deleted.key]
+		if !ok {
+			continue
+		}
+		delete(deletedByKey, deleted.key)
+		tc.onDelete.each(func(callback func(ctx *EventContext, row T)) {
+			callback(ctx, row)
+		})
+	}
+	for _, u := range updated {
+		tc.onUpdate.each(func(callback func(ctx *EventContext, oldRow T, newRow T)) {
+			callback(ctx, u.oldRow, u.newRow)
+		})
+	}
+	for _, row := range inserted {
+		tc.onInsert.each(func(callback func(ctx *EventContext, row T)) {
+			callback(ctx, row)
+		})
+	}
+	return nil
 }
 
 type Table interface {
-	Insert(reader *BinaryReader) error
-	Delete(reader *BinaryReader) error
+	// ApplyUpdate applies the BSATN encoded rows deleted and inserted by a single table update.
+	// The connection counts rows returned by overlapping subscriptions, so it only passes on rows
+	// that enter the client cache or leave it entirely.
+	ApplyUpdate(ctx *EventContext, deletes [][]byte, inserts [][]byte) error
 }
 
 type TableNameMap = map[string]Table
@@ -0,0 +1,150 @@
+package test
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+	"github.com/alexanderbh/spacetimedb-go-sdk/test/testbindings"
+)
+
+func TestDecodeValueOfRow(t *testing.T) {
+	data, err := os.ReadFile("testdata/schema.json")
+	if err != nil {
+		t.Fatalf("failed to read schema: %v", err)
+	}
+	def, err := spacetimedb.ParseModuleDef(data)
+	if err != nil {
+		t.Fatalf("failed to parse schema: %v", err)
+	}
+
+	owner, _ := spacetimedb.NewIdentity("c200f6a0e1dd7c2ca3a2d0e6bd6f02b6bd5e91c2d9ae0ab6d2e0fa7a6d1c9bd1")
+	row := &testbindings.Player{
+		Id:           42,
+		Name:         "alice",
+		Position:     testbindings.Point{X: 1.5, Y: -2},
+		Tags:         []string{"admin"},
+		Avatar:       []byte{0xab},
+		Score:        big.NewInt(1000),
+		Owner:        &owner,
+		Path:         []testbindings.Point{},
+		Status:       testbindings.PlayerStatus{Value: &testbindings.PlayerStatusBanned{Value: "spam"}},
+		Cooldown:     spacetimedb.NewTimeDuration(1500),
+		ConnectionId: spacetimedb.NewConnectionId(big.NewInt(7)),
+		Balance:      big.NewInt(-1),
+		Alive:        true,
+	}
+	writer := spacetimedb.NewBinaryWriter()
+	if err := row.Serialize(writer); err != nil {
+		t.Fatalf("failed to serialize row: %v", err)
+	}
+
+	reader := spacetimedb.NewBinaryReader(writer.GetBuffer())
+	value, err := def.Typespace.DecodeValue(reader, spacetimedb.NewRefType(def.Tables[0].ProductTypeRef))
+	if err != nil {
+		t.Fatalf("failed to decode row: %v", err)
+	}
+	if reader.Remaining() != 0 {
+		t.Errorf("%d bytes left over after decoding row", reader.Remaining())
+	}
+	product, ok := value.(spacetimedb.ProductValue)
+	if !ok {
+		t.Fatalf("expected ProductValue, got %T", value)
+	}
+
+	expect := func(name string, want spacetimedb.Value) {
+		t.Helper()
+		got, ok := product.Get(name)
+		if !ok {
+			t.Errorf("missing element %s", name)
+		} else if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s mismatch: got %#v, want %#v", name, got, want)
+		}
+	}
+	expect("id", uint64(42))
+	expect("name", "alice")
+	expect("tags", spacetimedb.ArrayValue{"admin"})
+	expect("avatar", []byte{0xab})
+	expect("owner", spacetimedb.SumValue{Tag: 0, Name: "some", Value: owner})
+	expect("nickname", spacetimedb.SumValue{Tag: 1, Name: "none", Value: spacetimedb.ProductValue{Elements: []spacetimedb.ProductValueElement{}}})
+	expect("status", spacetimedb.SumValue{Tag: 2, Name: "banned", Value: "spam"})
+	expect("cooldown", spacetimedb.NewTimeDuration(1500))
+	expect("position", spacetimedb.ProductValue{Elements: []spacetimedb.ProductValueElement{
+		{Name: "x", Value: float32(1.5)},
+		{Name: "y", Value: float32(-2)},
+	}})
+
+	formatted := spacetimedb.FormatValue(value)
+	for _, want := range []string{`name = "alice"`, "position = (x = 1.5, y = -2)", `status = banned("spam")`, "nickname = none", "owner = some(c200f6a0"} {
+		if !strings.Contains(formatted, want) {
+			t.Errorf("formatted row %s does not contain %s", formatted, want)
+		}
+	}
+}
+
+func TestDecodeValueOfMap(t *testing.T) {
+	data, err := spacetimedb.Marshal(map[string]uint32{"b": 2, "a": 1})
+	if err != nil {
+		t.Fatalf("failed to marshal map: %v", err)
+	}
+	ty := spacetimedb.NewMapType(spacetimedb.AlgebraicType{Kind: spacetimedb.AlgebraicTypeString}, spacetimedb.AlgebraicType{Kind: spacetimedb.AlgebraicTypeU32})
+	value, err := spacetimedb.DecodeValue(spacetimedb.NewBinaryReader(data), ty)
+	if err != nil {
+		t.Fatalf("failed to decode map: %v", err)
+	}
+	want := spacetimedb.MapValue{{Key: "a", Value: uint32(1)}, {Key: "b", Value: uint32(2)}}
+	if !reflect.DeepEqual(value, want) {
+		t.Errorf("map mismatch: got %#v, want %#v", value, want)
+	}
+}
+
+func TestDecodeValueErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		data []byte
+		ty   spacetimedb.AlgebraicType
+		want string
+	}{
+		{"unresolved ref", []byte{0}, spacetimedb.NewRefType(0), "reference to missing type"},
+		{"unknown sum tag", []byte{2}, spacetimedb.NewOptionType(spacetimedb.AlgebraicType{Kind: spacetimedb.AlgebraicTypeU8}), "unknown tag 2"},
+		{"truncated", []byte{1, 0}, spacetimedb.AlgebraicType{Kind: spacetimedb.AlgebraicTypeU32}, "out of bounds"},
+		{"invalid kind", []byte{0}, spacetimedb.AlgebraicType{}, "invalid type kind"},
+	} {
+		_, err := spacetimedb.DecodeValue(spacetimedb.NewBinaryReader(tc.data), tc.ty)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+func TestAlgebraicTypeJSON(t *testing.T) {
+	ty := spacetimedb.NewProductType(
+		spacetimedb.ProductTypeElement{Name: "owner", Type: spacetimedb.IdentityType()},
+		spacetimedb.ProductTypeElement{Name: "tags", Type: spacetimedb.NewArrayType(spacetimedb.AlgebraicType{Kind: spacetimedb.AlgebraicTypeString})},
+		spacetimedb.ProductTypeElement{Name: "seen", Type: spacetimedb.NewOptionType(spacetimedb.TimestampType())},
+		spacetimedb.ProductTypeElement{Name: "point", Type: spacetimedb.NewRefType(3)},
+		spacetimedb.ProductTypeElement{Type: spacetimedb.NewMapType(spacetimedb.AlgebraicType{Kind: spacetimedb.AlgebraicTypeU8}, spacetimedb.ConnectionIdType())},
+	)
+	data, err := json.Marshal(ty)
+	if err != nil {
+		t.Fatalf("failed to marshal type: %v", err)
+	}
+	if !strings.Contains(string(data), `{"name":{"some":"tags"},"algebraic_type":{"Array":{"String":[]}}}`) {
+		t.Errorf("unexpected JSON encoding: %s", data)
+	}
+
+	var decoded spacetimedb.AlgebraicType
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal type: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, ty) {
+		t.Errorf("round trip mismatch:\ngot  %+v\nwant %+v", decoded, ty)
+	}
+	if inner, ok := decoded.Product.Elements[2].Type.Option(); !ok || !inner.IsTimestamp() {
+		t.Errorf("expected seen to be an Option of Timestamp")
+	}
+}
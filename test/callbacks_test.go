@@ -0,0 +1,102 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+	"github.com/gorilla/websocket"
+)
+
+func TestTableCallbacksReceiveTypedRows(t *testing.T) {
+	server := newTestServer(t)
+	players := spacetimedb.NewTableCache(decodeTestPlayer, (*testPlayer).primaryKey)
+	events := make(chan string, 10)
+	var conns []*spacetimedb.DBConnection
+	players.OnInsert(func(ctx *spacetimedb.EventContext, row *testPlayer) {
+		conns = append(conns, ctx.Conn)
+		events <- fmt.Sprintf("insert %d %s %T", row.Id, row.Name, ctx.Event)
+	})
+	players.OnDelete(func(ctx *spacetimedb.EventContext, row *testPlayer) {
+		events <- fmt.Sprintf("delete %d %s %T", row.Id, row.Name, ctx.Event)
+	})
+	players.OnUpdate(func(ctx *spacetimedb.EventContext, oldRow, newRow *testPlayer) {
+		events <- fmt.Sprintf("update %s -> %s %T", oldRow.Name, newRow.Name, ctx.Event)
+	})
+	db, ws := server.connect(t, spacetimedb.WithTableNameMap(spacetimedb.TableNameMap{"player": players}))
+
+	ws.WriteMessage(websocket.BinaryMessage, initialSubscriptionMessage(testTableUpdate{
+		name:    "player",
+		inserts: [][]byte{encodeTestPlayer(1, "alice"), encodeTestPlayer(2, "bob")},
+	}))
+	ws.WriteMessage(websocket.BinaryMessage, transactionUpdateMessage(testTableUpdate{
+		name:    "player",
+		deletes: [][]byte{encodeTestPlayer(1, "alice"), encodeTestPlayer(2, "bob")},
+		inserts: [][]byte{encodeTestPlayer(1, "alicia")},
+	}))
+
+	want := []string{
+		"insert 1 alice *spacetimedb.InitialSubscription",
+		"insert 2 bob *spacetimedb.InitialSubscription",
+		"delete 2 bob *spacetimedb.TransactionUpdate",
+		"update alice -> alicia *spacetimedb.TransactionUpdate",
+	}
+	for _, w := range want {
+		if got := waitForEvent(t, events); got != w {
+			t.Errorf("event mismatch: got %q, want %q", got, w)
+		}
+	}
+	for _, conn := range conns {
+		if conn != db {
+			t.Errorf("expected the callback context to hold the connection")
+		}
+	}
+}
+
+func TestTableCallbacksOrderAndRemove(t *testing.T) {
+	cache := spacetimedb.NewTableCache(decodeTestPlayer, (*testPlayer).primaryKey)
+
+	var events []string
+	var second *spacetimedb.CallbackHandle
+	first := cache.OnInsert(func(ctx *spacetimedb.EventContext, row *testPlayer) {
+		events = append(events, "first "+row.Name)
+		// Removing a callback while the callbacks run does not skip it for the current row.
+		second.Remove()
+	})
+	second = cache.OnInsert(func(ctx *spacetimedb.EventContext, row *testPlayer) {
+		events = append(events, "second "+row.Name)
+	})
+	deleteHandle := cache.OnDelete(func(ctx *spacetimedb.EventContext, row *testPlayer) {
+		events = append(events, "delete "+row.Name)
+	})
+
+	ctx := &spacetimedb.EventContext{}
+	if err := cache.ApplyUpdate(ctx, nil, [][]byte{encodeTestPlayer(1, "alice")}); err != nil {
+		t.Fatalf("failed to apply update: %v", err)
+	}
+	if fmt.Sprint(events) != fmt.Sprint([]string{"first alice", "second alice"}) {
+		t.Errorf("events mismatch: got %v", events)
+	}
+
+	events = nil
+	if err := cache.ApplyUpdate(ctx, nil, [][]byte{encodeTestPlayer(2, "bob")}); err != nil {
+		t.Fatalf("failed to apply update: %v", err)
+	}
+	if fmt.Sprint(events) != fmt.Sprint([]string{"first bob"}) {
+		t.Errorf("expected the removed callback not to run, got %v", events)
+	}
+
+	first.Remove()
+	first.Remove()
+	deleteHandle.Remove()
+	events = nil
+	if err := cache.ApplyUpdate(ctx, [][]byte{encodeTestPlayer(1, "alice")}, [][]byte{encodeTestPlayer(3, "carol")}); err != nil {
+		t.Fatalf("failed to apply update: %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("expected no callbacks to run, got %v", events)
+	}
+	if _, ok := cache.Get("1"); ok || cache.Count() != 2 {
+		t.Errorf("unexpected rows in cache: %v", cache.All())
+	}
+}
@@ -0,0 +1,187 @@
+package test
+
+import (
+	"bytes"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+	"github.com/alexanderbh/spacetimedb-go-sdk/codegen"
+	"github.com/alexanderbh/spacetimedb-go-sdk/test/testbindings"
+)
+
+func TestGeneratedBindingsUpToDate(t *testing.T) {
+	for _, tc := range []struct {
+		schema string
+		dir    string
+	}{
+		{"../examples/quickstart-chat/schema.json", "../examples/quickstart-chat/client/module_bindings"},
+		{"testdata/schema.json", "testbindings"},
+	} {
+		data, err := os.ReadFile(tc.schema)
+		if err != nil {
+			t.Fatalf("failed to read schema: %v", err)
+		}
+		def, err := spacetimedb.ParseModuleDef(data)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", tc.schema, err)
+		}
+		files, err := codegen.Generate(def, filepath.Base(tc.dir))
+		if err != nil {
+			t.Fatalf("failed to generate bindings for %s: %v", tc.schema, err)
+		}
+
+		entries, err := os.ReadDir(tc.dir)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", tc.dir, err)
+		}
+		if len(entries) != len(files) {
+			t.Errorf("%s has %d files, generated %d", tc.dir, len(entries), len(files))
+		}
+		for name, source := range files {
+			existing, err := os.ReadFile(filepath.Join(tc.dir, name))
+			if err != nil || !bytes.Equal(existing, source) {
+				t.Errorf("%s/%s is out of date, run spacetimedb-go-codegen -schema %s -out %s", tc.dir, name, tc.schema, tc.dir)
+			}
+		}
+	}
+}
+
+func TestGeneratedRowRoundTrip(t *testing.T) {
+	owner, err := spacetimedb.NewIdentity("c200f6a0e1dd7c2ca3a2d0e6bd6f02b6bd5e91c2d9ae0ab6d2e0fa7a6d1c9bd1")
+	if err != nil {
+		t.Fatalf("failed to create identity: %v", err)
+	}
+	nickname := "ace"
+	row := &testbindings.Player{
+		Id:           42,
+		Name:         "alice",
+		Position:     testbindings.Point{X: 1.5, Y: -2},
+		Tags:         []string{"admin", "beta"},
+		Avatar:       []byte{1, 2, 3},
+		Score:        new(big.Int).Lsh(big.NewInt(1), 100),
+		Owner:        &owner,
+		Nickname:     &nickname,
+		LastSeen:     nil,
+		Path:         []testbindings.Point{{X: 1, Y: 2}, {X: 3, Y: 4}},
+		Status:       testbindings.PlayerStatus{Value: &testbindings.PlayerStatusBanned{Value: "spam"}},
+		Cooldown:     spacetimedb.NewTimeDuration(1500),
+		ConnectionId: spacetimedb.NewConnectionId(big.NewInt(7)),
+		Balance:      big.NewInt(-12345),
+		Alive:        true,
+		Level:        -3,
+		Ratio:        0.25,
+	}
+
+	writer := spacetimedb.NewBinaryWriter()
+	if err := row.Serialize(writer); err != nil {
+		t.Fatalf("failed to serialize row: %v", err)
+	}
+	reader := spacetimedb.NewBinaryReader(writer.GetBuffer())
+	decoded, err := testbindings.DeserializePlayer(reader)
+	if err != nil {
+		t.Fatalf("failed to deserialize row: %v", err)
+	}
+	if reader.Remaining() != 0 {
+		t.Errorf("%d bytes left over after decoding row", reader.Remaining())
+	}
+	if !reflect.DeepEqual(decoded, row) {
+		t.Errorf("round trip mismatch:\ngot  %+v\nwant %+v", decoded, row)
+	}
+
+	// A truncated row is reported instead of panicking.
+	_, err = testbindings.DeserializePlayer(spacetimedb.NewBinaryReader(writer.GetBuffer()[:40]))
+	if err == nil {
+		t.Errorf("expected error decoding truncated row")
+	}
+}
+
+func TestGeneratedTableFindByPrimaryKey(t *testing.T) {
+	encode := func(id uint64, name string) []byte {
+		row := &testbindings.Player{
+			Id:           id,
+			Name:         name,
+			Position:     testbindings.Point{},
+			Score:        big.NewInt(0),
+			Status:       testbindings.PlayerStatus{Value: &testbindings.PlayerStatusOnline{}},
+			Cooldown:     spacetimedb.NewTimeDuration(0),
+			ConnectionId: spacetimedb.NewConnectionId(big.NewInt(1)),
+			Balance:      big.NewInt(0),
+		}
+		writer := spacetimedb.NewBinaryWriter()
+		if err := row.Serialize(writer); err != nil {
+			t.Fatalf("failed to serialize row: %v", err)
+		}
+		return writer.GetBuffer()
+	}
+
+	table := testbindings.NewPlayerTable()
+	var updated []string
+	table.OnUpdate(func(ctx *spacetimedb.EventContext, oldRow, newRow *testbindings.Player) {
+		updated = append(updated, oldRow.Name+" -> "+newRow.Name)
+	})
+	ctx := &spacetimedb.EventContext{}
+	if err := table.ApplyUpdate(ctx, nil, [][]byte{encode(1, "alice"), encode(2, "bob")}); err != nil {
+		t.Fatalf("failed to apply update: %v", err)
+	}
+	if err := table.ApplyUpdate(ctx, [][]byte{encode(1, "alice")}, [][]byte{encode(1, "alicia")}); err != nil {
+		t.Fatalf("failed to apply update: %v", err)
+	}
+
+	if row, ok := table.FindById(1); !ok || row.Name != "alicia" {
+		t.Errorf("FindById(1) = %+v, %v", row, ok)
+	}
+	if _, ok := table.FindById(3); ok {
+		t.Errorf("expected no row with id 3")
+	}
+	if len(updated) != 1 || updated[0] != "alice -> alicia" {
+		t.Errorf("expected one update, got %v", updated)
+	}
+}
+
+func TestGeneratedZeroValueRow(t *testing.T) {
+	// Required fields of named types are values, so a zero row serializes without dereferencing
+	// nil. The zero Color has no variant, which is reported as an error.
+	err := (&testbindings.Marker{}).Serialize(spacetimedb.NewBinaryWriter())
+	if err == nil {
+		t.Errorf("expected an error serializing a marker without a color")
+	}
+
+	writer := spacetimedb.NewBinaryWriter()
+	marker := &testbindings.Marker{Color: testbindings.Color{Value: &testbindings.ColorRed{}}}
+	if err := marker.Serialize(writer); err != nil {
+		t.Fatalf("failed to serialize marker: %v", err)
+	}
+	decoded, err := testbindings.DeserializeMarker(spacetimedb.NewBinaryReader(writer.GetBuffer()))
+	if err != nil {
+		t.Fatalf("failed to deserialize marker: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, marker) {
+		t.Errorf("round trip mismatch:\ngot  %+v\nwant %+v", decoded, marker)
+	}
+}
+
+func TestGeneratedRemoteTablesPerConnection(t *testing.T) {
+	first, second := testbindings.NewRemoteTables(), testbindings.NewRemoteTables()
+	if first.Player == second.Player || first.TableNameMap()["player"] == second.TableNameMap()["player"] {
+		t.Fatalf("expected every RemoteTables to have its own caches")
+	}
+	if testbindings.Tables["player"] != testbindings.Db.Player {
+		t.Errorf("expected Tables to hold the caches of Db")
+	}
+
+	row := &testbindings.Marker{Color: testbindings.Color{Value: &testbindings.ColorGreen{}}}
+	writer := spacetimedb.NewBinaryWriter()
+	if err := row.Serialize(writer); err != nil {
+		t.Fatalf("failed to serialize marker: %v", err)
+	}
+	if err := first.Marker.ApplyUpdate(&spacetimedb.EventContext{}, nil, [][]byte{writer.GetBuffer()}); err != nil {
+		t.Fatalf("failed to apply update: %v", err)
+	}
+	if first.Marker.Count() != 1 || second.Marker.Count() != 0 {
+		t.Errorf("expected only the first tables to hold the row, got %d and %d", first.Marker.Count(), second.Marker.Count())
+	}
+}
@@ -0,0 +1,152 @@
+package test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+	"github.com/andybalholm/brotli"
+	"github.com/gorilla/websocket"
+)
+
+func writeQueryUpdate(writer *spacetimedb.BinaryWriter, deletes []byte, inserts []byte) {
+	for _, rows := range [][]byte{deletes, inserts} {
+		writer.WriteU8(0x00) // RowSizeHint FixedSize
+		writer.WriteU16(4)
+		writer.WriteUInt8Array(rows)
+	}
+}
+
+func TestParsingCompressedQueryUpdate(t *testing.T) {
+	deletes := []byte{0x01, 0x00, 0x00, 0x00}
+	inserts := []byte{0x02, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00}
+
+	queryUpdateWriter := spacetimedb.NewBinaryWriter()
+	writeQueryUpdate(queryUpdateWriter, deletes, inserts)
+	queryUpdate := queryUpdateWriter.GetBuffer()
+
+	var gzipped bytes.Buffer
+	gzipWriter := gzip.NewWriter(&gzipped)
+	gzipWriter.Write(queryUpdate)
+	gzipWriter.Close()
+
+	var brotlied bytes.Buffer
+	brotliWriter := brotli.NewWriter(&brotlied)
+	brotliWriter.Write(queryUpdate)
+	brotliWriter.Close()
+
+	tests := []struct {
+		name  string
+		tag   uint8
+		bytes []byte
+	}{
+		{name: "brotli", tag: 0x01, bytes: brotlied.Bytes()},
+		{name: "gzip", tag: 0x02, bytes: gzipped.Bytes()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := spacetimedb.NewBinaryWriter()
+			writer.WriteU8(tt.tag)
+			writer.WriteUInt8Array(tt.bytes)
+
+			got := &spacetimedb.CompressableQueryUpdate{}
+			if err := got.Deserialize(spacetimedb.NewBinaryReader(writer.GetBuffer())); err != nil {
+				t.Fatalf("failed to deserialize compressable query update: %v", err)
+			}
+			if got.Update == nil {
+				t.Fatalf("expected query update to be decompressed, got nil")
+			}
+			if !bytes.Equal(got.Update.Deletes.RowsData, deletes) {
+				t.Errorf("deletes mismatch: got %v, want %v", got.Update.Deletes.RowsData, deletes)
+			}
+			if !bytes.Equal(got.Update.Inserts.RowsData, inserts) {
+				t.Errorf("inserts mismatch: got %v, want %v", got.Update.Inserts.RowsData, inserts)
+			}
+		})
+	}
+}
+
+func TestCompressedQueryUpdateTrailingBytes(t *testing.T) {
+	writer := spacetimedb.NewBinaryWriter()
+	writeQueryUpdate(writer, []byte{0x01, 0x00, 0x00, 0x00}, nil)
+	// A second payload concatenated to the query update is not silently ignored.
+	payload := append(writer.GetBuffer(), writer.GetBuffer()...)
+
+	var gzipped bytes.Buffer
+	gzipWriter := gzip.NewWriter(&gzipped)
+	gzipWriter.Write(payload)
+	gzipWriter.Close()
+
+	var brotlied bytes.Buffer
+	brotliWriter := brotli.NewWriter(&brotlied)
+	brotliWriter.Write(payload)
+	brotliWriter.Close()
+
+	for _, tt := range []struct {
+		name  string
+		tag   uint8
+		bytes []byte
+	}{
+		{name: "brotli", tag: 0x01, bytes: brotlied.Bytes()},
+		{name: "gzip", tag: 0x02, bytes: gzipped.Bytes()},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := spacetimedb.NewBinaryWriter()
+			writer.WriteU8(tt.tag)
+			writer.WriteUInt8Array(tt.bytes)
+
+			got := &spacetimedb.CompressableQueryUpdate{}
+			if err := got.Deserialize(spacetimedb.NewBinaryReader(writer.GetBuffer())); err == nil {
+				t.Errorf("expected an error for trailing bytes after the query update")
+			}
+		})
+	}
+}
+
+func TestCompressedServerMessage(t *testing.T) {
+	compressors := []struct {
+		name     string
+		tag      uint8
+		compress func(data []byte) []byte
+	}{
+		{name: "brotli", tag: spacetimedb.CompressionTypeBrotly, compress: func(data []byte) []byte {
+			var compressed bytes.Buffer
+			writer := brotli.NewWriter(&compressed)
+			writer.Write(data)
+			writer.Close()
+			return compressed.Bytes()
+		}},
+		{name: "gzip", tag: spacetimedb.CompressionTypeGzip, compress: func(data []byte) []byte {
+			var compressed bytes.Buffer
+			writer := gzip.NewWriter(&compressed)
+			writer.Write(data)
+			writer.Close()
+			return compressed.Bytes()
+		}},
+	}
+
+	for _, tt := range compressors {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t)
+			players := spacetimedb.NewTableCache(decodeTestPlayer, (*testPlayer).primaryKey)
+			events := make(chan string, 10)
+			players.OnInsert(func(ctx *spacetimedb.EventContext, row *testPlayer) {
+				events <- "insert " + row.Name
+			})
+			_, ws := server.connect(t, spacetimedb.WithTableNameMap(spacetimedb.TableNameMap{"player": players}))
+
+			// The whole ServerMessage after the compression tag is compressed.
+			message := initialSubscriptionMessage(testTableUpdate{name: "player", inserts: [][]byte{encodeTestPlayer(1, "alice")}})
+			compressed := append([]byte{tt.tag}, tt.compress(message[1:])...)
+			ws.WriteMessage(websocket.BinaryMessage, compressed)
+			if got := waitForEvent(t, events); got != "insert alice" {
+				t.Errorf("event mismatch: got %q, want %q", got, "insert alice")
+			}
+			if row, ok := players.Get("1"); !ok || row.Name != "alice" {
+				t.Errorf("expected alice in the cache, got %+v", row)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+	"github.com/gorilla/websocket"
+)
+
+// newSilentServer starts a websocket server that hands every connection to handle without
+// sending an IdentityToken.
+func newSilentServer(t *testing.T, handle func(ws *websocket.Conn)) string {
+	t.Helper()
+	upgrader := websocket.Upgrader{Subprotocols: []string{"v1.bsatn.spacetimedb"}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("failed to upgrade connection: %v", err)
+			return
+		}
+		defer ws.Close()
+		handle(ws)
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func waitForDone(t *testing.T, db *spacetimedb.DBConnection) {
+	t.Helper()
+	select {
+	case <-db.Done():
+	case <-waitTimeout():
+		t.Fatalf("timed out waiting for the connection to end")
+	}
+}
+
+func TestConnectContextWaitsForIdentity(t *testing.T) {
+	server := newTestServer(t)
+	disconnects := make(chan error, 1)
+	db := spacetimedb.NewDBConnection(
+		spacetimedb.WithHost("ws"+strings.TrimPrefix(server.URL, "http")),
+		spacetimedb.WithNameOrIdentity("test"),
+		spacetimedb.WithLogger(t.Logf),
+		spacetimedb.WithOnDisconnectError(func(conn *spacetimedb.DBConnection, err error) {
+			disconnects <- err
+		}),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := db.ConnectContext(ctx); err != nil {
+		t.Fatalf("ConnectContext failed: %v", err)
+	}
+	<-server.conns
+	if !db.IsConnected() || db.Identity().IsZero() || db.ConnectionId().IsZero() {
+		t.Errorf("expected identity to be set when ConnectContext returns, got %+v", db.State())
+	}
+	select {
+	case <-db.Done():
+		t.Fatalf("expected Done to stay open while connected")
+	default:
+	}
+
+	db.Close()
+	waitForDone(t, db)
+	select {
+	case err := <-disconnects:
+		if err != nil {
+			t.Errorf("expected no disconnect error after Close, got %v", err)
+		}
+	case <-waitTimeout():
+		t.Fatalf("timed out waiting for OnDisconnectError")
+	}
+}
+
+func TestConnectContextTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	host := newSilentServer(t, func(ws *websocket.Conn) {
+		<-release
+	})
+
+	connectErrors := make(chan error, 1)
+	db := spacetimedb.NewDBConnection(
+		spacetimedb.WithHost(host),
+		spacetimedb.WithNameOrIdentity("test"),
+		spacetimedb.WithLogger(t.Logf),
+		spacetimedb.WithOnConnectError(func(err error) {
+			connectErrors <- err
+		}),
+		spacetimedb.WithOnDisconnect(func(conn *spacetimedb.DBConnection) {
+			t.Errorf("OnDisconnect must not run for a connection that was never accepted")
+		}),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := db.ConnectContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	select {
+	case connectErr := <-connectErrors:
+		if !errors.Is(connectErr, context.DeadlineExceeded) {
+			t.Errorf("OnConnectError got %v", connectErr)
+		}
+	case <-waitTimeout():
+		t.Fatalf("timed out waiting for OnConnectError")
+	}
+	waitForDone(t, db)
+}
+
+func TestConnectContextClosedBeforeIdentity(t *testing.T) {
+	host := newSilentServer(t, func(ws *websocket.Conn) {
+		ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(4001, "unauthorized"))
+	})
+
+	db := spacetimedb.NewDBConnection(
+		spacetimedb.WithHost(host),
+		spacetimedb.WithNameOrIdentity("test"),
+		spacetimedb.WithLogger(t.Logf),
+	)
+	err := db.ConnectContext(context.Background())
+	var disconnectErr *spacetimedb.DisconnectError
+	if !errors.As(err, &disconnectErr) || disconnectErr.Code != 4001 || disconnectErr.Reason != "unauthorized" {
+		t.Fatalf("expected close code 4001, got %v", err)
+	}
+	waitForDone(t, db)
+}
+
+func TestDisconnectErrorHasCloseCode(t *testing.T) {
+	server := newTestServer(t)
+	disconnects := make(chan error, 1)
+	db, ws := server.connect(t, spacetimedb.WithOnDisconnectError(func(conn *spacetimedb.DBConnection, err error) {
+		disconnects <- err
+	}))
+
+	ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(4000, "kicked"))
+	select {
+	case err := <-disconnects:
+		var disconnectErr *spacetimedb.DisconnectError
+		if !errors.As(err, &disconnectErr) || disconnectErr.Code != 4000 || disconnectErr.Reason != "kicked" {
+			t.Errorf("expected close code 4000, got %v", err)
+		}
+	case <-waitTimeout():
+		t.Fatalf("timed out waiting for OnDisconnectError")
+	}
+	// Without a reconnect policy the connection ends.
+	waitForDone(t, db)
+}
+
+func TestConnectDialError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	host := "ws" + strings.TrimPrefix(server.URL, "http")
+	server.Close()
+
+	var connectErr error
+	db := spacetimedb.NewDBConnection(
+		spacetimedb.WithHost(host),
+		spacetimedb.WithNameOrIdentity("test"),
+		spacetimedb.WithLogger(t.Logf),
+		spacetimedb.WithOnConnectError(func(err error) {
+			connectErr = err
+		}),
+	)
+	err := db.ConnectContext(context.Background())
+	if err == nil || connectErr != err {
+		t.Errorf("expected the dial error to be returned and passed to OnConnectError, got %v and %v", err, connectErr)
+	}
+	waitForDone(t, db)
+}
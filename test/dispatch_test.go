@@ -0,0 +1,89 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+	"github.com/gorilla/websocket"
+)
+
+// waitForQueuedEvents waits until the connection has queued n events.
+func waitForQueuedEvents(t *testing.T, db *spacetimedb.DBConnection, n int) {
+	t.Helper()
+	timeout := waitTimeout()
+	for len(db.Events()) < n {
+		select {
+		case <-timeout:
+			t.Fatalf("timed out waiting for %d queued events, got %d", n, len(db.Events()))
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func TestFrameTickDispatch(t *testing.T) {
+	server := newTestServer(t)
+	players := spacetimedb.NewTableCache(decodeTestPlayer, (*testPlayer).primaryKey)
+	var events []string
+	players.OnInsert(func(ctx *spacetimedb.EventContext, row *testPlayer) {
+		events = append(events, "insert "+row.Name)
+	})
+	db, ws := server.connect(t,
+		spacetimedb.WithDispatchMode(spacetimedb.DispatchFrameTick),
+		spacetimedb.WithTableNameMap(spacetimedb.TableNameMap{"player": players}),
+		spacetimedb.WithOnConnect(func(conn *spacetimedb.DBConnection, identity spacetimedb.Identity, token string, connectionId spacetimedb.ConnectionId) {
+			events = append(events, "connect")
+		}),
+		spacetimedb.WithOnDisconnect(func(conn *spacetimedb.DBConnection) {
+			events = append(events, "disconnect")
+		}),
+	)
+
+	ws.WriteMessage(websocket.BinaryMessage, transactionUpdateMessage(testTableUpdate{
+		name:    "player",
+		inserts: [][]byte{encodeTestPlayer(1, "alice")},
+	}))
+	waitForQueuedEvents(t, db, 2)
+	if len(events) != 0 || players.Count() != 0 || db.IsConnected() {
+		t.Fatalf("expected nothing to be applied before FrameTick, got events %v and %d rows", events, players.Count())
+	}
+
+	db.FrameTick()
+	if len(events) != 2 || events[0] != "connect" || events[1] != "insert alice" {
+		t.Errorf("events mismatch after FrameTick: %v", events)
+	}
+	if players.Count() != 1 || !db.IsConnected() {
+		t.Errorf("expected the update to be applied, got %d rows", players.Count())
+	}
+
+	// A tick without queued events does nothing.
+	db.FrameTick()
+	if len(events) != 2 {
+		t.Errorf("unexpected events from empty FrameTick: %v", events)
+	}
+
+	ws.Close()
+	select {
+	case event := <-db.Events():
+		if event.Message != nil {
+			t.Fatalf("expected the close event, got %T", event.Message.Message)
+		}
+		if err := db.ProcessEvent(event); err != nil {
+			t.Fatalf("failed to process close event: %v", err)
+		}
+	case <-waitTimeout():
+		t.Fatalf("timed out waiting for close event")
+	}
+	if len(events) != 3 || events[2] != "disconnect" {
+		t.Errorf("expected disconnect to run in ProcessEvent, got %v", events)
+	}
+}
+
+func TestBackgroundDispatchHasNoEvents(t *testing.T) {
+	db := spacetimedb.NewDBConnection(spacetimedb.WithLogger(t.Logf))
+	if db.Events() != nil {
+		t.Errorf("expected no event queue in background dispatch mode")
+	}
+	// FrameTick is a no-op without a queue.
+	db.FrameTick()
+}
@@ -0,0 +1,167 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+)
+
+const testIdentityHex = "c200a784a2e3a7bd5a6c5e8e9d7c1c3b2d1e0f4a5b6c7d8e9f0a1b2c3d4e5f60"
+
+func newTestHTTPServer(t *testing.T, handler http.HandlerFunc) *spacetimedb.HTTPClient {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client := spacetimedb.NewHTTPClient(
+		spacetimedb.WithHost("ws://"+strings.TrimPrefix(server.URL, "http://")),
+		spacetimedb.WithNameOrIdentity("quickstart-chat"),
+		spacetimedb.WithToken("test-token"),
+		spacetimedb.WithLogger(t.Logf),
+	)
+	client.Client = server.Client()
+	return client
+}
+
+func TestHTTPClientRequests(t *testing.T) {
+	schema, err := os.ReadFile(filepath.Join("testdata", "schema.json"))
+	if err != nil {
+		t.Fatalf("failed to read schema: %v", err)
+	}
+
+	client := newTestHTTPServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer test-token" {
+			t.Errorf("authorization header mismatch: got %q", got)
+		}
+		body, _ := io.ReadAll(r.Body)
+
+		switch r.Method + " " + r.URL.Path {
+		case "POST /v1/identity":
+			io.WriteString(w, `{"identity":"`+testIdentityHex+`","token":"new-token"}`)
+		case "GET /v1/database/quickstart-chat/schema":
+			if got := r.URL.Query().Get("version"); got != "9" {
+				t.Errorf("schema version mismatch: got %q", got)
+			}
+			w.Write(schema)
+		case "POST /v1/database/quickstart-chat/sql":
+			if string(body) != "SELECT * FROM user" {
+				t.Errorf("sql body mismatch: got %q", body)
+			}
+			io.WriteString(w, `[{"schema":{"elements":[{"name":{"some":"name"},"algebraic_type":{"String":[]}}]},"rows":[["alice"],["bob"]],"total_duration_micros":42}]`)
+		case "POST /v1/database/quickstart-chat/call/set_name":
+			if got := r.Header.Get("Content-Type"); got != "application/json" {
+				t.Errorf("content type mismatch: got %q", got)
+			}
+			if string(body) != `["alice",3]` {
+				t.Errorf("reducer args mismatch: got %s", body)
+			}
+		case "POST /v1/database/quickstart-chat/call/fail":
+			w.WriteHeader(530)
+			io.WriteString(w, "name must not be empty\n")
+		case "GET /v1/database/quickstart-chat/logs":
+			if got := r.URL.Query().Get("num_lines"); got != "2" {
+				t.Errorf("num_lines mismatch: got %q", got)
+			}
+			io.WriteString(w, `{"level":"Info","ts":1700000000000000,"target":"quickstart","filename":"src/lib.rs","line_number":12,"message":"hello"}`+"\n")
+			io.WriteString(w, `{"level":"Error","ts":1700000000000001,"message":"boom"}`+"\n")
+		case "GET /v1/database/quickstart-chat":
+			io.WriteString(w, `{"database_identity":{"__identity__":"0x`+testIdentityHex+`"},"owner_identity":"`+testIdentityHex+`","host_type":{"Wasm":[]},"initial_program":"abc123"}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	ctx := context.Background()
+
+	created, err := client.CreateIdentity(ctx)
+	if err != nil {
+		t.Fatalf("CreateIdentity failed: %v", err)
+	}
+	if created.Identity.ToHexString() != testIdentityHex || created.Token != "new-token" {
+		t.Errorf("CreateIdentity mismatch: got %s %q", created.Identity.ToHexString(), created.Token)
+	}
+
+	def, err := client.Schema(ctx)
+	if err != nil {
+		t.Fatalf("Schema failed: %v", err)
+	}
+	if len(def.Tables) == 0 || def.Tables[0].Name != "player" {
+		t.Errorf("unexpected schema tables: %+v", def.Tables)
+	}
+
+	results, err := client.SQL(ctx, "SELECT * FROM user")
+	if err != nil {
+		t.Fatalf("SQL failed: %v", err)
+	}
+	if len(results) != 1 || len(results[0].Rows) != 2 || string(results[0].Rows[1][0]) != `"bob"` {
+		t.Errorf("unexpected sql results: %+v", results)
+	}
+	if results[0].Schema.Elements[0].Name != "name" || results[0].TotalDurationMicros != 42 {
+		t.Errorf("unexpected sql schema: %+v", results[0])
+	}
+
+	if err := client.CallReducer(ctx, "set_name", "alice", 3); err != nil {
+		t.Errorf("CallReducer failed: %v", err)
+	}
+	err = client.CallReducer(ctx, "fail")
+	var httpErr *spacetimedb.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != 530 || httpErr.Body != "name must not be empty" {
+		t.Errorf("expected HTTPError with reducer message, got %v", err)
+	}
+
+	records, err := client.Logs(ctx, 2)
+	if err != nil {
+		t.Fatalf("Logs failed: %v", err)
+	}
+	if len(records) != 2 || records[0].Message != "hello" || records[0].LineNumber != 12 || records[1].Level != "Error" {
+		t.Errorf("unexpected log records: %+v", records)
+	}
+	if records[0].Timestamp().MicrosSinceUnixEpoch() != 1700000000000000 {
+		t.Errorf("log timestamp mismatch: got %d", records[0].Timestamp().MicrosSinceUnixEpoch())
+	}
+
+	info, err := client.DatabaseInfo(ctx)
+	if err != nil {
+		t.Fatalf("DatabaseInfo failed: %v", err)
+	}
+	if info.DatabaseIdentity.ToHexString() != testIdentityHex || info.OwnerIdentity.ToHexString() != testIdentityHex {
+		t.Errorf("identity mismatch: %s %s", info.DatabaseIdentity.ToHexString(), info.OwnerIdentity.ToHexString())
+	}
+	if info.HostType != "Wasm" || info.InitialProgram != "abc123" {
+		t.Errorf("unexpected database info: %+v", info)
+	}
+}
+
+func TestHTTPClientLoadsTokenFromStore(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer stored-token" {
+			t.Errorf("authorization header mismatch: got %q", got)
+		}
+		io.WriteString(w, "[]")
+	}))
+	defer server.Close()
+
+	host := "ws://" + strings.TrimPrefix(server.URL, "http://")
+	store := spacetimedb.NewFileTokenStore(filepath.Join(t.TempDir(), "tokens.json"))
+	if err := store.Save(host, "quickstart-chat", "stored-token"); err != nil {
+		t.Fatalf("failed to save token: %v", err)
+	}
+	conn := spacetimedb.NewDBConnection(
+		spacetimedb.WithHost(host),
+		spacetimedb.WithNameOrIdentity("quickstart-chat"),
+		spacetimedb.WithTokenStore(store),
+		spacetimedb.WithLogger(t.Logf),
+	)
+
+	if _, err := conn.HTTPClient().SQL(context.Background(), "SELECT * FROM user"); err != nil {
+		t.Fatalf("SQL failed: %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+)
+
+func TestIdentityValue(t *testing.T) {
+	identity, err := spacetimedb.NewIdentity(testIdentityHex)
+	if err != nil {
+		t.Fatalf("failed to create identity: %v", err)
+	}
+	if identity.ToHexString() != testIdentityHex || identity.String() != testIdentityHex {
+		t.Errorf("unexpected hex string: %s", identity.ToHexString())
+	}
+	fromBigInt, err := spacetimedb.NewIdentity(identity.Data())
+	if err != nil {
+		t.Fatalf("failed to create identity from big.Int: %v", err)
+	}
+	if fromBigInt != identity {
+		t.Errorf("expected identities to be equal: %s != %s", fromBigInt, identity)
+	}
+	if _, err := spacetimedb.NewIdentity(new(big.Int).Lsh(big.NewInt(1), 256)); err == nil {
+		t.Errorf("expected an error for an identity wider than 256 bits")
+	}
+
+	names := map[spacetimedb.Identity]string{identity: "alice"}
+	if names[fromBigInt] != "alice" {
+		t.Errorf("expected identity to work as a map key")
+	}
+
+	writer := spacetimedb.NewBinaryWriter()
+	if err := identity.Serialize(writer); err != nil {
+		t.Fatalf("failed to serialize identity: %v", err)
+	}
+	expected := spacetimedb.NewBinaryWriter()
+	expected.WriteU256(identity.Data())
+	if string(writer.GetBuffer()) != string(expected.GetBuffer()) {
+		t.Errorf("expected the U256 encoding, got %x", writer.GetBuffer())
+	}
+	var decoded spacetimedb.Identity
+	if err := decoded.Deserialize(spacetimedb.NewBinaryReader(writer.GetBuffer())); err != nil || decoded != identity {
+		t.Errorf("unexpected round trip: %s, %v", decoded, err)
+	}
+	if err := decoded.Deserialize(spacetimedb.NewBinaryReader([]byte{1, 2})); err == nil {
+		t.Errorf("expected an error for a short identity")
+	}
+}
+
+func TestConnectionIdValue(t *testing.T) {
+	connectionId := spacetimedb.NewConnectionId(big.NewInt(456))
+	if connectionId.GetData().Int64() != 456 || connectionId.IsZero() {
+		t.Errorf("unexpected connection id: %s", connectionId)
+	}
+	parsed, err := spacetimedb.ConnectionIDFromString(connectionId.ToHexString())
+	if err != nil {
+		t.Fatalf("failed to parse connection id: %v", err)
+	}
+	if parsed != connectionId {
+		t.Errorf("expected connection ids to be equal: %s != %s", parsed, connectionId)
+	}
+	if zero, err := spacetimedb.ConnectionIDFromStringOrNull("00000000000000000000000000000000"); err != nil || zero != nil {
+		t.Errorf("expected nil for a zero connection id, got %v, %v", zero, err)
+	}
+
+	writer := spacetimedb.NewBinaryWriter()
+	if err := connectionId.Serialize(writer); err != nil {
+		t.Fatalf("failed to serialize connection id: %v", err)
+	}
+	expected := spacetimedb.NewBinaryWriter()
+	expected.WriteU128(big.NewInt(456))
+	if string(writer.GetBuffer()) != string(expected.GetBuffer()) {
+		t.Errorf("expected the U128 encoding, got %x", writer.GetBuffer())
+	}
+	decoded := spacetimedb.ReadValue[spacetimedb.ConnectionId](spacetimedb.NewBinaryReader(writer.GetBuffer()))
+	if *decoded != connectionId {
+		t.Errorf("unexpected round trip: %s", decoded)
+	}
+}
+
+func TestIdentityJSON(t *testing.T) {
+	identity, _ := spacetimedb.NewIdentity(testIdentityHex)
+	connectionId := spacetimedb.NewConnectionId(big.NewInt(42))
+
+	data, err := json.Marshal(struct {
+		Identity     spacetimedb.Identity
+		ConnectionId spacetimedb.ConnectionId
+		Names        map[spacetimedb.Identity]string
+	}{identity, connectionId, map[spacetimedb.Identity]string{identity: "alice"}})
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	expected := `{"Identity":{"__identity__":"0x` + testIdentityHex + `"},` +
+		`"ConnectionId":{"__connection_id__":"0x0000000000000000000000000000002a"},` +
+		`"Names":{"` + testIdentityHex + `":"alice"}}`
+	if string(data) != expected {
+		t.Errorf("unexpected JSON:\n got %s\nwant %s", data, expected)
+	}
+
+	var decoded struct {
+		Identity     spacetimedb.Identity
+		ConnectionId spacetimedb.ConnectionId
+		Names        map[spacetimedb.Identity]string
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if decoded.Identity != identity || decoded.ConnectionId != connectionId || decoded.Names[identity] != "alice" {
+		t.Errorf("unexpected decoded JSON: %+v", decoded)
+	}
+
+	for _, input := range []string{`"` + testIdentityHex + `"`, `{"__identity__":"0x` + testIdentityHex + `"}`} {
+		var parsed spacetimedb.Identity
+		if err := json.Unmarshal([]byte(input), &parsed); err != nil || parsed != identity {
+			t.Errorf("failed to unmarshal %s: %s, %v", input, parsed, err)
+		}
+	}
+	for _, input := range []string{
+		`{"__connection_id__":42}`,
+		`"0000000000000000000000000000002a"`,
+		`{"__connection_id__":"0000000000000000000000000000002a"}`,
+		`{"__connection_id__":"0x2a"}`,
+	} {
+		var parsed spacetimedb.ConnectionId
+		if err := json.Unmarshal([]byte(input), &parsed); err != nil || parsed != connectionId {
+			t.Errorf("failed to unmarshal %s: %s, %v", input, parsed, err)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package test
+
+import (
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+	"github.com/alexanderbh/spacetimedb-go-sdk/test/testbindings"
+	"github.com/gorilla/websocket"
+)
+
+const jsonPlayerRow = `{"id":1,"name":"alice","position":{"x":1.5,"y":-2},"tags":["admin"],"avatar":"ab01",` +
+	`"score":"0x10000000000000000","owner":{"some":{"__identity__":"0x0a"}},"nickname":{"none":[]},` +
+	`"last_seen":{"some":{"__timestamp_micros_since_unix_epoch__":1700000000000000}},"path":[{"x":3,"y":4}],` +
+	`"status":{"banned":"spam"},"cooldown":{"__time_duration_micros__":1500},"connection_id":{"__connection_id__":7},` +
+	`"balance":-12345,"alive":true,"level":-3,"ratio":0.25}`
+
+func jsonString(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+func TestJSONProtocol(t *testing.T) {
+	schema, err := os.ReadFile(filepath.Join("testdata", "schema.json"))
+	if err != nil {
+		t.Fatalf("failed to read schema: %v", err)
+	}
+	conns := make(chan *websocket.Conn, 1)
+	upgrader := websocket.Upgrader{Subprotocols: []string{"v1.json.spacetimedb"}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/schema") {
+			w.Write(schema)
+			return
+		}
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("failed to upgrade connection: %v", err)
+			return
+		}
+		if ws.Subprotocol() != "v1.json.spacetimedb" {
+			t.Errorf("expected the JSON subprotocol, got %q", ws.Subprotocol())
+		}
+		ws.WriteMessage(websocket.TextMessage, []byte(`{"IdentityToken":{"identity":{"__identity__":"0x`+testIdentityHex+`"},`+
+			`"token":"json-token","connection_id":{"__connection_id__":"0x2a"}}}`))
+		conns <- ws
+	}))
+	defer server.Close()
+
+	players := testbindings.NewPlayerTable()
+	db := spacetimedb.NewDBConnection(
+		spacetimedb.WithHost("ws"+strings.TrimPrefix(server.URL, "http")),
+		spacetimedb.WithNameOrIdentity("test"),
+		spacetimedb.WithProtocol(spacetimedb.ProtocolJSON),
+		spacetimedb.WithDispatchMode(spacetimedb.DispatchFrameTick),
+		spacetimedb.WithTableNameMap(spacetimedb.TableNameMap{"player": players}),
+		spacetimedb.WithLogger(func(format string, args ...interface{}) {}),
+	)
+	if err := db.Connect(); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer db.Close()
+	ws := <-conns
+	defer ws.Close()
+
+	nextMessage := func() any {
+		t.Helper()
+		select {
+		case event := <-db.Events():
+			if err := db.ProcessEvent(event); err != nil {
+				t.Fatalf("failed to process %T: %v", event.Message.Message, err)
+			}
+			return event.Message.Message
+		case <-waitTimeout():
+			t.Fatalf("timed out waiting for message")
+			return nil
+		}
+	}
+
+	identityToken, ok := nextMessage().(*spacetimedb.IdentityToken)
+	if !ok || identityToken.Identity.ToHexString() != testIdentityHex || identityToken.Token != "json-token" || identityToken.ConnectionId.GetData().Int64() != 42 {
+		t.Fatalf("unexpected IdentityToken: %+v", identityToken)
+	}
+
+	ws.WriteMessage(websocket.TextMessage, []byte(`{"InitialSubscription":{"database_update":{"tables":[{"table_id":4096,`+
+		`"table_name":"player","num_rows":1,"updates":[{"deletes":[],"inserts":[`+jsonString(jsonPlayerRow)+`]}]}]},`+
+		`"request_id":1,"total_host_execution_duration":{"__time_duration_micros__":10}}}`))
+	if _, ok := nextMessage().(*spacetimedb.InitialSubscription); !ok {
+		t.Fatalf("expected InitialSubscription")
+	}
+	row, ok := players.FindById(1)
+	if !ok {
+		t.Fatalf("expected player 1 in the cache")
+	}
+	expectedScore := new(big.Int).Lsh(big.NewInt(1), 64)
+	if row.Name != "alice" || row.Position.X != 1.5 || row.Avatar[0] != 0xab || row.Score.Cmp(expectedScore) != 0 ||
+		row.Owner.Data().Int64() != 10 || row.Nickname != nil || row.LastSeen.MicrosSinceUnixEpoch() != 1700000000000000 ||
+		len(row.Path) != 1 || row.Cooldown.Micros != 1500 || row.Balance.Int64() != -12345 || !row.Alive || row.Level != -3 || row.Ratio != 0.25 {
+		t.Errorf("unexpected row: %+v", row)
+	}
+	if banned, ok := row.Status.Value.(*testbindings.PlayerStatusBanned); !ok || banned.Value != "spam" {
+		t.Errorf("unexpected status: %+v", row.Status.Value)
+	}
+
+	ws.WriteMessage(websocket.TextMessage, []byte(`{"TransactionUpdate":{"status":{"Committed":{"tables":[{"table_id":4096,`+
+		`"table_name":"player","num_rows":1,"updates":[{"deletes":[`+jsonString(jsonPlayerRow)+`],"inserts":[]}]}]}},`+
+		`"timestamp":{"__timestamp_micros_since_unix_epoch__":1700000000000001},"caller_identity":{"__identity__":"0x01"},`+
+		`"caller_connection_id":{"__connection_id__":0},"reducer_call":{"reducer_name":"move_player","reducer_id":1,`+
+		`"args":"[1,{\"x\":3,\"y\":4},\"walk\",[],{\"none\":[]}]","request_id":5},"energy_quanta_used":{"quanta":100},`+
+		`"total_host_execution_duration":{"__time_duration_micros__":20}}}`))
+	update, ok := nextMessage().(*spacetimedb.TransactionUpdate)
+	if !ok {
+		t.Fatalf("expected TransactionUpdate")
+	}
+	if players.Count() != 0 {
+		t.Errorf("expected the player to be deleted, got %d rows", players.Count())
+	}
+	expectedArgs := spacetimedb.NewBinaryWriter()
+	expectedArgs.WriteU64(1)
+	expectedArgs.WriteF32(3)
+	expectedArgs.WriteF32(4)
+	expectedArgs.WriteString("walk")
+	expectedArgs.WriteU32(0)
+	expectedArgs.WriteU8(1)
+	if string(update.ReducerCall.Args) != string(expectedArgs.GetBuffer()) || update.ReducerCall.RequestID != 5 {
+		t.Errorf("unexpected reducer call: %+v", update.ReducerCall)
+	}
+	if update.Timestamp.MicrosSinceUnixEpoch() != 1700000000000001 || update.EnergyQuantaUsed.Quanta.Int64() != 100 {
+		t.Errorf("unexpected transaction update: %+v", update)
+	}
+
+	ws.WriteMessage(websocket.TextMessage, []byte(`{"SubscriptionError":{"total_host_execution_duration_micros":3,`+
+		`"request_id":{"some":7},"query_id":{"none":[]},"table_id":{"none":[]},"error":"bad query"}}`))
+	subscriptionError, ok := nextMessage().(*spacetimedb.SubscriptionError)
+	if !ok || subscriptionError.RequestId == nil || *subscriptionError.RequestId != 7 || subscriptionError.QueryId != nil || subscriptionError.ErrorMessage != "bad query" {
+		t.Errorf("unexpected SubscriptionError: %+v", subscriptionError)
+	}
+}
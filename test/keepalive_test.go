@@ -0,0 +1,86 @@
+package test
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+	"github.com/gorilla/websocket"
+)
+
+func TestKeepaliveDetectsDeadConnection(t *testing.T) {
+	server := newTestServer(t)
+	disconnects := make(chan error, 1)
+	policy := spacetimedb.ReconnectPolicy{InitialDelay: 10 * time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	db, _ := server.connect(t,
+		spacetimedb.WithKeepalive(20*time.Millisecond, 100*time.Millisecond),
+		spacetimedb.WithReconnect(policy),
+		spacetimedb.WithOnDisconnectError(func(conn *spacetimedb.DBConnection, err error) {
+			select {
+			case disconnects <- err:
+			default:
+			}
+		}),
+	)
+
+	// The server side never reads, so pings are not answered, like on a half-open connection.
+	select {
+	case err := <-disconnects:
+		var disconnectErr *spacetimedb.DisconnectError
+		if !errors.As(err, &disconnectErr) || !strings.Contains(err.Error(), "nothing received from the server") {
+			t.Errorf("expected an idle timeout, got %v", err)
+		}
+	case <-waitTimeout():
+		t.Fatalf("timed out waiting for the dead connection to be detected")
+	}
+
+	// The lost connection is reconnected.
+	select {
+	case ws := <-server.conns:
+		ws.Close()
+	case <-waitTimeout():
+		t.Fatalf("timed out waiting for reconnect")
+	}
+	select {
+	case <-db.Done():
+		t.Errorf("expected the connection to be reconnected, not ended")
+	default:
+	}
+}
+
+func TestKeepaliveSendsPings(t *testing.T) {
+	server := newTestServer(t)
+	db, ws := server.connect(t,
+		spacetimedb.WithKeepalive(10*time.Millisecond, 100*time.Millisecond),
+		spacetimedb.WithOnDisconnectError(func(conn *spacetimedb.DBConnection, err error) {
+			if err != nil {
+				t.Errorf("unexpected disconnect: %v", err)
+			}
+		}),
+	)
+
+	var pings atomic.Int32
+	ws.SetPingHandler(func(data string) error {
+		pings.Add(1)
+		return ws.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+	// Reading makes the server answer the pings with pongs.
+	go func() {
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	time.Sleep(300 * time.Millisecond)
+	if !db.IsConnected() {
+		t.Errorf("expected the connection to stay open while pongs arrive")
+	}
+	if pings.Load() < 5 {
+		t.Errorf("expected pings every 10ms, got %d", pings.Load())
+	}
+}
@@ -0,0 +1,69 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+	"github.com/alexanderbh/spacetimedb-go-sdk/spacetimedbtest"
+	"github.com/alexanderbh/spacetimedb-go-sdk/test/testbindings"
+)
+
+func TestLightModeRequestsLightUpdates(t *testing.T) {
+	queries := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries <- r.URL.RawQuery
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	db := spacetimedb.NewDBConnection(
+		spacetimedb.WithHost("ws"+strings.TrimPrefix(server.URL, "http")),
+		spacetimedb.WithNameOrIdentity("test"),
+		spacetimedb.WithLightMode(),
+		spacetimedb.WithLogger(func(format string, args ...interface{}) {}),
+	)
+	db.Connect()
+	defer db.Close()
+
+	select {
+	case query := <-queries:
+		if query != "compression=None&light=true" {
+			t.Errorf("unexpected query: %q", query)
+		}
+	case <-waitTimeout():
+		t.Fatalf("timed out waiting for the connection")
+	}
+}
+
+func TestTransactionUpdateLightUpdatesCache(t *testing.T) {
+	server := spacetimedbtest.NewServer(t)
+	players := testbindings.NewPlayerTable()
+	conn := server.Connect(spacetimedb.WithLightMode(), spacetimedb.WithTableNameMap(spacetimedb.TableNameMap{"player": players}))
+
+	events := make(chan any, 2)
+	players.OnInsert(func(ctx *spacetimedb.EventContext, row *testbindings.Player) { events <- ctx.Event })
+	players.OnDelete(func(ctx *spacetimedb.EventContext, row *testbindings.Player) { events <- ctx.Event })
+
+	if err := server.SendTransactionUpdateLight(spacetimedbtest.Insert("player", newTestBindingsPlayer(1, "alice"))); err != nil {
+		t.Fatalf("failed to send TransactionUpdateLight: %v", err)
+	}
+	if err := server.SendTransactionUpdateLight(spacetimedbtest.Delete("player", newTestBindingsPlayer(1, "alice"))); err != nil {
+		t.Fatalf("failed to send TransactionUpdateLight: %v", err)
+	}
+	for range 2 {
+		select {
+		case event := <-events:
+			if _, ok := event.(*spacetimedb.TransactionUpdateLight); !ok {
+				t.Errorf("expected a TransactionUpdateLight event, got %T", event)
+			}
+		case <-waitTimeout():
+			t.Fatalf("timed out waiting for the cache to be updated")
+		}
+	}
+	if players.Count() != 0 || !conn.IsConnected() {
+		t.Errorf("expected an empty cache on a live connection, got %d rows", players.Count())
+	}
+}
@@ -0,0 +1,239 @@
+package test
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+	"github.com/alexanderbh/spacetimedb-go-sdk/test/testbindings"
+)
+
+type markerPoint struct {
+	X float32
+	Y float32
+}
+
+type markerRed struct{}
+
+type markerGreen struct{}
+
+type markerColor struct {
+	value any
+}
+
+func (c markerColor) SumVariants() []any     { return []any{markerRed{}, markerGreen{}} }
+func (c markerColor) SumValue() any          { return c.value }
+func (c *markerColor) SetSumValue(value any) { c.value = value }
+
+// marshalMarker has the same layout as the generated testbindings.Marker.
+type marshalMarker struct {
+	Color   markerColor
+	At      markerPoint
+	Comment *markerPoint
+	Note    string `bsatn:"-"`
+}
+
+type marshalEverything struct {
+	Bool      bool
+	I8        int8
+	U16       uint16
+	I32       int32
+	U64       uint64
+	F32       float32
+	F64       float64
+	Text      string
+	Bytes     []byte
+	Names     []string
+	Grid      [2][2]int16
+	Scores    map[string]uint32
+	Nickname  *string
+	Missing   *string
+	Big       *big.Int   `bsatn:"u128"`
+	Balances  []*big.Int `bsatn:"i256"`
+	Owner     spacetimedb.Identity
+	Seen      *spacetimedb.Timestamp
+	Cooldown  spacetimedb.TimeDuration
+	Generated *testbindings.Point
+	Tree      marshalTree
+}
+
+type marshalTree struct {
+	Name     string
+	Children []marshalTree
+}
+
+func TestMarshalMatchesGeneratedBindings(t *testing.T) {
+	generated := &testbindings.Marker{
+		Color:   testbindings.Color{Value: &testbindings.ColorGreen{}},
+		At:      testbindings.Point{X: 1, Y: 2},
+		Comment: &testbindings.Point{X: -3, Y: 0.5},
+	}
+	writer := spacetimedb.NewBinaryWriter()
+	if err := generated.Serialize(writer); err != nil {
+		t.Fatalf("failed to serialize marker: %v", err)
+	}
+
+	marker := marshalMarker{
+		Color:   markerColor{value: markerGreen{}},
+		At:      markerPoint{X: 1, Y: 2},
+		Comment: &markerPoint{X: -3, Y: 0.5},
+		Note:    "not encoded",
+	}
+	data, err := spacetimedb.Marshal(marker)
+	if err != nil {
+		t.Fatalf("failed to marshal marker: %v", err)
+	}
+	if !bytes.Equal(data, writer.GetBuffer()) {
+		t.Errorf("encoding mismatch:\ngot  %x\nwant %x", data, writer.GetBuffer())
+	}
+
+	var decoded marshalMarker
+	if err := spacetimedb.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal marker: %v", err)
+	}
+	marker.Note = ""
+	if !reflect.DeepEqual(decoded, marker) {
+		t.Errorf("round trip mismatch:\ngot  %+v\nwant %+v", decoded, marker)
+	}
+
+	// Generated types are encoded with their own methods.
+	data, err = spacetimedb.Marshal(generated)
+	if err != nil {
+		t.Fatalf("failed to marshal generated marker: %v", err)
+	}
+	if !bytes.Equal(data, writer.GetBuffer()) {
+		t.Errorf("encoding mismatch:\ngot  %x\nwant %x", data, writer.GetBuffer())
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	owner, err := spacetimedb.NewIdentity("c200f6a0e1dd7c2ca3a2d0e6bd6f02b6bd5e91c2d9ae0ab6d2e0fa7a6d1c9bd1")
+	if err != nil {
+		t.Fatalf("failed to create identity: %v", err)
+	}
+	nickname := "ace"
+	value := marshalEverything{
+		Bool:      true,
+		I8:        -8,
+		U16:       16,
+		I32:       -32,
+		U64:       1 << 63,
+		F32:       1.5,
+		F64:       -2.25,
+		Text:      "hello",
+		Bytes:     []byte{1, 2, 3},
+		Names:     []string{"a", "b"},
+		Grid:      [2][2]int16{{1, 2}, {3, 4}},
+		Scores:    map[string]uint32{"alice": 3, "bob": 5},
+		Nickname:  &nickname,
+		Big:       new(big.Int).Lsh(big.NewInt(1), 100),
+		Balances:  []*big.Int{big.NewInt(-5), big.NewInt(7)},
+		Owner:     owner,
+		Seen:      spacetimedb.NewTimestamp(1700000000000000),
+		Cooldown:  spacetimedb.TimeDuration{Micros: 250},
+		Generated: &testbindings.Point{X: 4, Y: 5},
+		Tree: marshalTree{Name: "root", Children: []marshalTree{
+			{Name: "leaf", Children: []marshalTree{}},
+		}},
+	}
+
+	data, err := spacetimedb.Marshal(&value)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	again, err := spacetimedb.Marshal(value)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	if !bytes.Equal(data, again) {
+		t.Errorf("encoding of a value and a pointer to it differ")
+	}
+
+	var decoded marshalEverything
+	if err := spacetimedb.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, value) {
+		t.Errorf("round trip mismatch:\ngot  %+v\nwant %+v", decoded, value)
+	}
+}
+
+func TestMarshalOptionTags(t *testing.T) {
+	some := uint8(7)
+	data, err := spacetimedb.Marshal(struct{ Value *uint8 }{Value: &some})
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	if !bytes.Equal(data, []byte{0, 7}) {
+		t.Errorf("Some encoding mismatch: got %x, want 0007", data)
+	}
+	data, err = spacetimedb.Marshal(struct{ Value *uint8 }{})
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	if !bytes.Equal(data, []byte{1}) {
+		t.Errorf("None encoding mismatch: got %x, want 01", data)
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{"int", struct{ Count int }{}, "use a sized integer type"},
+		{"big.Int without size", struct{ Score *big.Int }{Score: big.NewInt(1)}, "needs a bsatn tag"},
+		{"unknown tag option", struct {
+			Score uint8 `bsatn:"u7"`
+		}{}, "unknown option"},
+		{"unexported fields", big.Int{}, "no exported fields"},
+		{"unknown variant", markerColor{value: "blue"}, "unknown variant type"},
+	} {
+		_, err := spacetimedb.Marshal(tc.value)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tc.name, tc.want, err)
+		}
+	}
+
+	var marker marshalMarker
+	for _, tc := range []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"truncated", []byte{0, 0, 0}, "out of bounds"},
+		{"unknown sum tag", []byte{5}, "unknown tag 5"},
+		{"invalid option tag", append(make([]byte, 9), 2), "invalid option tag"},
+		{"trailing bytes", append(make([]byte, 9), 1, 0), "1 bytes left over"},
+	} {
+		err := spacetimedb.Unmarshal(tc.data, &marker)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected error containing %q, got %v", tc.name, tc.want, err)
+		}
+	}
+	if err := spacetimedb.Unmarshal([]byte{1}, marker); err == nil {
+		t.Errorf("expected error unmarshaling into a non-pointer")
+	}
+}
+
+func BenchmarkUnmarshalRow(b *testing.B) {
+	data, err := spacetimedb.Marshal(marshalMarker{
+		Color:   markerColor{value: markerRed{}},
+		At:      markerPoint{X: 1, Y: 2},
+		Comment: &markerPoint{X: 3, Y: 4},
+	})
+	if err != nil {
+		b.Fatalf("failed to marshal: %v", err)
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		var marker marshalMarker
+		if err := spacetimedb.Unmarshal(data, &marker); err != nil {
+			b.Fatalf("failed to unmarshal: %v", err)
+		}
+	}
+}
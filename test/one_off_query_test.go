@@ -0,0 +1,116 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+	"github.com/gorilla/websocket"
+)
+
+type oneOffQueryOutcome struct {
+	result *spacetimedb.OneOffQueryResult
+	err    error
+}
+
+// oneOffQueryResponseMessage builds a OneOffQueryResponse with the rows of tables, or with
+// errorMessage if it is not empty.
+func oneOffQueryResponseMessage(messageId []byte, errorMessage string, tables ...testTableUpdate) []byte {
+	writer := spacetimedb.NewBinaryWriter()
+	writer.WriteU8(spacetimedb.CompressionTypeNone)
+	writer.WriteU8(0x04) // OneOffQueryResponse
+	writer.WriteUInt8Array(messageId)
+	if errorMessage == "" {
+		writer.WriteU8(1) // Error: None
+	} else {
+		writer.WriteU8(0) // Error: Some
+		writer.WriteString(errorMessage)
+	}
+	spacetimedb.WriteArray(writer, tables, func(writer *spacetimedb.BinaryWriter, table testTableUpdate) {
+		writer.WriteString(table.name)
+		writeRowList(writer, table.inserts)
+	})
+	writer.WriteI64(125) // TotalHostExecutionDuration
+	return writer.GetBuffer()
+}
+
+func TestOneOffQuery(t *testing.T) {
+	server := newTestServer(t)
+	db, ws := server.connect(t)
+
+	outcomes := make(chan oneOffQueryOutcome, 1)
+	runQuery := func(sql string) []byte {
+		go func() {
+			result, err := db.OneOffQuery(context.Background(), sql)
+			outcomes <- oneOffQueryOutcome{result: result, err: err}
+		}()
+		tag, reader := readClientMessage(t, ws)
+		if tag != 0x02 {
+			t.Fatalf("expected OneOffQuery, got message type 0x%02x", tag)
+		}
+		messageId := reader.ReadUInt8Array()
+		if query := reader.ReadString(); query != sql {
+			t.Errorf("query mismatch: got %q, want %q", query, sql)
+		}
+		if len(messageId) == 0 {
+			t.Errorf("expected a message id")
+		}
+		return messageId
+	}
+	waitForOutcome := func() oneOffQueryOutcome {
+		select {
+		case outcome := <-outcomes:
+			return outcome
+		case <-waitTimeout():
+			t.Fatalf("timed out waiting for query result")
+			return oneOffQueryOutcome{}
+		}
+	}
+
+	messageId := runQuery("SELECT * FROM user")
+	// A response to another query must not complete this one.
+	ws.WriteMessage(websocket.BinaryMessage, oneOffQueryResponseMessage([]byte("other"), "not ours"))
+	ws.WriteMessage(websocket.BinaryMessage, oneOffQueryResponseMessage(messageId, "", testTableUpdate{
+		name:    "user",
+		inserts: [][]byte{encodeTestPlayer(1, "alice"), encodeTestPlayer(2, "bob")},
+	}))
+
+	outcome := waitForOutcome()
+	if outcome.err != nil {
+		t.Fatalf("OneOffQuery failed: %v", outcome.err)
+	}
+	if outcome.result.TotalHostExecutionDuration.Micros != 125 {
+		t.Errorf("duration mismatch: got %s", outcome.result.TotalHostExecutionDuration)
+	}
+	table := outcome.result.Table("user")
+	if table == nil {
+		t.Fatalf("expected rows of table user, got %+v", outcome.result.Tables)
+	}
+	users := spacetimedb.NewTableCache(decodeTestPlayer, (*testPlayer).primaryKey)
+	rows, err := users.DecodeRows(table.Rows)
+	if err != nil {
+		t.Fatalf("failed to decode rows: %v", err)
+	}
+	if len(rows) != 2 || rows[0].Name != "alice" || rows[1].Id != 2 {
+		t.Errorf("rows mismatch: got %v", rows)
+	}
+	if users.Count() != 0 {
+		t.Errorf("expected decoded rows not to be cached, got %v", users.All())
+	}
+
+	secondId := runQuery("SELECT * FROM nope")
+	if string(secondId) == string(messageId) {
+		t.Errorf("expected a fresh message id, got %x twice", messageId)
+	}
+	ws.WriteMessage(websocket.BinaryMessage, oneOffQueryResponseMessage(secondId, "no such table: nope"))
+	outcome = waitForOutcome()
+	if outcome.err == nil || outcome.err.Error() != "one-off query failed: no such table: nope" {
+		t.Errorf("expected the server's error, got %v", outcome.err)
+	}
+
+	runQuery("SELECT * FROM user")
+	ws.Close()
+	if outcome := waitForOutcome(); outcome.err == nil {
+		t.Errorf("expected an error when the connection closes")
+	}
+}
@@ -88,3 +88,91 @@ func TestParsingTransactionUpdateMessage(t *testing.T) {
 		t.Errorf("reducer call id mismatch: got %d, want %d", transactionUpdate.ReducerCall.ReducerID, wantReducerCall.ReducerID)
 	}
 }
+
+func TestParsingTransactionUpdateLightMessage(t *testing.T) {
+	writer := spacetimedb.NewBinaryWriter()
+	writer.WriteU8(0x02) // TransactionUpdateLight
+	writer.WriteU32(7)
+	writeDatabaseUpdate(writer, []testTableUpdate{{name: "player", inserts: [][]byte{encodeTestPlayer(1, "alice")}}})
+
+	got := &spacetimedb.ServerMessage{}
+	if err := got.Deserialize(spacetimedb.NewBinaryReader(writer.GetBuffer())); err != nil {
+		t.Fatalf("failed to deserialize server message: %v", err)
+	}
+	update, ok := got.Message.(*spacetimedb.TransactionUpdateLight)
+	if !ok {
+		t.Fatalf("failed to cast to spacetimedb.TransactionUpdateLight, got %T", got.Message)
+	}
+	if update.RequestId != 7 {
+		t.Errorf("request id mismatch: got %d, want %d", update.RequestId, 7)
+	}
+	if len(update.Update.Tables) != 1 || update.Update.Tables[0].TableName != "player" {
+		t.Errorf("unexpected tables in update: %v", update.Update)
+	}
+}
+
+func TestParsingOneOffQueryResponseMessage(t *testing.T) {
+	writer := spacetimedb.NewBinaryWriter()
+	writer.WriteU8(0x04) // OneOffQueryResponse
+	writer.WriteUInt8Array([]byte{0x01, 0x02})
+	writer.WriteU8(0x01) // Error: None
+	writer.WriteU32(1)
+	writer.WriteString("player")
+	writeRowList(writer, [][]byte{encodeTestPlayer(1, "alice"), encodeTestPlayer(2, "bob")})
+	writer.WriteI64(42)
+
+	got := &spacetimedb.ServerMessage{}
+	if err := got.Deserialize(spacetimedb.NewBinaryReader(writer.GetBuffer())); err != nil {
+		t.Fatalf("failed to deserialize server message: %v", err)
+	}
+	response, ok := got.Message.(*spacetimedb.OneOffQueryResponse)
+	if !ok {
+		t.Fatalf("failed to cast to spacetimedb.OneOffQueryResponse, got %T", got.Message)
+	}
+	if !bytes.Equal(response.MessageId, []byte{0x01, 0x02}) {
+		t.Errorf("message id mismatch: got %x", response.MessageId)
+	}
+	if response.Error != nil {
+		t.Errorf("expected no error, got %q", *response.Error)
+	}
+	if len(response.Tables) != 1 || response.Tables[0].TableName != "player" {
+		t.Fatalf("unexpected tables in response: %v", response.Tables)
+	}
+	rows, err := response.Tables[0].Rows.Rows()
+	if err != nil {
+		t.Fatalf("failed to split rows: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Errorf("expected 2 rows, got %d", len(rows))
+	}
+	if response.TotalHostExecutionDuration.Micros != 42 {
+		t.Errorf("duration mismatch: got %s", response.TotalHostExecutionDuration)
+	}
+}
+
+func TestParsingUnknownServerMessage(t *testing.T) {
+	got := &spacetimedb.ServerMessage{}
+	if err := got.Deserialize(spacetimedb.NewBinaryReader([]byte{0x2a})); err == nil {
+		t.Errorf("expected an error for unknown message type, got %T", got.Message)
+	}
+}
+
+func TestParsingTruncatedMessage(t *testing.T) {
+	bs, err := hex.DecodeString(identityTokenHex)
+	if err != nil {
+		t.Fatalf("failed to decode hex string: %v", err)
+	}
+
+	for _, length := range []int{1, 2, 10, 40, len(bs) - 1} {
+		reader := spacetimedb.NewBinaryReader(bs[:length])
+		_ = reader.ReadU8() // Read compression type
+
+		got := &spacetimedb.ServerMessage{}
+		if err := got.Deserialize(reader); err == nil {
+			t.Errorf("expected an error when deserializing message truncated to %d bytes", length)
+		}
+		if reader.Err() == nil {
+			t.Errorf("expected the reader to report an error when reading message truncated to %d bytes", length)
+		}
+	}
+}
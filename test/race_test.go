@@ -0,0 +1,123 @@
+package test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+	"github.com/gorilla/websocket"
+)
+
+// TestConcurrentReadsDuringUpdates reads the connection state and the table cache from several
+// goroutines while the connection applies a stream of table updates. Run it with -race.
+func TestConcurrentReadsDuringUpdates(t *testing.T) {
+	const updates = 200
+
+	server := newTestServer(t)
+	players := spacetimedb.NewTableCache(decodeTestPlayer, (*testPlayer).primaryKey)
+	done := make(chan struct{})
+	players.OnInsert(func(ctx *spacetimedb.EventContext, row *testPlayer) {
+		if row.Id == updates {
+			close(done)
+		}
+	})
+	connected := make(chan struct{})
+	db, ws := server.connect(t,
+		spacetimedb.WithTableNameMap(spacetimedb.TableNameMap{"player": players}),
+		spacetimedb.WithOnConnect(func(conn *spacetimedb.DBConnection, identity spacetimedb.Identity, token string, connectionId spacetimedb.ConnectionId) {
+			close(connected)
+		}),
+	)
+
+	subscription := db.NewSubscription()
+	if err := subscription.Subscribe("SELECT * FROM player"); err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	tag, reader := readClientMessage(t, ws)
+	if tag != 0x03 {
+		t.Fatalf("expected SubscribeSingle, got message type 0x%02x", tag)
+	}
+	reader.ReadString()
+	requestId, queryId := reader.ReadU32(), reader.ReadU32()
+
+	// Discard the reducer calls sent by the readers.
+	go func() {
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	stop := make(chan struct{})
+	var readers sync.WaitGroup
+	for i := range 4 {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				state := db.State()
+				if state.IsConnected && (state.Identity.IsZero() || state.ConnectionId.IsZero()) {
+					t.Errorf("connected state without identity: %+v", state)
+				}
+				for _, row := range players.All() {
+					if row.Name != fmt.Sprint("player ", row.Id) {
+						t.Errorf("inconsistent row: %+v", row)
+					}
+					// Look up the row and the one that is about to replace it.
+					for _, id := range []uint32{row.Id, row.Id + 1} {
+						if found, ok := players.Get(fmt.Sprint(id)); ok && found.Id != id {
+							t.Errorf("Get(%d) returned row %+v", id, found)
+						}
+					}
+				}
+				if players.Count() > 1 {
+					t.Errorf("expected at most one row, got %d", players.Count())
+				}
+				subscription.IsActive()
+
+				handle := players.OnUpdate(func(ctx *spacetimedb.EventContext, oldRow, newRow *testPlayer) {})
+				handle.Remove()
+
+				if db.IsConnected() {
+					if err := db.CallReducer("noop", nil, uint32(i), 0); err != nil {
+						t.Errorf("failed to call reducer: %v", err)
+					}
+				}
+			}
+		}()
+	}
+
+	<-connected
+	ws.WriteMessage(websocket.BinaryMessage, subscribeRowsMessage(0x05, requestId, queryId, testTableUpdate{name: "player"}))
+	// Every update replaces the single row of the table with the next player.
+	for id := uint32(1); id <= updates; id++ {
+		update := testTableUpdate{name: "player", inserts: [][]byte{encodeTestPlayer(id, fmt.Sprint("player ", id))}}
+		if id > 1 {
+			update.deletes = [][]byte{encodeTestPlayer(id-1, fmt.Sprint("player ", id-1))}
+		}
+		ws.WriteMessage(websocket.BinaryMessage, transactionUpdateMessage(update))
+	}
+
+	select {
+	case <-done:
+	case <-waitTimeout():
+		t.Fatalf("timed out waiting for updates")
+	}
+	close(stop)
+	readers.Wait()
+
+	if row, ok := players.Get(fmt.Sprint(updates)); !ok || players.Count() != 1 {
+		t.Errorf("expected only player %d in the cache, got %+v and %d rows", updates, row, players.Count())
+	}
+	if !subscription.IsActive() {
+		t.Errorf("expected subscription to be active")
+	}
+}
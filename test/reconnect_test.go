@@ -0,0 +1,328 @@
+package test
+
+import (
+	"encoding/hex"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+	"github.com/alexanderbh/spacetimedb-go-sdk/spacetimedbtest"
+	"github.com/alexanderbh/spacetimedb-go-sdk/test/testbindings"
+	"github.com/gorilla/websocket"
+)
+
+const identityTokenHex = "00030cf431a3f5d27800aaec59ec8ec09883b2f9a9a9b41480056a0487210af600c28201000065794a30655841694f694a4b563151694c434a68624763694f694a46557a49314e694a392e65794a6f5a5868666157526c626e527064486b694f694a6a4d6a41775a6a5977595449784f4463774e445a684d4455344d444530596a52684f5745355a6a6c694d6a677a4f54686a4d44686c5a574d314f57566a595745774d4463345a444a6d4e57457a4d7a466d4e44426a4969776963335669496a6f694d6d56684d5449305a4441745a444935597930305a4759774c546b774f4451745a4745324d6a4e6a4e3259304e5756694969776961584e7a496a6f696247396a5957786f62334e30496977695958566b496a7062496e4e7759574e6c64476c745a575269496c3073496d6c68644349364d5463304f44417a4e4441324f4377695a586877496a70756457787366512e5a4a4b6e734f633854736e547033584a576473535a64755379315876366f2d55585f494672334a4f7a6e7072723970424f68497549426e6b4c5a5f6c6f34777351306863523667624476446672444857686a63664167daa835213b727f77283d0ff5d51884c1"
+
+type recordedConnection struct {
+	authorization string
+	messages      [][]byte
+}
+
+func TestReconnectResendsTokenAndSubscriptions(t *testing.T) {
+	identityToken, err := hex.DecodeString(identityTokenHex)
+	if err != nil {
+		t.Fatalf("failed to decode hex string: %v", err)
+	}
+
+	connections := make(chan *recordedConnection, 2)
+	upgrader := websocket.Upgrader{Subprotocols: []string{"v1.bsatn.spacetimedb"}}
+	var connectionCount atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("failed to upgrade connection: %v", err)
+			return
+		}
+		defer ws.Close()
+		count := connectionCount.Add(1)
+
+		recorded := &recordedConnection{authorization: r.Header.Get("Authorization")}
+		ws.WriteMessage(websocket.BinaryMessage, identityToken)
+		_, msg, err := ws.ReadMessage()
+		if err == nil {
+			recorded.messages = append(recorded.messages, msg)
+		}
+		connections <- recorded
+
+		if count > 1 {
+			// Keep the second connection open until the client closes it.
+			ws.ReadMessage()
+		}
+	}))
+	defer server.Close()
+
+	subscribed := false
+	policy := spacetimedb.ReconnectPolicy{InitialDelay: 10 * time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	db := spacetimedb.NewDBConnection(
+		spacetimedb.WithHost("ws"+strings.TrimPrefix(server.URL, "http")),
+		spacetimedb.WithNameOrIdentity("test"),
+		spacetimedb.WithReconnect(policy),
+		spacetimedb.WithLogger(func(format string, args ...interface{}) {}),
+		spacetimedb.WithOnConnect(func(conn *spacetimedb.DBConnection, identity spacetimedb.Identity, token string, connectionId spacetimedb.ConnectionId) {
+			if !subscribed {
+				subscribed = true
+				conn.Subscribe("SELECT * FROM user")
+			}
+		}),
+	)
+	if err := db.Connect(); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer db.Close()
+
+	first := waitForConnection(t, connections)
+	if first.authorization != "" {
+		t.Errorf("expected no authorization header on first connection, got %q", first.authorization)
+	}
+	second := waitForConnection(t, connections)
+	if !strings.HasPrefix(second.authorization, "Bearer ey") {
+		t.Errorf("expected saved token to be sent on reconnect, got %q", second.authorization)
+	}
+	if len(first.messages) != 1 || len(second.messages) != 1 {
+		t.Fatalf("expected one subscribe message per connection, got %d and %d", len(first.messages), len(second.messages))
+	}
+	// The replayed Subscribe has the same queries but a new request ID in its last four bytes.
+	firstQueries := first.messages[0][:len(first.messages[0])-4]
+	secondQueries := second.messages[0][:len(second.messages[0])-4]
+	if string(firstQueries) != string(secondQueries) {
+		t.Errorf("expected subscription to be replayed on reconnect: got %x, want %x", secondQueries, firstQueries)
+	}
+}
+
+func TestReconnectPolicyDelay(t *testing.T) {
+	policy := spacetimedb.ReconnectPolicy{
+		InitialDelay: 100 * time.Millisecond,
+		MaxDelay:     time.Second,
+		Multiplier:   2,
+		Jitter:       0.5,
+	}
+	for attempt := 0; attempt < 10; attempt++ {
+		want := min(100*time.Millisecond<<attempt, time.Second)
+		got := policy.Delay(attempt)
+		if got > want || got < want/2 {
+			t.Errorf("attempt %d: delay %s outside of [%s, %s]", attempt, got, want/2, want)
+		}
+	}
+}
+
+func TestReconnectPolicyDelayWithoutMaxDelay(t *testing.T) {
+	// Without MaxDelay the backoff grows until it no longer fits in a Duration, and must stay at the
+	// largest one instead of overflowing to a negative delay.
+	for _, jitter := range []float64{0, 0.5} {
+		policy := spacetimedb.ReconnectPolicy{InitialDelay: 100 * time.Millisecond, Multiplier: 2, Jitter: jitter}
+		for _, attempt := range []int{62, 100, 10000} {
+			got := policy.Delay(attempt)
+			if got < time.Duration(math.MaxInt64)/2 {
+				t.Errorf("jitter %v, attempt %d: delay %s, want at least %s", jitter, attempt, got, time.Duration(math.MaxInt64)/2)
+			}
+		}
+	}
+}
+
+func waitForConnection(t *testing.T, connections chan *recordedConnection) *recordedConnection {
+	t.Helper()
+	select {
+	case c := <-connections:
+		return c
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for connection")
+		return nil
+	}
+}
+
+// replayedSubscribe waits for a subscribe message including query that is not first, which is
+// the subscription replayed after a reconnect.
+func replayedSubscribe(server *spacetimedbtest.Server, query string, first any) any {
+	return server.WaitForMessage(func(message any) bool {
+		if message == first {
+			return false
+		}
+		switch msg := message.(type) {
+		case *spacetimedb.Subscribe:
+			return len(msg.QueryStrings) == 1 && msg.QueryStrings[0] == query
+		case *spacetimedb.SubscribeSingle:
+			return msg.Query == query
+		}
+		return false
+	})
+}
+
+func TestReconnectReplayedSubscriptionError(t *testing.T) {
+	server := spacetimedbtest.NewServer(t)
+	players := testbindings.NewPlayerTable()
+	policy := spacetimedb.ReconnectPolicy{InitialDelay: 10 * time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	conn := server.Connect(
+		spacetimedb.WithReconnect(policy),
+		spacetimedb.WithTableNameMap(spacetimedb.TableNameMap{"player": players}),
+	)
+	events := make(chan string, 10)
+	players.OnInsert(func(ctx *spacetimedb.EventContext, row *testbindings.Player) { events <- "insert " + row.Name })
+	players.OnDelete(func(ctx *spacetimedb.EventContext, row *testbindings.Player) { events <- "delete " + row.Name })
+
+	subscription := conn.NewSubscription()
+	if err := subscription.Subscribe("SELECT * FROM player"); err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	first := server.WaitForSubscribe("SELECT * FROM player")
+	if err := server.SendSubscribeApplied(first, spacetimedbtest.Insert("player", newTestBindingsPlayer(1, "alice"))); err != nil {
+		t.Fatalf("failed to send SubscribeApplied: %v", err)
+	}
+	if event := waitForEvent(t, events); event != "insert alice" {
+		t.Fatalf("expected insert alice, got %q", event)
+	}
+
+	server.CloseConnections()
+	replayed := replayedSubscribe(server, "SELECT * FROM player", first).(*spacetimedb.SubscribeSingle)
+	err := server.Send(&spacetimedb.SubscriptionError{
+		TotalHostExecutionDuration: spacetimedb.NewTimeDuration(0),
+		RequestId:                  &replayed.RequestId,
+		QueryId:                    &replayed.QueryId,
+		ErrorMessage:               "table dropped",
+	})
+	if err != nil {
+		t.Fatalf("failed to send SubscriptionError: %v", err)
+	}
+	// The failed subscription no longer holds alice, and the cache is no longer being rebuilt.
+	if event := waitForEvent(t, events); event != "delete alice" {
+		t.Fatalf("expected delete alice, got %q", event)
+	}
+	if err := server.SendTransactionUpdate(spacetimedbtest.Insert("player", newTestBindingsPlayer(2, "bob"))); err != nil {
+		t.Fatalf("failed to send TransactionUpdate: %v", err)
+	}
+	if event := waitForEvent(t, events); event != "insert bob" {
+		t.Fatalf("expected insert bob after the failed replay, got %q", event)
+	}
+}
+
+func TestReconnectEndsUnsubscribingSubscription(t *testing.T) {
+	server := spacetimedbtest.NewServer(t)
+	players := testbindings.NewPlayerTable()
+	policy := spacetimedb.ReconnectPolicy{InitialDelay: 10 * time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	conn := server.Connect(
+		spacetimedb.WithReconnect(policy),
+		spacetimedb.WithTableNameMap(spacetimedb.TableNameMap{"player": players}),
+	)
+	events := make(chan string, 10)
+	players.OnInsert(func(ctx *spacetimedb.EventContext, row *testbindings.Player) { events <- "insert " + row.Name })
+	players.OnDelete(func(ctx *spacetimedb.EventContext, row *testbindings.Player) { events <- "delete " + row.Name })
+
+	subscription := conn.NewSubscription()
+	subscription.OnEnd(func(ctx *spacetimedb.EventContext) { events <- "ended" })
+	if err := subscription.Subscribe("SELECT * FROM player"); err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	first := server.WaitForSubscribe("SELECT * FROM player")
+	if err := server.SendSubscribeApplied(first, spacetimedbtest.Insert("player", newTestBindingsPlayer(1, "alice"))); err != nil {
+		t.Fatalf("failed to send SubscribeApplied: %v", err)
+	}
+	if event := waitForEvent(t, events); event != "insert alice" {
+		t.Fatalf("expected insert alice, got %q", event)
+	}
+
+	// The connection is lost before the unsubscribe is applied, so the subscription is not replayed
+	// and ends once the cache has been rebuilt.
+	if err := subscription.Unsubscribe(); err != nil {
+		t.Fatalf("failed to unsubscribe: %v", err)
+	}
+	server.CloseConnections()
+	for _, want := range []string{"delete alice", "ended"} {
+		if event := waitForEvent(t, events); event != want {
+			t.Errorf("expected %s, got %q", want, event)
+		}
+	}
+	if !subscription.IsEnded() {
+		t.Errorf("expected subscription to have ended")
+	}
+}
+
+// eventsUntil returns the events received before last, which is not included.
+func eventsUntil(t *testing.T, events chan string, last string) []string {
+	t.Helper()
+	var received []string
+	for {
+		event := waitForEvent(t, events)
+		if event == last {
+			sort.Strings(received)
+			return received
+		}
+		received = append(received, event)
+	}
+}
+
+// reconnectPlayers connects to server with a reconnect policy, subscribes to the player table and
+// caches rows, then drops the connection. It returns the replayed Subscribe, which has not been
+// answered yet, and the channel receiving the insert and delete events of the table.
+func reconnectPlayers(t *testing.T, server *spacetimedbtest.Server, rows ...any) (*spacetimedb.Subscribe, chan string) {
+	t.Helper()
+	players := testbindings.NewPlayerTable()
+	policy := spacetimedb.ReconnectPolicy{InitialDelay: 10 * time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	conn := server.Connect(
+		spacetimedb.WithReconnect(policy),
+		spacetimedb.WithTableNameMap(spacetimedb.TableNameMap{"player": players}),
+	)
+	events := make(chan string, 20)
+	players.OnInsert(func(ctx *spacetimedb.EventContext, row *testbindings.Player) { events <- "insert " + row.Name })
+	players.OnDelete(func(ctx *spacetimedb.EventContext, row *testbindings.Player) { events <- "delete " + row.Name })
+
+	if err := conn.Subscribe("SELECT * FROM player"); err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	first := server.WaitForSubscribe("SELECT * FROM player")
+	if err := server.SendSubscribeApplied(first, spacetimedbtest.Insert("player", rows...)); err != nil {
+		t.Fatalf("failed to send InitialSubscription: %v", err)
+	}
+	for range rows {
+		waitForEvent(t, events)
+	}
+
+	server.CloseConnections()
+	return replayedSubscribe(server, "SELECT * FROM player", first).(*spacetimedb.Subscribe), events
+}
+
+func TestReconnectReconcilesCache(t *testing.T) {
+	server := spacetimedbtest.NewServer(t)
+	alice, bob, carol := newTestBindingsPlayer(1, "alice"), newTestBindingsPlayer(2, "bob"), newTestBindingsPlayer(3, "carol")
+	replayed, events := reconnectPlayers(t, server, alice, bob)
+
+	// Alice was deleted and carol inserted while the client was offline.
+	if err := server.SendSubscribeApplied(replayed, spacetimedbtest.Insert("player", bob, carol)); err != nil {
+		t.Fatalf("failed to answer the replayed subscription: %v", err)
+	}
+	// The update after the reconcile marks the end of its events.
+	if err := server.SendTransactionUpdate(spacetimedbtest.Insert("player", newTestBindingsPlayer(4, "dave"))); err != nil {
+		t.Fatalf("failed to send TransactionUpdate: %v", err)
+	}
+	received := eventsUntil(t, events, "insert dave")
+	if want := []string{"delete alice", "insert carol"}; !reflect.DeepEqual(received, want) {
+		t.Errorf("unexpected reconcile events: got %v, want %v", received, want)
+	}
+}
+
+func TestReconnectDefersTransactionUpdates(t *testing.T) {
+	server := spacetimedbtest.NewServer(t)
+	alice, bob, carol := newTestBindingsPlayer(1, "alice"), newTestBindingsPlayer(2, "bob"), newTestBindingsPlayer(3, "carol")
+	replayed, events := reconnectPlayers(t, server, alice)
+
+	// Carol is inserted before the replayed subscription is applied. Applying it to the cache right
+	// away would insert carol and then delete her again, as she is missing from the replayed rows.
+	if err := server.SendTransactionUpdate(spacetimedbtest.Insert("player", carol)); err != nil {
+		t.Fatalf("failed to send TransactionUpdate: %v", err)
+	}
+	if err := server.SendSubscribeApplied(replayed, spacetimedbtest.Insert("player", alice, bob)); err != nil {
+		t.Fatalf("failed to answer the replayed subscription: %v", err)
+	}
+	if err := server.SendTransactionUpdate(spacetimedbtest.Insert("player", newTestBindingsPlayer(4, "dave"))); err != nil {
+		t.Fatalf("failed to send TransactionUpdate: %v", err)
+	}
+	received := eventsUntil(t, events, "insert dave")
+	if want := []string{"insert bob", "insert carol"}; !reflect.DeepEqual(received, want) {
+		t.Errorf("unexpected reconcile events: got %v, want %v", received, want)
+	}
+}
@@ -0,0 +1,94 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+	"github.com/gorilla/websocket"
+)
+
+type reducerCallOutcome struct {
+	result *spacetimedb.ReducerResult
+	err    error
+}
+
+func TestCallReducerAsync(t *testing.T) {
+	server := newTestServer(t)
+	connected := make(chan struct{})
+	db, ws := server.connect(t, spacetimedb.WithOnConnect(func(conn *spacetimedb.DBConnection, identity spacetimedb.Identity, token string, connectionId spacetimedb.ConnectionId) {
+		close(connected)
+	}))
+	<-connected
+
+	outcomes := make(chan reducerCallOutcome, 2)
+	for _, name := range []string{"first", "second"} {
+		go func() {
+			args := spacetimedb.NewBinaryWriter()
+			args.WriteString(name)
+			result, err := db.CallReducerAsync(context.Background(), "set_name", args.GetBuffer())
+			outcomes <- reducerCallOutcome{result: result, err: err}
+		}()
+	}
+
+	requestIds := make(map[string]uint32)
+	for range 2 {
+		tag, reader := readClientMessage(t, ws)
+		if tag != 0x00 {
+			t.Fatalf("expected CallReducer, got message type 0x%02x", tag)
+		}
+		if reducer := reader.ReadString(); reducer != "set_name" {
+			t.Errorf("reducer mismatch: got %q", reducer)
+		}
+		args := spacetimedb.NewBinaryReader(reader.ReadUInt8Array())
+		requestIds[args.ReadString()] = reader.ReadU32()
+	}
+	if requestIds["first"] == requestIds["second"] {
+		t.Fatalf("expected reducer calls to get different request ids, got %v", requestIds)
+	}
+
+	// Answer in reverse order, and send an update for another client's call with the same request id first.
+	connectionId := identityTokenConnectionId(t)
+	ws.WriteMessage(websocket.BinaryMessage, reducerTransactionUpdateMessage(requestIds["second"], make([]byte, 16), "not ours"))
+	ws.WriteMessage(websocket.BinaryMessage, reducerTransactionUpdateMessage(requestIds["second"], connectionId, "name taken"))
+	ws.WriteMessage(websocket.BinaryMessage, reducerTransactionUpdateMessage(requestIds["first"], connectionId, ""))
+
+	var committed, failed int
+	for range 2 {
+		select {
+		case outcome := <-outcomes:
+			if outcome.err != nil {
+				t.Fatalf("reducer call returned error: %v", outcome.err)
+			}
+			if outcome.result.TotalHostExecutionDuration.Micros != 250 {
+				t.Errorf("duration mismatch: got %s", outcome.result.TotalHostExecutionDuration)
+			}
+			if outcome.result.Committed() {
+				committed++
+			} else if err := outcome.result.Err(); err != nil && err.Error() == "reducer failed: name taken" {
+				failed++
+			} else {
+				t.Errorf("unexpected reducer result error: %v", err)
+			}
+		case <-waitTimeout():
+			t.Fatalf("timed out waiting for reducer result")
+		}
+	}
+	if committed != 1 || failed != 1 {
+		t.Errorf("expected one committed and one failed call, got %d and %d", committed, failed)
+	}
+}
+
+func TestCallReducerAsyncContextDone(t *testing.T) {
+	server := newTestServer(t)
+	db, _ := server.connect(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := db.CallReducerAsync(ctx, "set_name", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
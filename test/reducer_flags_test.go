@@ -0,0 +1,60 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+	"github.com/alexanderbh/spacetimedb-go-sdk/spacetimedbtest"
+	"github.com/alexanderbh/spacetimedb-go-sdk/test/testbindings"
+)
+
+func TestDefaultReducerFlags(t *testing.T) {
+	server := spacetimedbtest.NewServer(t)
+	conn := server.Connect(spacetimedb.WithDefaultReducerFlags(spacetimedb.NoSuccessNotify))
+
+	if err := testbindings.MovePlayer(conn, 1, testbindings.Point{X: 3, Y: 4}, "walk", nil, nil); err != nil {
+		t.Fatalf("failed to call reducer: %v", err)
+	}
+	call := server.WaitForReducerCall("move_player")
+	if call.Flags != spacetimedb.NoSuccessNotify {
+		t.Errorf("expected NoSuccessNotify, got %d", call.Flags)
+	}
+
+	// A failed call made without waiting has no pending call to resolve.
+	if err := server.SendReducerResult(call, "player is frozen"); err != nil {
+		t.Fatalf("failed to send reducer result: %v", err)
+	}
+
+	// A call waiting for its result keeps the flag and returns as soon as it is sent.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result, err := testbindings.ResetAsync(ctx, conn)
+	if !errors.Is(err, spacetimedb.ErrNoSuccessNotify) || result != nil {
+		t.Fatalf("expected ErrNoSuccessNotify, got %+v, %v", result, err)
+	}
+	if call := server.WaitForReducerCall("reset"); call.Flags != spacetimedb.NoSuccessNotify {
+		t.Errorf("expected NoSuccessNotify for a call waiting for its result, got %d", call.Flags)
+	}
+}
+
+func TestCallReducerFlagsRoundTrip(t *testing.T) {
+	writer := spacetimedb.NewBinaryWriter()
+	call := &spacetimedb.CallReducer{Reducer: "move_player", Args: []byte{1}, RequestId: 2, Flags: spacetimedb.NoSuccessNotify}
+	if err := call.Serialize(writer); err != nil {
+		t.Fatalf("failed to serialize: %v", err)
+	}
+	buffer := writer.GetBuffer()
+	if buffer[len(buffer)-1] != 1 {
+		t.Errorf("expected flags byte 1, got %d", buffer[len(buffer)-1])
+	}
+	var decoded spacetimedb.CallReducer
+	if err := decoded.Deserialize(spacetimedb.NewBinaryReader(buffer)); err != nil {
+		t.Fatalf("failed to deserialize: %v", err)
+	}
+	if decoded.Flags != spacetimedb.NoSuccessNotify {
+		t.Errorf("expected NoSuccessNotify, got %d", decoded.Flags)
+	}
+}
@@ -0,0 +1,202 @@
+package test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+	"github.com/gorilla/websocket"
+)
+
+// testServer is a websocket server that sends an IdentityToken to every client that connects
+// and hands the server side of the connection to the test.
+type testServer struct {
+	*httptest.Server
+	conns chan *websocket.Conn
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	identityToken, err := hex.DecodeString(identityTokenHex)
+	if err != nil {
+		t.Fatalf("failed to decode hex string: %v", err)
+	}
+
+	server := &testServer{conns: make(chan *websocket.Conn, 1)}
+	upgrader := websocket.Upgrader{Subprotocols: []string{"v1.bsatn.spacetimedb"}}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("failed to upgrade connection: %v", err)
+			return
+		}
+		ws.WriteMessage(websocket.BinaryMessage, identityToken)
+		server.conns <- ws
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// connect connects a new DBConnection to the server and returns it with the server side of the websocket.
+func (s *testServer) connect(t *testing.T, opts ...spacetimedb.DBConnectionOption) (*spacetimedb.DBConnection, *websocket.Conn) {
+	t.Helper()
+	opts = append([]spacetimedb.DBConnectionOption{
+		spacetimedb.WithHost("ws" + strings.TrimPrefix(s.URL, "http")),
+		spacetimedb.WithNameOrIdentity("test"),
+		spacetimedb.WithLogger(func(format string, args ...interface{}) {}),
+	}, opts...)
+	db := spacetimedb.NewDBConnection(opts...)
+	if err := db.Connect(); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(db.Close)
+
+	select {
+	case ws := <-s.conns:
+		t.Cleanup(func() { ws.Close() })
+		return db, ws
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for connection")
+		return nil, nil
+	}
+}
+
+type testTableUpdate struct {
+	name    string
+	deletes [][]byte
+	inserts [][]byte
+}
+
+func writeRowList(writer *spacetimedb.BinaryWriter, rows [][]byte) {
+	writer.WriteU8(0x01) // RowSizeHint RowOffsets
+	offset := uint64(0)
+	spacetimedb.WriteArray(writer, rows, func(writer *spacetimedb.BinaryWriter, row []byte) {
+		writer.WriteU64(offset)
+		offset += uint64(len(row))
+	})
+	writer.WriteUInt8Array(bytes.Join(rows, nil))
+}
+
+func writeDatabaseUpdate(writer *spacetimedb.BinaryWriter, tables []testTableUpdate) {
+	spacetimedb.WriteArray(writer, tables, writeTableUpdate)
+}
+
+func initialSubscriptionMessage(tables ...testTableUpdate) []byte {
+	writer := spacetimedb.NewBinaryWriter()
+	writer.WriteU8(spacetimedb.CompressionTypeNone)
+	writer.WriteU8(0x00) // InitialSubscription
+	writeDatabaseUpdate(writer, tables)
+	writer.WriteU32(0)
+	writer.WriteI64(0)
+	return writer.GetBuffer()
+}
+
+func transactionUpdateMessage(tables ...testTableUpdate) []byte {
+	return reducerTransactionUpdateMessage(0, make([]byte, 16), "", tables...)
+}
+
+// reducerTransactionUpdateMessage builds the TransactionUpdate answering a reducer call.
+// The status is Failed with errorMessage, or Committed if errorMessage is empty.
+func reducerTransactionUpdateMessage(requestId uint32, callerConnectionId []byte, errorMessage string, tables ...testTableUpdate) []byte {
+	writer := spacetimedb.NewBinaryWriter()
+	writer.WriteU8(spacetimedb.CompressionTypeNone)
+	writer.WriteU8(0x01) // TransactionUpdate
+	if errorMessage == "" {
+		writer.WriteU8(0x00) // UpdateStatus Committed
+		writeDatabaseUpdate(writer, tables)
+	} else {
+		writer.WriteU8(0x01) // UpdateStatus Failed
+		writer.WriteString(errorMessage)
+	}
+	writer.WriteI64(0)             // Timestamp
+	writer.WriteU256(new(big.Int)) // CallerIdentity
+	for _, b := range callerConnectionId {
+		writer.WriteU8(b)
+	}
+	writer.WriteString("test_reducer")
+	writer.WriteU32(0)
+	writer.WriteUInt8Array(nil)
+	writer.WriteU32(requestId)
+	writer.WriteU128(big.NewInt(1000)) // EnergyQuantaUsed
+	writer.WriteI64(250)               // TotalHostExecutionDuration
+	return writer.GetBuffer()
+}
+
+// identityTokenConnectionId returns the BSATN encoded ConnectionId sent by the test server.
+func identityTokenConnectionId(t *testing.T) []byte {
+	t.Helper()
+	connectionId, err := hex.DecodeString(identityTokenHex[len(identityTokenHex)-32:])
+	if err != nil {
+		t.Fatalf("failed to decode hex string: %v", err)
+	}
+	return connectionId
+}
+
+func waitTimeout() <-chan time.Time {
+	return time.After(5 * time.Second)
+}
+
+func waitForEvent(t *testing.T, events chan string) string {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-waitTimeout():
+		t.Fatalf("timed out waiting for event")
+		return ""
+	}
+}
+
+func writeTableUpdate(writer *spacetimedb.BinaryWriter, table testTableUpdate) {
+	writer.WriteU32(0)
+	writer.WriteString(table.name)
+	writer.WriteU64(uint64(len(table.deletes) + len(table.inserts)))
+	writer.WriteU32(1)
+	writer.WriteU8(0x00) // Uncompressed QueryUpdate
+	writeRowList(writer, table.deletes)
+	writeRowList(writer, table.inserts)
+}
+
+func subscribeRowsMessage(tag uint8, requestId uint32, queryId uint32, table testTableUpdate) []byte {
+	writer := spacetimedb.NewBinaryWriter()
+	writer.WriteU8(spacetimedb.CompressionTypeNone)
+	writer.WriteU8(tag)
+	writer.WriteU32(requestId)
+	writer.WriteU64(0) // TotalHostExecutionDuration
+	writer.WriteU32(queryId)
+	writer.WriteU32(0) // TableId
+	writer.WriteString(table.name)
+	writeTableUpdate(writer, table)
+	return writer.GetBuffer()
+}
+
+func subscriptionErrorMessage(queryId uint32, message string) []byte {
+	writer := spacetimedb.NewBinaryWriter()
+	writer.WriteU8(spacetimedb.CompressionTypeNone)
+	writer.WriteU8(0x07) // SubscriptionError
+	writer.WriteU64(0)   // TotalHostExecutionDuration
+	writer.WriteU8(1)    // RequestId: None
+	writer.WriteU8(0)    // QueryId: Some
+	writer.WriteU32(queryId)
+	writer.WriteU8(1) // TableId: None
+	writer.WriteString(message)
+	return writer.GetBuffer()
+}
+
+// readClientMessage reads the next message sent by the client and returns its type tag and a reader for the rest.
+func readClientMessage(t *testing.T, ws *websocket.Conn) (uint8, *spacetimedb.BinaryReader) {
+	t.Helper()
+	ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, msg, err := ws.ReadMessage()
+	if err != nil {
+		t.Fatalf("failed to read client message: %v", err)
+	}
+	reader := spacetimedb.NewBinaryReader(msg)
+	return reader.ReadU8(), reader
+}
@@ -0,0 +1,135 @@
+package test
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+	"github.com/alexanderbh/spacetimedb-go-sdk/spacetimedbtest"
+	"github.com/alexanderbh/spacetimedb-go-sdk/test/testbindings"
+)
+
+func newTestBindingsPlayer(id uint64, name string) *testbindings.Player {
+	owner, _ := spacetimedb.NewIdentity(big.NewInt(int64(id)))
+	return &testbindings.Player{
+		Id:           id,
+		Name:         name,
+		Position:     testbindings.Point{X: 1, Y: 2},
+		Score:        big.NewInt(0),
+		Owner:        &owner,
+		Status:       testbindings.PlayerStatus{Value: &testbindings.PlayerStatusBanned{Value: "spam"}},
+		Cooldown:     spacetimedb.NewTimeDuration(0),
+		ConnectionId: spacetimedb.NewConnectionId(big.NewInt(1)),
+		Balance:      big.NewInt(0),
+	}
+}
+
+func TestFakeServer(t *testing.T) {
+	identity, err := spacetimedb.NewIdentity(testIdentityHex)
+	if err != nil {
+		t.Fatalf("failed to create identity: %v", err)
+	}
+	connectionId := spacetimedb.NewConnectionId(big.NewInt(99))
+	server := spacetimedbtest.NewServer(t, spacetimedbtest.WithIdentityToken(identity, "fake-token", connectionId))
+
+	players := testbindings.NewPlayerTable()
+	conn := server.Connect(spacetimedb.WithTableNameMap(spacetimedb.TableNameMap{"player": players}))
+	if conn.Token() != "fake-token" || !conn.Identity().IsEqual(identity) || !conn.ConnectionId().IsEqual(connectionId) {
+		t.Fatalf("unexpected connection state: %+v", conn.State())
+	}
+
+	applied := make(chan struct{})
+	subscription := conn.NewSubscription()
+	subscription.OnApplied(func(ctx *spacetimedb.EventContext) { close(applied) })
+	if err := subscription.Subscribe("SELECT * FROM player"); err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	subscribe := server.WaitForSubscribe("SELECT * FROM player")
+	if err := server.SendSubscribeApplied(subscribe, spacetimedbtest.Insert("player", newTestBindingsPlayer(1, "alice"))); err != nil {
+		t.Fatalf("failed to send SubscribeApplied: %v", err)
+	}
+	select {
+	case <-applied:
+	case <-waitTimeout():
+		t.Fatalf("timed out waiting for subscription to be applied")
+	}
+	if row, ok := players.FindById(1); !ok || row.Name != "alice" {
+		t.Fatalf("expected alice in the cache, got %+v", row)
+	}
+
+	updated := make(chan *testbindings.Player, 1)
+	players.OnUpdate(func(ctx *spacetimedb.EventContext, oldRow, newRow *testbindings.Player) { updated <- newRow })
+	err = server.SendTransactionUpdate(spacetimedbtest.Update("player", newTestBindingsPlayer(1, "alice"), newTestBindingsPlayer(1, "alicia")))
+	if err != nil {
+		t.Fatalf("failed to send TransactionUpdate: %v", err)
+	}
+	select {
+	case row := <-updated:
+		if row.Name != "alicia" {
+			t.Errorf("expected updated name alicia, got %q", row.Name)
+		}
+	case <-waitTimeout():
+		t.Fatalf("timed out waiting for update")
+	}
+
+	go func() {
+		call := server.WaitForReducerCall("move_player")
+		server.SendReducerResult(call, "player is frozen")
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result, err := testbindings.MovePlayerAsync(ctx, conn, 1, testbindings.Point{X: 3, Y: 4}, "walk", nil, nil)
+	if err != nil {
+		t.Fatalf("failed to call reducer: %v", err)
+	}
+	if result.Committed() || result.Err() == nil || result.Err().Error() != "reducer failed: player is frozen" {
+		t.Errorf("expected failed reducer result, got %+v", result.Status)
+	}
+
+	calls := server.ReducerCalls()
+	if len(calls) != 1 || calls[0].Reducer != "move_player" || len(calls[0].Args) == 0 {
+		t.Errorf("unexpected reducer calls: %+v", calls)
+	}
+	if queries := server.SubscribedQueries(); !reflect.DeepEqual(queries, []string{"SELECT * FROM player"}) {
+		t.Errorf("unexpected subscribed queries: %v", queries)
+	}
+}
+
+func TestServerMessageSerializeRoundTrip(t *testing.T) {
+	identity, _ := spacetimedb.NewIdentity(testIdentityHex)
+	requestId := uint32(3)
+	messages := []any{
+		&spacetimedb.IdentityToken{Identity: identity, Token: "token", ConnectionId: spacetimedb.NewConnectionId(big.NewInt(5))},
+		&spacetimedb.TransactionUpdateLight{RequestId: 7, Update: &spacetimedb.DatabaseUpdate{Tables: []*spacetimedb.TableUpdate{{
+			TableID:   1,
+			TableName: "player",
+			NumRows:   2,
+			Updates: []*spacetimedb.QueryUpdate{{
+				Deletes: spacetimedb.NewBsatnRowList([][]byte{{1, 2}}),
+				Inserts: &spacetimedb.BsatnRowList{SizeHint: &spacetimedb.RowSizeHint{RowSizeHint: &spacetimedb.RowSizeHintFixedSize{FixedSize: 2}}, RowsData: []byte{3, 4}},
+			}},
+		}}}},
+		&spacetimedb.SubscriptionError{TotalHostExecutionDuration: spacetimedb.NewTimeDuration(10), RequestId: &requestId, ErrorMessage: "bad query"},
+		&spacetimedb.OneOffQueryResponse{MessageId: []byte{1}, TotalHostExecutionDuration: spacetimedb.NewTimeDuration(4), Tables: []*spacetimedb.OneOffTable{{
+			TableName: "player",
+			Rows:      spacetimedb.NewBsatnRowList([][]byte{{1}, {2, 3}}),
+		}}},
+	}
+
+	for _, message := range messages {
+		writer := spacetimedb.NewBinaryWriter()
+		if err := (&spacetimedb.ServerMessage{Message: message}).Serialize(writer); err != nil {
+			t.Fatalf("failed to serialize %T: %v", message, err)
+		}
+		var decoded spacetimedb.ServerMessage
+		if err := decoded.Deserialize(spacetimedb.NewBinaryReader(writer.GetBuffer())); err != nil {
+			t.Fatalf("failed to deserialize %T: %v", message, err)
+		}
+		if !reflect.DeepEqual(decoded.Message, message) {
+			t.Errorf("round trip mismatch for %T:\n got %+v\nwant %+v", message, decoded.Message, message)
+		}
+	}
+}
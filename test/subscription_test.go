@@ -0,0 +1,119 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+	"github.com/gorilla/websocket"
+)
+
+func TestSubscriptionHandle(t *testing.T) {
+	server := newTestServer(t)
+	players := spacetimedb.NewTableCache(decodeTestPlayer, (*testPlayer).primaryKey)
+	events := make(chan string, 10)
+	players.OnInsert(func(ctx *spacetimedb.EventContext, row *testPlayer) {
+		events <- "insert " + row.Name
+	})
+	players.OnDelete(func(ctx *spacetimedb.EventContext, row *testPlayer) {
+		events <- "delete " + row.Name
+	})
+	db, ws := server.connect(t, spacetimedb.WithTableNameMap(spacetimedb.TableNameMap{"player": players}))
+
+	subscription := db.NewSubscription()
+	subscription.OnApplied(func(ctx *spacetimedb.EventContext) {
+		events <- "applied"
+	})
+	subscription.OnEnd(func(ctx *spacetimedb.EventContext) {
+		events <- "ended"
+	})
+	if err := subscription.Subscribe("SELECT * FROM player WHERE id = 1"); err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+
+	tag, reader := readClientMessage(t, ws)
+	if tag != 0x03 {
+		t.Fatalf("expected SubscribeSingle, got message type 0x%02x", tag)
+	}
+	query, requestId, queryId := reader.ReadString(), reader.ReadU32(), reader.ReadU32()
+	if query != "SELECT * FROM player WHERE id = 1" {
+		t.Errorf("query mismatch: got %q", query)
+	}
+
+	alice := encodeTestPlayer(1, "alice")
+	ws.WriteMessage(websocket.BinaryMessage, subscribeRowsMessage(0x05, requestId, queryId, testTableUpdate{name: "player", inserts: [][]byte{alice}}))
+	for _, want := range []string{"insert alice", "applied"} {
+		if got := waitForEvent(t, events); got != want {
+			t.Errorf("event mismatch: got %q, want %q", got, want)
+		}
+	}
+	if !subscription.IsActive() {
+		t.Errorf("expected subscription to be active after it was applied")
+	}
+
+	if err := subscription.Unsubscribe(); err != nil {
+		t.Fatalf("failed to unsubscribe: %v", err)
+	}
+	// The subscription stays cached until the unsubscribe is applied, but is not unsubscribed twice.
+	if subscription.IsActive() {
+		t.Errorf("expected subscription not to be active while unsubscribing")
+	}
+	if err := subscription.Unsubscribe(); err == nil {
+		t.Errorf("expected an error unsubscribing twice")
+	}
+	tag, reader = readClientMessage(t, ws)
+	if tag != 0x05 {
+		t.Fatalf("expected Unsubscribe, got message type 0x%02x", tag)
+	}
+	requestId = reader.ReadU32()
+	if got := reader.ReadU32(); got != queryId {
+		t.Errorf("unsubscribe query id mismatch: got %d, want %d", got, queryId)
+	}
+
+	ws.WriteMessage(websocket.BinaryMessage, subscribeRowsMessage(0x06, requestId, queryId, testTableUpdate{name: "player", deletes: [][]byte{alice}}))
+	for _, want := range []string{"delete alice", "ended"} {
+		if got := waitForEvent(t, events); got != want {
+			t.Errorf("event mismatch: got %q, want %q", got, want)
+		}
+	}
+	if subscription.IsActive() || !subscription.IsEnded() {
+		t.Errorf("expected subscription to have ended")
+	}
+}
+
+func TestSubscriptionError(t *testing.T) {
+	server := newTestServer(t)
+	db, ws := server.connect(t)
+
+	errs := make(chan error, 1)
+	subscription := db.NewSubscription()
+	subscription.OnError(func(ctx *spacetimedb.EventContext, err error) {
+		errs <- err
+	})
+	if err := subscription.Subscribe("SELECT * FROM player", "SELECT * FROM missing"); err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+
+	tag, reader := readClientMessage(t, ws)
+	if tag != 0x04 {
+		t.Fatalf("expected SubscribeMulti, got message type 0x%02x", tag)
+	}
+	queries := spacetimedb.ReadArray(reader, reader.ReadString)
+	if len(queries) != 2 {
+		t.Errorf("expected 2 queries, got %v", queries)
+	}
+	_ = reader.ReadU32() // RequestId
+	queryId := reader.ReadU32()
+
+	ws.WriteMessage(websocket.BinaryMessage, subscriptionErrorMessage(queryId, "no such table: missing"))
+	select {
+	case err := <-errs:
+		if err.Error() != "no such table: missing" {
+			t.Errorf("error mismatch: got %q", err.Error())
+		}
+	case <-waitTimeout():
+		t.Fatalf("timed out waiting for subscription error")
+	}
+	if !subscription.IsEnded() {
+		t.Errorf("expected subscription to have ended after an error")
+	}
+}
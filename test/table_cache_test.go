@@ -0,0 +1,202 @@
+package test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+	"github.com/gorilla/websocket"
+)
+
+type testPlayer struct {
+	Id   uint32
+	Name string
+}
+
+func decodeTestPlayer(reader *spacetimedb.BinaryReader) (*testPlayer, error) {
+	return &testPlayer{Id: reader.ReadU32(), Name: reader.ReadString()}, nil
+}
+
+func (p *testPlayer) primaryKey() string {
+	return fmt.Sprint(p.Id)
+}
+
+func encodeTestPlayer(id uint32, name string) []byte {
+	writer := spacetimedb.NewBinaryWriter()
+	writer.WriteU32(id)
+	writer.WriteString(name)
+	return writer.GetBuffer()
+}
+
+func TestTableCacheCallbacks(t *testing.T) {
+	cache := spacetimedb.NewTableCache(decodeTestPlayer, (*testPlayer).primaryKey)
+
+	var events []string
+	cache.OnInsert(func(ctx *spacetimedb.EventContext, row *testPlayer) {
+		events = append(events, "insert "+row.Name)
+	})
+	cache.OnDelete(func(ctx *spacetimedb.EventContext, row *testPlayer) {
+		events = append(events, "delete "+row.Name)
+	})
+	updateHandle := cache.OnUpdate(func(ctx *spacetimedb.EventContext, oldRow *testPlayer, newRow *testPlayer) {
+		events = append(events, "update "+oldRow.Name+" -> "+newRow.Name)
+	})
+
+	ctx := &spacetimedb.EventContext{}
+	steps := []struct {
+		name    string
+		deletes [][]byte
+		inserts [][]byte
+		want    []string
+	}{
+		{
+			name:    "insert",
+			inserts: [][]byte{encodeTestPlayer(1, "alice"), encodeTestPlayer(2, "bob")},
+			want:    []string{"insert alice", "insert bob"},
+		},
+		{
+			name:    "update by primary key",
+			deletes: [][]byte{encodeTestPlayer(1, "alice")},
+			inserts: [][]byte{encodeTestPlayer(1, "alicia")},
+			want:    []string{"update alice -> alicia"},
+		},
+		{
+			name:    "delete and insert",
+			deletes: [][]byte{encodeTestPlayer(2, "bob")},
+			inserts: [][]byte{encodeTestPlayer(3, "carol")},
+			want:    []string{"delete bob", "insert carol"},
+		},
+	}
+
+	for _, step := range steps {
+		events = nil
+		if err := cache.ApplyUpdate(ctx, step.deletes, step.inserts); err != nil {
+			t.Fatalf("%s: failed to apply update: %v", step.name, err)
+		}
+		if fmt.Sprint(events) != fmt.Sprint(step.want) {
+			t.Errorf("%s: events mismatch: got %v, want %v", step.name, events, step.want)
+		}
+	}
+
+	alicia, _ := cache.Get("1")
+	carol, _ := cache.Get("3")
+	if cache.Count() != 2 || alicia == nil || alicia.Name != "alicia" || carol == nil || carol.Name != "carol" {
+		t.Errorf("unexpected rows in cache: %v", cache.All())
+	}
+
+	updateHandle.Remove()
+	events = nil
+	if err := cache.ApplyUpdate(ctx, [][]byte{encodeTestPlayer(1, "alicia")}, [][]byte{encodeTestPlayer(1, "alice")}); err != nil {
+		t.Fatalf("failed to apply update: %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("expected removed update callback not to run, got %v", events)
+	}
+}
+
+func TestOverlappingSubscriptionsKeepRows(t *testing.T) {
+	server := newTestServer(t)
+	players := spacetimedb.NewTableCache(decodeTestPlayer, (*testPlayer).primaryKey)
+	events := make(chan string, 10)
+	players.OnInsert(func(ctx *spacetimedb.EventContext, row *testPlayer) {
+		events <- "insert " + row.Name
+	})
+	players.OnDelete(func(ctx *spacetimedb.EventContext, row *testPlayer) {
+		events <- "delete " + row.Name
+	})
+	_, ws := server.connect(t, spacetimedb.WithTableNameMap(spacetimedb.TableNameMap{"player": players}))
+
+	alice := encodeTestPlayer(1, "alice")
+	bob := encodeTestPlayer(2, "bob")
+
+	// Two overlapping queries both return alice.
+	ws.WriteMessage(websocket.BinaryMessage, initialSubscriptionMessage(testTableUpdate{name: "player", inserts: [][]byte{alice, bob, alice}}))
+	for _, want := range []string{"insert alice", "insert bob"} {
+		if got := waitForEvent(t, events); got != want {
+			t.Errorf("event mismatch: got %q, want %q", got, want)
+		}
+	}
+
+	// One query stops matching alice, the other still does. Inserting carol afterwards shows that
+	// the delete has been applied without an event.
+	ws.WriteMessage(websocket.BinaryMessage, transactionUpdateMessage(testTableUpdate{name: "player", deletes: [][]byte{alice}}))
+	ws.WriteMessage(websocket.BinaryMessage, transactionUpdateMessage(testTableUpdate{name: "player", inserts: [][]byte{encodeTestPlayer(3, "carol")}}))
+	if got := waitForEvent(t, events); got != "insert carol" {
+		t.Fatalf("event mismatch: got %q, want %q", got, "insert carol")
+	}
+	if row, ok := players.Get("1"); !ok || row.Name != "alice" {
+		t.Fatalf("expected alice to stay cached, got %+v", row)
+	}
+
+	// The last query stops matching alice.
+	ws.WriteMessage(websocket.BinaryMessage, transactionUpdateMessage(testTableUpdate{name: "player", deletes: [][]byte{alice}}))
+	if got := waitForEvent(t, events); got != "delete alice" {
+		t.Errorf("event mismatch: got %q, want %q", got, "delete alice")
+	}
+	if _, ok := players.Get("1"); ok {
+		t.Errorf("expected alice to be removed from the cache")
+	}
+}
+
+func TestSubscribeReplacesPreviousRows(t *testing.T) {
+	server := newTestServer(t)
+	players := spacetimedb.NewTableCache(decodeTestPlayer, (*testPlayer).primaryKey)
+	events := make(chan string, 10)
+	players.OnInsert(func(ctx *spacetimedb.EventContext, row *testPlayer) {
+		events <- "insert " + row.Name
+	})
+	players.OnDelete(func(ctx *spacetimedb.EventContext, row *testPlayer) {
+		events <- "delete " + row.Name
+	})
+	db, ws := server.connect(t, spacetimedb.WithTableNameMap(spacetimedb.TableNameMap{"player": players}))
+
+	alice := encodeTestPlayer(1, "alice")
+	bob := encodeTestPlayer(2, "bob")
+
+	// Each Subscribe replaces the previous one, and the server answers with all of its rows.
+	subscribe := func(query string, rows ...[]byte) {
+		t.Helper()
+		if err := db.Subscribe(query); err != nil {
+			t.Fatalf("failed to subscribe: %v", err)
+		}
+		ws.WriteMessage(websocket.BinaryMessage, initialSubscriptionMessage(testTableUpdate{name: "player", inserts: rows}))
+	}
+	subscribe("SELECT * FROM player", alice, bob)
+	for _, want := range []string{"insert alice", "insert bob"} {
+		if got := waitForEvent(t, events); got != want {
+			t.Errorf("event mismatch: got %q, want %q", got, want)
+		}
+	}
+
+	// Subscribing to the same query again changes nothing, and a narrower query only drops bob.
+	subscribe("SELECT * FROM player", alice, bob)
+	subscribe("SELECT * FROM player WHERE id = 1", alice)
+	if got := waitForEvent(t, events); got != "delete bob" {
+		t.Fatalf("event mismatch: got %q, want %q", got, "delete bob")
+	}
+
+	ws.WriteMessage(websocket.BinaryMessage, transactionUpdateMessage(testTableUpdate{name: "player", deletes: [][]byte{alice}}))
+	if got := waitForEvent(t, events); got != "delete alice" {
+		t.Errorf("event mismatch: got %q, want %q", got, "delete alice")
+	}
+	if players.Count() != 0 {
+		t.Errorf("expected the cache to be empty, got %v", players.All())
+	}
+}
+
+func TestMalformedMessageKeepsConnectionAlive(t *testing.T) {
+	server := newTestServer(t)
+	players := spacetimedb.NewTableCache(decodeTestPlayer, (*testPlayer).primaryKey)
+	events := make(chan string, 10)
+	players.OnInsert(func(ctx *spacetimedb.EventContext, row *testPlayer) {
+		events <- "insert " + row.Name
+	})
+	_, ws := server.connect(t, spacetimedb.WithTableNameMap(spacetimedb.TableNameMap{"player": players}))
+
+	message := initialSubscriptionMessage(testTableUpdate{name: "player", inserts: [][]byte{encodeTestPlayer(1, "alice")}})
+	ws.WriteMessage(websocket.BinaryMessage, message[:len(message)/2])
+	ws.WriteMessage(websocket.BinaryMessage, message)
+	if got := waitForEvent(t, events); got != "insert alice" {
+		t.Errorf("event mismatch: got %q, want %q", got, "insert alice")
+	}
+}
@@ -0,0 +1,42 @@
+// Code generated by spacetimedb-go-codegen. DO NOT EDIT.
+
+package testbindings
+
+import (
+	"fmt"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+)
+
+type Color struct {
+	// Value is one of *ColorRed, *ColorGreen.
+	Value any
+}
+
+type ColorRed struct{}
+
+type ColorGreen struct{}
+
+func (c *Color) Serialize(writer *spacetimedb.BinaryWriter) error {
+	switch c.Value.(type) {
+	case *ColorRed:
+		writer.WriteU8(0)
+	case *ColorGreen:
+		writer.WriteU8(1)
+	default:
+		return fmt.Errorf("Color.Serialize: unknown variant %T", c.Value)
+	}
+	return nil
+}
+
+func (c *Color) Deserialize(reader *spacetimedb.BinaryReader) error {
+	switch tag := reader.ReadU8(); tag {
+	case 0:
+		c.Value = &ColorRed{}
+	case 1:
+		c.Value = &ColorGreen{}
+	default:
+		return fmt.Errorf("Color.Deserialize: unknown union type 0x%02x", tag)
+	}
+	return reader.Err()
+}
@@ -0,0 +1,18 @@
+// Code generated by spacetimedb-go-codegen. DO NOT EDIT.
+
+package testbindings
+
+import (
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+)
+
+// MarkerTable is the client cache of the marker table.
+type MarkerTable struct {
+	*spacetimedb.TableCache[*Marker]
+}
+
+func NewMarkerTable() *MarkerTable {
+	return &MarkerTable{
+		TableCache: spacetimedb.NewTableCache(DeserializeMarker, nil),
+	}
+}
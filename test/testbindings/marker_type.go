@@ -0,0 +1,48 @@
+// Code generated by spacetimedb-go-codegen. DO NOT EDIT.
+
+package testbindings
+
+import (
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+)
+
+type Marker struct {
+	Color   Color
+	At      Point
+	Comment *Point
+}
+
+func (m *Marker) Serialize(writer *spacetimedb.BinaryWriter) error {
+	if err := m.Color.Serialize(writer); err != nil {
+		return err
+	}
+	if err := m.At.Serialize(writer); err != nil {
+		return err
+	}
+	if m.Comment == nil {
+		writer.WriteU8(1)
+	} else {
+		writer.WriteU8(0)
+		if err := m.Comment.Serialize(writer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Marker) Deserialize(reader *spacetimedb.BinaryReader) error {
+	m.Color = *spacetimedb.ReadValue[Color](reader)
+	m.At = *spacetimedb.ReadValue[Point](reader)
+	m.Comment = spacetimedb.ReadOption(reader, func() Point {
+		return *spacetimedb.ReadValue[Point](reader)
+	})
+	return reader.Err()
+}
+
+func DeserializeMarker(reader *spacetimedb.BinaryReader) (*Marker, error) {
+	row := &Marker{}
+	if err := row.Deserialize(reader); err != nil {
+		return nil, err
+	}
+	return row, nil
+}
@@ -0,0 +1,56 @@
+// Code generated by spacetimedb-go-codegen. DO NOT EDIT.
+
+package testbindings
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+)
+
+// MovePlayer calls the move_player reducer with the DefaultReducerFlags of conn, without waiting for its result.
+func MovePlayer(conn *spacetimedb.DBConnection, id uint64, to Point, type_ string, waypoints [][]Point, reason *string) error {
+	args, err := serializeMovePlayerArgs(id, to, type_, waypoints, reason)
+	if err != nil {
+		return err
+	}
+	if err := conn.CallReducer("move_player", args, 0, conn.DefaultReducerFlags); err != nil {
+		return fmt.Errorf("failed to call move_player reducer: %w", err)
+	}
+	return nil
+}
+
+// MovePlayerAsync calls the move_player reducer and waits for its result.
+func MovePlayerAsync(ctx context.Context, conn *spacetimedb.DBConnection, id uint64, to Point, type_ string, waypoints [][]Point, reason *string) (*spacetimedb.ReducerResult, error) {
+	args, err := serializeMovePlayerArgs(id, to, type_, waypoints, reason)
+	if err != nil {
+		return nil, err
+	}
+	return conn.CallReducerAsync(ctx, "move_player", args)
+}
+
+func serializeMovePlayerArgs(id uint64, to Point, type_ string, waypoints [][]Point, reason *string) ([]byte, error) {
+	writer := spacetimedb.NewBinaryWriter()
+	writer.WriteU64(id)
+	if err := to.Serialize(writer); err != nil {
+		return nil, err
+	}
+	writer.WriteString(type_)
+	writer.WriteU32(uint32(len(waypoints)))
+	for _, item := range waypoints {
+		writer.WriteU32(uint32(len(item)))
+		for _, item1 := range item {
+			if err := item1.Serialize(writer); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if reason == nil {
+		writer.WriteU8(1)
+	} else {
+		writer.WriteU8(0)
+		writer.WriteString(*reason)
+	}
+	return writer.GetBuffer(), nil
+}
@@ -0,0 +1,67 @@
+// Code generated by spacetimedb-go-codegen. DO NOT EDIT.
+
+package testbindings
+
+import (
+	"fmt"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+)
+
+type PlayerStatus struct {
+	// Value is one of *PlayerStatusOnline, *PlayerStatusAway, *PlayerStatusBanned, *PlayerStatusMoving.
+	Value any
+}
+
+type PlayerStatusOnline struct{}
+
+type PlayerStatusAway struct {
+	Value *spacetimedb.TimeDuration
+}
+
+type PlayerStatusBanned struct {
+	Value string
+}
+
+type PlayerStatusMoving struct {
+	Value Point
+}
+
+func (p *PlayerStatus) Serialize(writer *spacetimedb.BinaryWriter) error {
+	switch variant := p.Value.(type) {
+	case *PlayerStatusOnline:
+		writer.WriteU8(0)
+	case *PlayerStatusAway:
+		writer.WriteU8(1)
+		if err := variant.Value.Serialize(writer); err != nil {
+			return err
+		}
+	case *PlayerStatusBanned:
+		writer.WriteU8(2)
+		writer.WriteString(variant.Value)
+	case *PlayerStatusMoving:
+		writer.WriteU8(3)
+		if err := variant.Value.Serialize(writer); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("PlayerStatus.Serialize: unknown variant %T", p.Value)
+	}
+	return nil
+}
+
+func (p *PlayerStatus) Deserialize(reader *spacetimedb.BinaryReader) error {
+	switch tag := reader.ReadU8(); tag {
+	case 0:
+		p.Value = &PlayerStatusOnline{}
+	case 1:
+		p.Value = &PlayerStatusAway{Value: spacetimedb.ReadValue[spacetimedb.TimeDuration](reader)}
+	case 2:
+		p.Value = &PlayerStatusBanned{Value: reader.ReadString()}
+	case 3:
+		p.Value = &PlayerStatusMoving{Value: *spacetimedb.ReadValue[Point](reader)}
+	default:
+		return fmt.Errorf("PlayerStatus.Deserialize: unknown union type 0x%02x", tag)
+	}
+	return reader.Err()
+}
@@ -0,0 +1,29 @@
+// Code generated by spacetimedb-go-codegen. DO NOT EDIT.
+
+package testbindings
+
+import (
+	"fmt"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+)
+
+// PlayerTable is the client cache of the player table.
+type PlayerTable struct {
+	*spacetimedb.TableCache[*Player]
+}
+
+func NewPlayerTable() *PlayerTable {
+	return &PlayerTable{
+		TableCache: spacetimedb.NewTableCache(DeserializePlayer, playerPrimaryKey),
+	}
+}
+
+func playerPrimaryKey(row *Player) string {
+	return fmt.Sprint(row.Id)
+}
+
+// FindById returns the cached row with the given id.
+func (t *PlayerTable) FindById(id uint64) (*Player, bool) {
+	return t.Get(fmt.Sprint(id))
+}
@@ -0,0 +1,120 @@
+// Code generated by spacetimedb-go-codegen. DO NOT EDIT.
+
+package testbindings
+
+import (
+	"math/big"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+)
+
+type Player struct {
+	Id           uint64
+	Name         string
+	Position     Point
+	Tags         []string
+	Avatar       []byte
+	Score        *big.Int
+	Owner        *spacetimedb.Identity
+	Nickname     *string
+	LastSeen     *spacetimedb.Timestamp
+	Path         []Point
+	Status       PlayerStatus
+	Cooldown     *spacetimedb.TimeDuration
+	ConnectionId spacetimedb.ConnectionId
+	Balance      *big.Int
+	Alive        bool
+	Level        int8
+	Ratio        float64
+}
+
+func (p *Player) Serialize(writer *spacetimedb.BinaryWriter) error {
+	writer.WriteU64(p.Id)
+	writer.WriteString(p.Name)
+	if err := p.Position.Serialize(writer); err != nil {
+		return err
+	}
+	writer.WriteU32(uint32(len(p.Tags)))
+	for _, item := range p.Tags {
+		writer.WriteString(item)
+	}
+	writer.WriteUInt8Array(p.Avatar)
+	writer.WriteU128(p.Score)
+	if p.Owner == nil {
+		writer.WriteU8(1)
+	} else {
+		writer.WriteU8(0)
+		if err := p.Owner.Serialize(writer); err != nil {
+			return err
+		}
+	}
+	if p.Nickname == nil {
+		writer.WriteU8(1)
+	} else {
+		writer.WriteU8(0)
+		writer.WriteString(*p.Nickname)
+	}
+	if p.LastSeen == nil {
+		writer.WriteU8(1)
+	} else {
+		writer.WriteU8(0)
+		if err := p.LastSeen.Serialize(writer); err != nil {
+			return err
+		}
+	}
+	writer.WriteU32(uint32(len(p.Path)))
+	for _, item := range p.Path {
+		if err := item.Serialize(writer); err != nil {
+			return err
+		}
+	}
+	if err := p.Status.Serialize(writer); err != nil {
+		return err
+	}
+	if err := p.Cooldown.Serialize(writer); err != nil {
+		return err
+	}
+	if err := p.ConnectionId.Serialize(writer); err != nil {
+		return err
+	}
+	writer.WriteI256(p.Balance)
+	writer.WriteBool(p.Alive)
+	writer.WriteI8(p.Level)
+	writer.WriteF64(p.Ratio)
+	return nil
+}
+
+func (p *Player) Deserialize(reader *spacetimedb.BinaryReader) error {
+	p.Id = reader.ReadU64()
+	p.Name = reader.ReadString()
+	p.Position = *spacetimedb.ReadValue[Point](reader)
+	p.Tags = spacetimedb.ReadArray(reader, reader.ReadString)
+	p.Avatar = reader.ReadUInt8Array()
+	p.Score = reader.ReadU128()
+	p.Owner = spacetimedb.ReadOption(reader, func() spacetimedb.Identity {
+		return *spacetimedb.ReadValue[spacetimedb.Identity](reader)
+	})
+	p.Nickname = spacetimedb.ReadOption(reader, reader.ReadString)
+	p.LastSeen = spacetimedb.ReadNullable(reader, func() *spacetimedb.Timestamp {
+		return spacetimedb.ReadValue[spacetimedb.Timestamp](reader)
+	})
+	p.Path = spacetimedb.ReadArray(reader, func() Point {
+		return *spacetimedb.ReadValue[Point](reader)
+	})
+	p.Status = *spacetimedb.ReadValue[PlayerStatus](reader)
+	p.Cooldown = spacetimedb.ReadValue[spacetimedb.TimeDuration](reader)
+	p.ConnectionId = *spacetimedb.ReadValue[spacetimedb.ConnectionId](reader)
+	p.Balance = reader.ReadI256()
+	p.Alive = reader.ReadBool()
+	p.Level = reader.ReadI8()
+	p.Ratio = reader.ReadF64()
+	return reader.Err()
+}
+
+func DeserializePlayer(reader *spacetimedb.BinaryReader) (*Player, error) {
+	row := &Player{}
+	if err := row.Deserialize(reader); err != nil {
+		return nil, err
+	}
+	return row, nil
+}
@@ -0,0 +1,32 @@
+// Code generated by spacetimedb-go-codegen. DO NOT EDIT.
+
+package testbindings
+
+import (
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+)
+
+type Point struct {
+	X float32
+	Y float32
+}
+
+func (p *Point) Serialize(writer *spacetimedb.BinaryWriter) error {
+	writer.WriteF32(p.X)
+	writer.WriteF32(p.Y)
+	return nil
+}
+
+func (p *Point) Deserialize(reader *spacetimedb.BinaryReader) error {
+	p.X = reader.ReadF32()
+	p.Y = reader.ReadF32()
+	return reader.Err()
+}
+
+func DeserializePoint(reader *spacetimedb.BinaryReader) (*Point, error) {
+	row := &Point{}
+	if err := row.Deserialize(reader); err != nil {
+		return nil, err
+	}
+	return row, nil
+}
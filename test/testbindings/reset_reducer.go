@@ -0,0 +1,36 @@
+// Code generated by spacetimedb-go-codegen. DO NOT EDIT.
+
+package testbindings
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+)
+
+// Reset calls the reset reducer with the DefaultReducerFlags of conn, without waiting for its result.
+func Reset(conn *spacetimedb.DBConnection) error {
+	args, err := serializeResetArgs()
+	if err != nil {
+		return err
+	}
+	if err := conn.CallReducer("reset", args, 0, conn.DefaultReducerFlags); err != nil {
+		return fmt.Errorf("failed to call reset reducer: %w", err)
+	}
+	return nil
+}
+
+// ResetAsync calls the reset reducer and waits for its result.
+func ResetAsync(ctx context.Context, conn *spacetimedb.DBConnection) (*spacetimedb.ReducerResult, error) {
+	args, err := serializeResetArgs()
+	if err != nil {
+		return nil, err
+	}
+	return conn.CallReducerAsync(ctx, "reset", args)
+}
+
+func serializeResetArgs() ([]byte, error) {
+	writer := spacetimedb.NewBinaryWriter()
+	return writer.GetBuffer(), nil
+}
@@ -0,0 +1,35 @@
+// Code generated by spacetimedb-go-codegen. DO NOT EDIT.
+
+package testbindings
+
+import (
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+)
+
+// RemoteTables holds the client cache of every table. Every DBConnection needs its own.
+type RemoteTables struct {
+	Player *PlayerTable
+	Marker *MarkerTable
+}
+
+// NewRemoteTables creates empty client caches for all tables.
+func NewRemoteTables() *RemoteTables {
+	return &RemoteTables{
+		Player: NewPlayerTable(),
+		Marker: NewMarkerTable(),
+	}
+}
+
+// TableNameMap returns the tables by name, to pass to spacetimedb.WithTableNameMap.
+func (t *RemoteTables) TableNameMap() spacetimedb.TableNameMap {
+	return spacetimedb.TableNameMap{
+		"player": t.Player,
+		"marker": t.Marker,
+	}
+}
+
+// Db holds the tables of a program with a single connection. Use NewRemoteTables for more connections.
+var Db = NewRemoteTables()
+
+// Tables is the TableNameMap of Db.
+var Tables = Db.TableNameMap()
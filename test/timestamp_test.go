@@ -0,0 +1,120 @@
+package test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+)
+
+func TestTimestampArithmetic(t *testing.T) {
+	start := spacetimedb.NewTimestamp(1_700_000_000_000_000)
+	later := start.Add(spacetimedb.FromDuration(1500 * time.Millisecond))
+	if later.MicrosSinceUnixEpoch() != 1_700_000_001_500_000 {
+		t.Errorf("unexpected Add result: %d", later.MicrosSinceUnixEpoch())
+	}
+	if d := later.Sub(start); d.Micros != 1_500_000 || d.ToDuration() != 1500*time.Millisecond {
+		t.Errorf("unexpected Sub result: %v", d)
+	}
+	if d := start.Sub(later); d.Micros != -1_500_000 {
+		t.Errorf("expected negative duration, got %v", d)
+	}
+	if d := spacetimedb.Since(spacetimedb.FromDate(time.Now().Add(-time.Minute))); d.ToDuration() < time.Minute {
+		t.Errorf("expected at least a minute since, got %v", d)
+	}
+	sum := spacetimedb.NewTimeDuration(10).Add(spacetimedb.NewTimeDuration(5)).Sub(spacetimedb.NewTimeDuration(20))
+	if sum.Micros != -5 || sum.String() != "-5µs" {
+		t.Errorf("unexpected duration arithmetic: %v", sum)
+	}
+	if d := spacetimedb.NewTimeDuration(1 << 62).ToDuration(); d != time.Duration(1<<63-1) {
+		t.Errorf("expected saturated duration, got %d", d)
+	}
+}
+
+func TestTimestampString(t *testing.T) {
+	if s := spacetimedb.NewTimestamp(1_700_000_000_123_456).String(); s != "2023-11-14T22:13:20.123456Z" {
+		t.Errorf("unexpected String: %s", s)
+	}
+	if s := spacetimedb.UnixEpoch.String(); s != "1970-01-01T00:00:00Z" {
+		t.Errorf("unexpected String: %s", s)
+	}
+	if _, err := time.Parse(time.RFC3339, spacetimedb.Now().String()); err != nil {
+		t.Errorf("String is not RFC 3339: %v", err)
+	}
+}
+
+func TestTimestampSerialize(t *testing.T) {
+	writer := spacetimedb.NewBinaryWriter()
+	if err := spacetimedb.NewTimestamp(-42).Serialize(writer); err != nil {
+		t.Fatalf("failed to serialize timestamp: %v", err)
+	}
+	if err := spacetimedb.NewTimeDuration(7).Serialize(writer); err != nil {
+		t.Fatalf("failed to serialize duration: %v", err)
+	}
+	reader := spacetimedb.NewBinaryReader(writer.GetBuffer())
+	timestamp := spacetimedb.ReadValue[spacetimedb.Timestamp](reader)
+	duration := spacetimedb.ReadValue[spacetimedb.TimeDuration](reader)
+	if err := reader.Err(); err != nil {
+		t.Fatalf("failed to deserialize: %v", err)
+	}
+	if timestamp.MicrosSinceUnixEpoch() != -42 || duration.Micros != 7 {
+		t.Errorf("unexpected round trip: %v, %v", timestamp, duration)
+	}
+
+	// Timestamp and TimeDuration are products of one i64, so their encoding is the i64 itself.
+	type row struct {
+		At    spacetimedb.Timestamp
+		Every *spacetimedb.TimeDuration
+	}
+	data, err := spacetimedb.Marshal(row{At: *spacetimedb.NewTimestamp(1), Every: spacetimedb.NewTimeDuration(2)})
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	expected := spacetimedb.NewBinaryWriter()
+	expected.WriteI64(1)
+	expected.WriteI64(2)
+	if string(data) != string(expected.GetBuffer()) {
+		t.Errorf("unexpected encoding: %v", data)
+	}
+	var decoded row
+	if err := spacetimedb.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if decoded.At.MicrosSinceUnixEpoch() != 1 || decoded.Every.Micros != 2 {
+		t.Errorf("unexpected decoded row: %+v", decoded)
+	}
+}
+
+func TestTimestampJSON(t *testing.T) {
+	data, err := json.Marshal(struct {
+		At    *spacetimedb.Timestamp
+		Every spacetimedb.TimeDuration
+	}{spacetimedb.NewTimestamp(5), *spacetimedb.NewTimeDuration(6)})
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	expected := `{"At":{"__timestamp_micros_since_unix_epoch__":5},"Every":{"__time_duration_micros__":6}}`
+	if string(data) != expected {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+
+	var decoded struct {
+		At    spacetimedb.Timestamp
+		Every *spacetimedb.TimeDuration
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if decoded.At.MicrosSinceUnixEpoch() != 5 || decoded.Every.Micros != 6 {
+		t.Errorf("unexpected decoded JSON: %+v", decoded)
+	}
+
+	writer := spacetimedb.NewBinaryWriter()
+	if err := spacetimedb.EncodeJSON(writer, []byte(`{"__timestamp_micros_since_unix_epoch__":5}`), spacetimedb.TimestampType()); err != nil {
+		t.Fatalf("failed to encode SATS JSON: %v", err)
+	}
+	if timestamp := spacetimedb.ReadValue[spacetimedb.Timestamp](spacetimedb.NewBinaryReader(writer.GetBuffer())); timestamp.MicrosSinceUnixEpoch() != 5 {
+		t.Errorf("unexpected timestamp from SATS JSON: %v", timestamp)
+	}
+}
@@ -0,0 +1,83 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexanderbh/spacetimedb-go-sdk"
+	"github.com/gorilla/websocket"
+)
+
+func TestFileTokenStore(t *testing.T) {
+	store := spacetimedb.NewFileTokenStore(filepath.Join(t.TempDir(), "nested", "tokens.json"))
+
+	token, err := store.Load("wss://maincloud.spacetimedb.com", "quickstart-chat")
+	if err != nil {
+		t.Fatalf("failed to load token from missing file: %v", err)
+	}
+	if token != "" {
+		t.Errorf("expected no token, got %q", token)
+	}
+
+	if err := store.Save("wss://maincloud.spacetimedb.com", "quickstart-chat", "token-1"); err != nil {
+		t.Fatalf("failed to save token: %v", err)
+	}
+	if err := store.Save("ws://localhost:3000", "quickstart-chat", "token-2"); err != nil {
+		t.Fatalf("failed to save token: %v", err)
+	}
+
+	token, err = store.Load("wss://maincloud.spacetimedb.com", "quickstart-chat")
+	if err != nil {
+		t.Fatalf("failed to load token: %v", err)
+	}
+	if token != "token-1" {
+		t.Errorf("token mismatch: got %q, want %q", token, "token-1")
+	}
+	token, err = store.Load("ws://localhost:3000", "quickstart-chat")
+	if err != nil {
+		t.Fatalf("failed to load token: %v", err)
+	}
+	if token != "token-2" {
+		t.Errorf("token mismatch: got %q, want %q", token, "token-2")
+	}
+}
+
+func TestConnectSendsToken(t *testing.T) {
+	authorizations := make(chan string, 1)
+	upgrader := websocket.Upgrader{Subprotocols: []string{"v1.bsatn.spacetimedb"}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("failed to upgrade connection: %v", err)
+			return
+		}
+		defer ws.Close()
+		authorizations <- r.Header.Get("Authorization")
+		ws.ReadMessage()
+	}))
+	defer server.Close()
+
+	db := spacetimedb.NewDBConnection(
+		spacetimedb.WithHost("ws"+strings.TrimPrefix(server.URL, "http")),
+		spacetimedb.WithNameOrIdentity("test"),
+		spacetimedb.WithToken("my-token"),
+		spacetimedb.WithLogger(func(format string, args ...interface{}) {}),
+	)
+	if err := db.Connect(); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer db.Close()
+
+	select {
+	case authorization := <-authorizations:
+		if authorization != "Bearer my-token" {
+			t.Errorf("authorization mismatch: got %q, want %q", authorization, "Bearer my-token")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for connection")
+	}
+}
@@ -1,6 +1,11 @@
 package spacetimedb
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
 
 // TimeDuration represents a difference between two points in time, in microseconds.
 type TimeDuration struct {
@@ -22,9 +27,62 @@ func FromMillis(millis int64) *TimeDuration {
 	return NewTimeDuration(micros)
 }
 
-func (td *TimeDuration) String() string {
-	if td.Micros < 0 {
-		return "-" + fmt.Sprint(td.Micros) + "µs"
+// FromDuration returns the TimeDuration of d, truncated to microseconds.
+func FromDuration(d time.Duration) *TimeDuration {
+	return NewTimeDuration(d.Microseconds())
+}
+
+// ToDuration returns the duration as a time.Duration, which saturates at about 292 years.
+func (td *TimeDuration) ToDuration() time.Duration {
+	const maxMicros = int64(math.MaxInt64 / time.Microsecond)
+	switch {
+	case td.Micros > maxMicros:
+		return math.MaxInt64
+	case td.Micros < -maxMicros:
+		return math.MinInt64
 	}
+	return time.Duration(td.Micros) * time.Microsecond
+}
+
+// Add returns the sum of td and other.
+func (td *TimeDuration) Add(other *TimeDuration) *TimeDuration {
+	return NewTimeDuration(td.Micros + other.Micros)
+}
+
+// Sub returns td minus other.
+func (td *TimeDuration) Sub(other *TimeDuration) *TimeDuration {
+	return NewTimeDuration(td.Micros - other.Micros)
+}
+
+func (td *TimeDuration) String() string {
 	return fmt.Sprint(td.Micros) + "µs"
 }
+
+func (td *TimeDuration) Serialize(writer *BinaryWriter) error {
+	writer.WriteI64(td.Micros)
+	return nil
+}
+
+func (td *TimeDuration) Deserialize(reader *BinaryReader) error {
+	td.Micros = reader.ReadI64()
+	return reader.Err()
+}
+
+type jsonTimeDuration struct {
+	Micros int64 `json:"__time_duration_micros__"`
+}
+
+// MarshalJSON encodes the duration in the SATS JSON format, as an object holding
+// __time_duration_micros__.
+func (td TimeDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonTimeDuration{Micros: td.Micros})
+}
+
+func (td *TimeDuration) UnmarshalJSON(data []byte) error {
+	var value jsonTimeDuration
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("TimeDuration.UnmarshalJSON: %w", err)
+	}
+	td.Micros = value.Micros
+	return nil
+}
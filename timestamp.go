@@ -1,6 +1,7 @@
 package spacetimedb
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"time"
@@ -35,6 +36,21 @@ func (t *Timestamp) MicrosSinceUnixEpoch() int64 {
 // UnixEpoch is the midnight at the beginning of January 1, 1970, UTC.
 var UnixEpoch = NewTimestamp(0)
 
+// Add returns the Timestamp d after t.
+func (t *Timestamp) Add(d *TimeDuration) *Timestamp {
+	return NewTimestamp(t.microsSinceUnixEpoch + d.Micros)
+}
+
+// Sub returns the duration from other to t, which is negative if other is after t.
+func (t *Timestamp) Sub(other *Timestamp) *TimeDuration {
+	return NewTimeDuration(t.microsSinceUnixEpoch - other.microsSinceUnixEpoch)
+}
+
+// Since returns the time elapsed since t.
+func Since(t *Timestamp) *TimeDuration {
+	return Now().Sub(t)
+}
+
 // Now returns a Timestamp representing the current moment in time.
 func Now() *Timestamp {
 	return FromDate(time.Now())
@@ -71,3 +87,42 @@ func FromBigInt(micros *big.Int) (*Timestamp, error) {
 	}
 	return NewTimestamp(micros.Int64()), nil
 }
+
+// String formats the timestamp in RFC 3339, or as microseconds since the Unix epoch if it is
+// outside the range of Go's time.Time.
+func (t *Timestamp) String() string {
+	date, err := t.ToDate()
+	if err != nil {
+		return fmt.Sprintf("%dµs", t.microsSinceUnixEpoch)
+	}
+	return date.UTC().Format(time.RFC3339Nano)
+}
+
+func (t *Timestamp) Serialize(writer *BinaryWriter) error {
+	writer.WriteI64(t.microsSinceUnixEpoch)
+	return nil
+}
+
+func (t *Timestamp) Deserialize(reader *BinaryReader) error {
+	t.microsSinceUnixEpoch = reader.ReadI64()
+	return reader.Err()
+}
+
+type jsonTimestamp struct {
+	Micros int64 `json:"__timestamp_micros_since_unix_epoch__"`
+}
+
+// MarshalJSON encodes the timestamp in the SATS JSON format, as an object holding
+// __timestamp_micros_since_unix_epoch__.
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonTimestamp{Micros: t.microsSinceUnixEpoch})
+}
+
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	var value jsonTimestamp
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("Timestamp.UnmarshalJSON: %w", err)
+	}
+	t.microsSinceUnixEpoch = value.Micros
+	return nil
+}
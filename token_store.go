@@ -0,0 +1,93 @@
+package spacetimedb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// TokenStore loads and saves auth tokens, so a client keeps the same identity across sessions.
+// Tokens are stored per host and database.
+type TokenStore interface {
+	// Load returns the saved token, or an empty string if there is none.
+	Load(host, nameOrIdentity string) (string, error)
+	Save(host, nameOrIdentity, token string) error
+}
+
+// FileTokenStore is a TokenStore that keeps tokens in a JSON file.
+type FileTokenStore struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// NewFileTokenStore creates a FileTokenStore that reads and writes the file at path.
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{Path: path}
+}
+
+// DefaultFileTokenStore creates a FileTokenStore in the user's config directory.
+func DefaultFileTokenStore() (*FileTokenStore, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user config directory: %w", err)
+	}
+	return NewFileTokenStore(filepath.Join(dir, "spacetimedb-go-sdk", "tokens.json")), nil
+}
+
+func tokenStoreKey(host, nameOrIdentity string) string {
+	return host + "/" + nameOrIdentity
+}
+
+func (s *FileTokenStore) Load(host, nameOrIdentity string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.read()
+	if err != nil {
+		return "", err
+	}
+	return tokens[tokenStoreKey(host, nameOrIdentity)], nil
+}
+
+func (s *FileTokenStore) Save(host, nameOrIdentity, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tokens, err := s.read()
+	if err != nil {
+		return err
+	}
+	tokens[tokenStoreKey(host, nameOrIdentity)] = token
+
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode tokens: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o700); err != nil {
+		return fmt.Errorf("failed to create token directory: %w", err)
+	}
+	if err := os.WriteFile(s.Path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	return nil
+}
+
+func (s *FileTokenStore) read() (map[string]string, error) {
+	tokens := make(map[string]string)
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token file: %w", err)
+	}
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to decode token file %s: %w", s.Path, err)
+	}
+	return tokens, nil
+}